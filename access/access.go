@@ -0,0 +1,160 @@
+// Package access guards contract methods with an owner or with roles.
+//
+// Ownable keeps a single owner. Ownership moves in two steps, so it can't be handed to an account nobody
+// controls: the owner proposes the new owner, which has to accept. The owner can also renounce, leaving the
+// contract without one.
+//
+// Roles is a role-based access list kept in collections. Every role has an admin role whose members grant
+// and revoke it, DefaultAdminRole unless SetRoleAdmin changes it:
+//
+//	// @contract:init
+//	func (c *Contract) Init(owner string) {
+//		c.Owner = access.NewOwnable(owner)
+//		c.Roles = access.NewRoles("r")
+//		c.Roles.InternalGrantRole(access.DefaultAdminRole, owner)
+//	}
+//
+//	// @contract:mutating
+//	func (c *Contract) Mint(accountId string, amount string) error {
+//		if err := c.Roles.RequireRole("minter"); err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// Pausable freezes features of a contract during incidents. Methods check their feature with
+// RequireNotPaused("transfers"), and the owner or a PauseManagerRole member toggles it through
+// pa_pause_feature and pa_unpause_feature.
+//
+// Every change is logged as a NEP-297 event of the "access" standard.
+package access
+
+import (
+	"errors"
+
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/events"
+)
+
+const (
+	ErrNotOwner           = "(ACCESS_ERROR): the predecessor is not the owner"
+	ErrNotPendingOwner    = "(ACCESS_ERROR): the predecessor is not the pending owner"
+	ErrNoPendingOwner     = "(ACCESS_ERROR): there is no pending ownership transfer"
+	ErrMissingRole        = "(ACCESS_ERROR): the predecessor doesn't have the role: "
+	ErrInvalidRole        = "(ACCESS_ERROR): the role name can't be empty"
+	ErrPredecessorUnknown = "(ACCESS_ERROR): failed to get the predecessor account: "
+)
+
+// The standard and version of the access events.
+const (
+	EventStandard = "access"
+	EventVersion  = "1.0.0"
+)
+
+// Access events.
+var (
+	OwnershipTransferStartedEvent = events.NewKind[OwnershipTransfer](EventStandard, EventVersion, "ownership_transfer_started")
+	OwnershipTransferredEvent     = events.NewKind[OwnershipTransfer](EventStandard, EventVersion, "ownership_transferred")
+	RoleGrantedEvent              = events.NewKind[RoleChange](EventStandard, EventVersion, "role_granted")
+	RoleRevokedEvent              = events.NewKind[RoleChange](EventStandard, EventVersion, "role_revoked")
+	RoleAdminChangedEvent         = events.NewKind[RoleAdminChange](EventStandard, EventVersion, "role_admin_changed")
+)
+
+// OwnershipTransfer is the data of the ownership events. An empty owner means the contract has none.
+type OwnershipTransfer struct {
+	OldOwnerID string `json:"old_owner_id"`
+	NewOwnerID string `json:"new_owner_id"`
+}
+
+// RoleChange is the data of the role_granted and role_revoked events. SenderID is the account that made
+// the change.
+type RoleChange struct {
+	Role      string `json:"role"`
+	AccountID string `json:"account_id"`
+	SenderID  string `json:"sender_id"`
+}
+
+// RoleAdminChange is the data of the role_admin_changed event.
+type RoleAdminChange struct {
+	Role              string `json:"role"`
+	PreviousAdminRole string `json:"previous_admin_role"`
+	NewAdminRole      string `json:"new_admin_role"`
+}
+
+func predecessor() (string, error) {
+	accountID, err := env.GetPredecessorAccountID()
+	if err != nil {
+		return "", errors.New(ErrPredecessorUnknown + err.Error())
+	}
+	return accountID, nil
+}
+
+// Ownable is the owner of a contract, kept in its state.
+type Ownable struct {
+	Owner        string `json:"owner"`
+	PendingOwner string `json:"pending_owner"`
+}
+
+// NewOwnable makes owner the owner of the contract.
+func NewOwnable(owner string) *Ownable {
+	OwnershipTransferredEvent.Emit(OwnershipTransfer{NewOwnerID: owner})
+	return &Ownable{Owner: owner}
+}
+
+// IsOwner reports whether accountID is the owner.
+func (o *Ownable) IsOwner(accountID string) bool {
+	return o.Owner != "" && o.Owner == accountID
+}
+
+// RequireOwner fails unless the predecessor is the owner.
+func (o *Ownable) RequireOwner() error {
+	accountID, err := predecessor()
+	if err != nil {
+		return err
+	}
+	if !o.IsOwner(accountID) {
+		return errors.New(ErrNotOwner)
+	}
+	return nil
+}
+
+// TransferOwnership proposes newOwner as the owner; it becomes the owner once it calls AcceptOwnership.
+// Only the owner can call it, and an empty newOwner cancels the pending transfer.
+func (o *Ownable) TransferOwnership(newOwner string) error {
+	if err := o.RequireOwner(); err != nil {
+		return err
+	}
+	o.PendingOwner = newOwner
+	OwnershipTransferStartedEvent.Emit(OwnershipTransfer{OldOwnerID: o.Owner, NewOwnerID: newOwner})
+	return nil
+}
+
+// AcceptOwnership makes the pending owner, which has to be the predecessor, the owner.
+func (o *Ownable) AcceptOwnership() error {
+	if o.PendingOwner == "" {
+		return errors.New(ErrNoPendingOwner)
+	}
+	accountID, err := predecessor()
+	if err != nil {
+		return err
+	}
+	if accountID != o.PendingOwner {
+		return errors.New(ErrNotPendingOwner)
+	}
+	previous := o.Owner
+	o.Owner, o.PendingOwner = accountID, ""
+	OwnershipTransferredEvent.Emit(OwnershipTransfer{OldOwnerID: previous, NewOwnerID: accountID})
+	return nil
+}
+
+// RenounceOwnership leaves the contract without an owner; RequireOwner fails from then on. Only the owner
+// can call it.
+func (o *Ownable) RenounceOwnership() error {
+	if err := o.RequireOwner(); err != nil {
+		return err
+	}
+	previous := o.Owner
+	o.Owner, o.PendingOwner = "", ""
+	OwnershipTransferredEvent.Emit(OwnershipTransfer{OldOwnerID: previous})
+	return nil
+}
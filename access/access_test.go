@@ -0,0 +1,165 @@
+package access
+
+import (
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/testutils"
+)
+
+// call builds the context of a call made by predecessor on top of the state left by the previous one.
+func call(previous *testutils.Context, predecessor string) *testutils.ContextBuilder {
+	return testutils.NewContextBuilder().
+		CurrentAccount("contract.near").
+		Predecessor(predecessor).
+		State(previous.Storage)
+}
+
+func expectError(t *testing.T, err error, expected string) {
+	t.Helper()
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
+
+func TestOwnershipTransfer(t *testing.T) {
+	ctx := testutils.NewContextBuilder().Build()
+	owner := NewOwnable("alice.near")
+	if transfers, _ := OwnershipTransferredEvent.Find(ctx.Logs()); len(transfers) != 1 || transfers[0].NewOwnerID != "alice.near" {
+		t.Errorf("expected an ownership_transferred event, got %+v", transfers)
+	}
+
+	ctx = call(ctx, "bob.near").Build()
+	expectError(t, owner.RequireOwner(), ErrNotOwner)
+	expectError(t, owner.TransferOwnership("bob.near"), ErrNotOwner)
+	expectError(t, owner.AcceptOwnership(), ErrNoPendingOwner)
+
+	ctx = call(ctx, "alice.near").Build()
+	if err := owner.RequireOwner(); err != nil {
+		t.Errorf("expected alice.near to be the owner, got %v", err)
+	}
+	if err := owner.TransferOwnership("bob.near"); err != nil {
+		t.Fatalf("failed to start the transfer: %v", err)
+	}
+	started, _ := OwnershipTransferStartedEvent.Find(ctx.Logs())
+	if len(started) != 1 || started[0] != (OwnershipTransfer{OldOwnerID: "alice.near", NewOwnerID: "bob.near"}) {
+		t.Errorf("unexpected ownership_transfer_started events %+v", started)
+	}
+	if !owner.IsOwner("alice.near") {
+		t.Errorf("expected alice.near to stay the owner until bob.near accepts")
+	}
+
+	ctx = call(ctx, "carol.near").Build()
+	expectError(t, owner.AcceptOwnership(), ErrNotPendingOwner)
+
+	ctx = call(ctx, "bob.near").Build()
+	if err := owner.AcceptOwnership(); err != nil {
+		t.Fatalf("failed to accept: %v", err)
+	}
+	if !owner.IsOwner("bob.near") || owner.PendingOwner != "" {
+		t.Errorf("expected bob.near to be the owner, got %+v", owner)
+	}
+	transferred, _ := OwnershipTransferredEvent.Find(ctx.Logs())
+	if len(transferred) != 1 || transferred[0] != (OwnershipTransfer{OldOwnerID: "alice.near", NewOwnerID: "bob.near"}) {
+		t.Errorf("unexpected ownership_transferred events %+v", transferred)
+	}
+}
+
+func TestRenounceOwnership(t *testing.T) {
+	ctx := testutils.NewContextBuilder().Build()
+	owner := NewOwnable("alice.near")
+
+	call(ctx, "alice.near").Build()
+	if err := owner.TransferOwnership("bob.near"); err != nil {
+		t.Fatalf("failed to start the transfer: %v", err)
+	}
+	if err := owner.RenounceOwnership(); err != nil {
+		t.Fatalf("failed to renounce: %v", err)
+	}
+	expectError(t, owner.RequireOwner(), ErrNotOwner)
+
+	call(ctx, "bob.near").Build()
+	expectError(t, owner.AcceptOwnership(), ErrNoPendingOwner)
+	if owner.IsOwner("") {
+		t.Errorf("expected nobody to own the contract")
+	}
+}
+
+func TestRoles(t *testing.T) {
+	ctx := testutils.NewContextBuilder().Build()
+	roles := NewRoles("r")
+	if err := roles.InternalGrantRole(DefaultAdminRole, "alice.near"); err != nil {
+		t.Fatalf("failed to grant the admin role: %v", err)
+	}
+
+	ctx = call(ctx, "bob.near").Build()
+	expectError(t, roles.GrantRole("minter", "bob.near"), ErrMissingRole+DefaultAdminRole)
+	expectError(t, roles.RequireRole("minter"), ErrMissingRole+"minter")
+
+	ctx = call(ctx, "alice.near").Build()
+	for _, accountID := range []string{"bob.near", "carol.near", "dave.near"} {
+		if err := roles.GrantRole("minter", accountID); err != nil {
+			t.Fatalf("failed to grant minter to %s: %v", accountID, err)
+		}
+	}
+	if err := roles.GrantRole("minter", "bob.near"); err != nil {
+		t.Errorf("expected granting a role twice to do nothing, got %v", err)
+	}
+	granted, _ := RoleGrantedEvent.Find(ctx.Logs())
+	if len(granted) != 3 || granted[0] != (RoleChange{Role: "minter", AccountID: "bob.near", SenderID: "alice.near"}) {
+		t.Errorf("unexpected role_granted events %+v", granted)
+	}
+	if count := roles.RoleMemberCount("minter"); count != 3 {
+		t.Errorf("expected 3 minters, got %d", count)
+	}
+	if members := roles.RoleMembers("minter", 1, 1); len(members) != 1 || members[0] != "carol.near" {
+		t.Errorf("unexpected page of minters %v", members)
+	}
+
+	ctx = call(ctx, "bob.near").Build()
+	if err := roles.RequireRole("minter"); err != nil {
+		t.Errorf("expected bob.near to be a minter, got %v", err)
+	}
+	if err := roles.RenounceRole("minter"); err != nil {
+		t.Fatalf("failed to renounce: %v", err)
+	}
+
+	ctx = call(ctx, "alice.near").Build()
+	if err := roles.RevokeRole("minter", "carol.near"); err != nil {
+		t.Fatalf("failed to revoke: %v", err)
+	}
+	revoked, _ := RoleRevokedEvent.Find(ctx.Logs())
+	if len(revoked) != 1 || revoked[0] != (RoleChange{Role: "minter", AccountID: "carol.near", SenderID: "alice.near"}) {
+		t.Errorf("unexpected role_revoked events %+v", revoked)
+	}
+	if members := roles.RoleMembers("minter", 0, 0); len(members) != 1 || members[0] != "dave.near" {
+		t.Errorf("expected dave.near to be the only minter, got %v", members)
+	}
+}
+
+func TestRoleAdmin(t *testing.T) {
+	ctx := testutils.NewContextBuilder().Build()
+	roles := NewRoles("r")
+	roles.InternalGrantRole(DefaultAdminRole, "alice.near")
+
+	ctx = call(ctx, "alice.near").Build()
+	if err := roles.SetRoleAdmin("minter", "minter_admin"); err != nil {
+		t.Fatalf("failed to set the admin role: %v", err)
+	}
+	changes, _ := RoleAdminChangedEvent.Find(ctx.Logs())
+	if len(changes) != 1 || changes[0] != (RoleAdminChange{Role: "minter", PreviousAdminRole: DefaultAdminRole, NewAdminRole: "minter_admin"}) {
+		t.Errorf("unexpected role_admin_changed events %+v", changes)
+	}
+	expectError(t, roles.GrantRole("minter", "bob.near"), ErrMissingRole+"minter_admin")
+	if err := roles.GrantRole("minter_admin", "bob.near"); err != nil {
+		t.Fatalf("failed to grant minter_admin: %v", err)
+	}
+
+	call(ctx, "bob.near").Build()
+	if err := roles.GrantRole("minter", "carol.near"); err != nil {
+		t.Errorf("expected the minter admin to grant minter, got %v", err)
+	}
+	if !roles.HasRole("minter", "carol.near") || roles.RoleAdmin("minter") != "minter_admin" {
+		t.Errorf("expected carol.near to be a minter")
+	}
+	expectError(t, roles.SetRoleAdmin("", "x"), ErrInvalidRole)
+}
@@ -0,0 +1,172 @@
+package access
+
+import (
+	"errors"
+
+	"github.com/vlmoon99/near-sdk-go/events"
+)
+
+// PauseManagerRole is the role allowed to pause and unpause features besides the owner.
+const PauseManagerRole = "pause_manager"
+
+// MaxFeatures is the number of features a Pausable can hold, one per bit of its mask.
+const MaxFeatures = 64
+
+const (
+	ErrUnknownFeature   = "(ACCESS_ERROR): the feature is not pausable: "
+	ErrTooManyFeatures  = "(ACCESS_ERROR): a Pausable holds at most 64 features"
+	ErrDuplicateFeature = "(ACCESS_ERROR): the feature is declared twice: "
+	ErrFeaturePaused    = "(ACCESS_ERROR): the feature is paused: "
+	ErrFeatureNotPaused = "(ACCESS_ERROR): the feature is not paused: "
+	ErrNotPauseManager  = "(ACCESS_ERROR): the predecessor is neither the owner nor a " + PauseManagerRole
+)
+
+// Pause events.
+var (
+	PausedEvent   = events.NewKind[PauseChange](EventStandard, EventVersion, "paused")
+	UnpausedEvent = events.NewKind[PauseChange](EventStandard, EventVersion, "unpaused")
+)
+
+// PauseChange is the data of the paused and unpaused events.
+type PauseChange struct {
+	Feature   string `json:"feature"`
+	AccountID string `json:"account_id"`
+}
+
+// Pausable keeps which features of a contract are paused, as a bitmask over the declared features. The
+// features are stored in declaration order, which fixes their bits: add new features at the end.
+//
+// The @contract annotations can't declare pausable methods: that needs the code generator, which isn't
+// part of this module. Each pausable method checks its feature itself, first thing:
+//
+//	// @contract:mutating
+//	func (c *Contract) Transfer(receiverId string, amount string) error {
+//		if err := c.Pausable.RequireNotPaused("transfers"); err != nil {
+//			return err
+//		}
+//		...
+//	}
+type Pausable struct {
+	Features []string `json:"features"`
+	Paused   uint64   `json:"paused"`
+}
+
+// NewPausable declares the features that can be paused; none is paused.
+func NewPausable(features ...string) (*Pausable, error) {
+	if len(features) > MaxFeatures {
+		return nil, errors.New(ErrTooManyFeatures)
+	}
+	seen := make(map[string]bool, len(features))
+	for _, feature := range features {
+		if seen[feature] {
+			return nil, errors.New(ErrDuplicateFeature + feature)
+		}
+		seen[feature] = true
+	}
+	return &Pausable{Features: features}, nil
+}
+
+func (p *Pausable) bit(feature string) (uint64, error) {
+	for i, declared := range p.Features {
+		if declared == feature {
+			return 1 << uint(i), nil
+		}
+	}
+	return 0, errors.New(ErrUnknownFeature + feature)
+}
+
+// IsPaused reports whether feature is paused. Unknown features are never paused.
+func (p *Pausable) IsPaused(feature string) bool {
+	bit, err := p.bit(feature)
+	return err == nil && p.Paused&bit != 0
+}
+
+// RequireNotPaused fails when feature is paused.
+func (p *Pausable) RequireNotPaused(feature string) error {
+	if p.IsPaused(feature) {
+		return errors.New(ErrFeaturePaused + feature)
+	}
+	return nil
+}
+
+// RequirePaused fails unless feature is paused, for the methods only allowed during an incident.
+func (p *Pausable) RequirePaused(feature string) error {
+	if !p.IsPaused(feature) {
+		return errors.New(ErrFeatureNotPaused + feature)
+	}
+	return nil
+}
+
+// Pause pauses feature without checking the predecessor. Pausing a paused feature does nothing.
+func (p *Pausable) Pause(feature string) error {
+	bit, err := p.bit(feature)
+	if err != nil {
+		return err
+	}
+	if p.Paused&bit != 0 {
+		return nil
+	}
+	p.Paused |= bit
+	accountID, _ := predecessor()
+	PausedEvent.Emit(PauseChange{Feature: feature, AccountID: accountID})
+	return nil
+}
+
+// Unpause resumes feature without checking the predecessor. Resuming a running feature does nothing.
+func (p *Pausable) Unpause(feature string) error {
+	bit, err := p.bit(feature)
+	if err != nil {
+		return err
+	}
+	if p.Paused&bit == 0 {
+		return nil
+	}
+	p.Paused &^= bit
+	accountID, _ := predecessor()
+	UnpausedEvent.Emit(PauseChange{Feature: feature, AccountID: accountID})
+	return nil
+}
+
+// PausedFeatures returns the paused features in declaration order.
+func (p *Pausable) PausedFeatures() []string {
+	paused := []string{}
+	for i, feature := range p.Features {
+		if p.Paused&(1<<uint(i)) != 0 {
+			paused = append(paused, feature)
+		}
+	}
+	return paused
+}
+
+// requireManager fails unless the predecessor is the owner or a pause manager. Either may be nil.
+func requireManager(owner *Ownable, roles *Roles) error {
+	accountID, err := predecessor()
+	if err != nil {
+		return err
+	}
+	if (owner != nil && owner.IsOwner(accountID)) || (roles != nil && roles.HasRole(PauseManagerRole, accountID)) {
+		return nil
+	}
+	return errors.New(ErrNotPauseManager)
+}
+
+// PaPauseFeature implements pa_pause_feature: the owner or a pause manager pauses feature.
+func (p *Pausable) PaPauseFeature(feature string, owner *Ownable, roles *Roles) error {
+	if err := requireManager(owner, roles); err != nil {
+		return err
+	}
+	return p.Pause(feature)
+}
+
+// PaUnpauseFeature implements pa_unpause_feature: the owner or a pause manager resumes feature.
+func (p *Pausable) PaUnpauseFeature(feature string, owner *Ownable, roles *Roles) error {
+	if err := requireManager(owner, roles); err != nil {
+		return err
+	}
+	return p.Unpause(feature)
+}
+
+// PaAllPaused implements the pa_all_paused view.
+func (p *Pausable) PaAllPaused() []string {
+	return p.PausedFeatures()
+}
@@ -0,0 +1,67 @@
+package access
+
+import (
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/testutils"
+)
+
+func TestPausable(t *testing.T) {
+	ctx := testutils.NewContextBuilder().Build()
+	owner := NewOwnable("alice.near")
+	roles := NewRoles("r")
+	roles.InternalGrantRole(PauseManagerRole, "bob.near")
+	pausable, err := NewPausable("transfers", "minting")
+	if err != nil {
+		t.Fatalf("failed to create: %v", err)
+	}
+
+	ctx = call(ctx, "carol.near").Build()
+	expectError(t, pausable.PaPauseFeature("transfers", owner, roles), ErrNotPauseManager)
+
+	ctx = call(ctx, "bob.near").Build()
+	if err := pausable.PaPauseFeature("minting", owner, roles); err != nil {
+		t.Fatalf("expected a pause manager to pause, got %v", err)
+	}
+	ctx = call(ctx, "alice.near").Build()
+	if err := pausable.PaPauseFeature("transfers", owner, nil); err != nil {
+		t.Fatalf("expected the owner to pause, got %v", err)
+	}
+	paused, _ := PausedEvent.Find(ctx.Logs())
+	if len(paused) != 1 || paused[0] != (PauseChange{Feature: "transfers", AccountID: "alice.near"}) {
+		t.Errorf("unexpected paused events %+v", paused)
+	}
+	if all := pausable.PaAllPaused(); len(all) != 2 || all[0] != "transfers" || all[1] != "minting" {
+		t.Errorf("expected both features to be paused, got %v", all)
+	}
+	expectError(t, pausable.RequireNotPaused("transfers"), ErrFeaturePaused+"transfers")
+	expectError(t, pausable.PaPauseFeature("voting", owner, nil), ErrUnknownFeature+"voting")
+
+	ctx = call(ctx, "alice.near").Build()
+	if err := pausable.PaUnpauseFeature("transfers", owner, roles); err != nil {
+		t.Fatalf("failed to unpause: %v", err)
+	}
+	if unpaused, _ := UnpausedEvent.Find(ctx.Logs()); len(unpaused) != 1 {
+		t.Errorf("expected an unpaused event, got %+v", unpaused)
+	}
+	if err := pausable.RequireNotPaused("transfers"); err != nil {
+		t.Errorf("expected transfers to run, got %v", err)
+	}
+	expectError(t, pausable.RequirePaused("transfers"), ErrFeatureNotPaused+"transfers")
+	if pausable.Paused != 2 {
+		t.Errorf("expected only the minting bit to be set, got %b", pausable.Paused)
+	}
+}
+
+func TestNewPausableErrors(t *testing.T) {
+	if _, err := NewPausable("a", "a"); err == nil || err.Error() != ErrDuplicateFeature+"a" {
+		t.Errorf("expected %q, got %v", ErrDuplicateFeature+"a", err)
+	}
+	features := make([]string, MaxFeatures+1)
+	for i := range features {
+		features[i] = string(rune('A' + i))
+	}
+	if _, err := NewPausable(features...); err == nil || err.Error() != ErrTooManyFeatures {
+		t.Errorf("expected %q, got %v", ErrTooManyFeatures, err)
+	}
+}
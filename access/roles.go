@@ -0,0 +1,180 @@
+package access
+
+import (
+	"errors"
+
+	"github.com/vlmoon99/near-sdk-go/collections"
+)
+
+// MaxRoleMembersLimit is the most members RoleMembers returns at once.
+const MaxRoleMembersLimit = 100
+
+// DefaultAdminRole is the admin of the roles without an admin of their own, itself included.
+const DefaultAdminRole = "default_admin"
+
+// Roles keeps the members of every role and the admin role of the roles that have one.
+type Roles struct {
+	Members *collections.LookupMap[string, collections.UnorderedSet[string]] `json:"members"`
+	Admins  *collections.LookupMap[string, string]                           `json:"admins"`
+	Prefix  string                                                           `json:"prefix"`
+}
+
+// NewRoles creates an empty access list stored under prefix: the members under prefix+"m", the admin roles
+// under prefix+"a" and the member set of each role under prefix+"s"+role.
+func NewRoles(prefix string) *Roles {
+	return &Roles{
+		Members: collections.NewLookupMap[string, collections.UnorderedSet[string]](prefix + "m"),
+		Admins:  collections.NewLookupMap[string, string](prefix + "a"),
+		Prefix:  prefix,
+	}
+}
+
+// HasRole reports whether accountID is a member of role.
+func (r *Roles) HasRole(role, accountID string) bool {
+	members, err := r.Members.Get(role)
+	if err != nil {
+		return false
+	}
+	found, err := members.Contains(accountID)
+	return err == nil && found
+}
+
+// RequireRole fails unless the predecessor is a member of role.
+func (r *Roles) RequireRole(role string) error {
+	accountID, err := predecessor()
+	if err != nil {
+		return err
+	}
+	if !r.HasRole(role, accountID) {
+		return errors.New(ErrMissingRole + role)
+	}
+	return nil
+}
+
+// RoleAdmin returns the role whose members grant and revoke role.
+func (r *Roles) RoleAdmin(role string) string {
+	admin, err := r.Admins.Get(role)
+	if err != nil {
+		return DefaultAdminRole
+	}
+	return admin
+}
+
+// SetRoleAdmin makes adminRole the admin of role. The predecessor must be a member of the current admin
+// role.
+func (r *Roles) SetRoleAdmin(role, adminRole string) error {
+	if role == "" || adminRole == "" {
+		return errors.New(ErrInvalidRole)
+	}
+	previous := r.RoleAdmin(role)
+	if err := r.RequireRole(previous); err != nil {
+		return err
+	}
+	if err := r.Admins.Insert(role, adminRole); err != nil {
+		return err
+	}
+	RoleAdminChangedEvent.Emit(RoleAdminChange{Role: role, PreviousAdminRole: previous, NewAdminRole: adminRole})
+	return nil
+}
+
+// GrantRole adds accountID to role. The predecessor must be a member of the admin role of role.
+func (r *Roles) GrantRole(role, accountID string) error {
+	if err := r.RequireRole(r.RoleAdmin(role)); err != nil {
+		return err
+	}
+	return r.InternalGrantRole(role, accountID)
+}
+
+// RevokeRole removes accountID from role. The predecessor must be a member of the admin role of role.
+func (r *Roles) RevokeRole(role, accountID string) error {
+	if err := r.RequireRole(r.RoleAdmin(role)); err != nil {
+		return err
+	}
+	return r.InternalRevokeRole(role, accountID)
+}
+
+// RenounceRole removes the predecessor from role.
+func (r *Roles) RenounceRole(role string) error {
+	accountID, err := predecessor()
+	if err != nil {
+		return err
+	}
+	return r.InternalRevokeRole(role, accountID)
+}
+
+// InternalGrantRole adds accountID to role without checking the predecessor, for example to set up the
+// first admin in the initializer. Granting a role to a member does nothing.
+func (r *Roles) InternalGrantRole(role, accountID string) error {
+	if role == "" {
+		return errors.New(ErrInvalidRole)
+	}
+	if r.HasRole(role, accountID) {
+		return nil
+	}
+	members, err := r.Members.Get(role)
+	if err != nil {
+		members = *collections.NewUnorderedSet[string](r.Prefix + "s" + role)
+	}
+	if err := members.Insert(accountID); err != nil {
+		return err
+	}
+	if err := r.Members.Insert(role, members); err != nil {
+		return err
+	}
+	sender, _ := predecessor()
+	RoleGrantedEvent.Emit(RoleChange{Role: role, AccountID: accountID, SenderID: sender})
+	return nil
+}
+
+// InternalRevokeRole removes accountID from role without checking the predecessor. Revoking a role from an
+// account that doesn't have it does nothing.
+func (r *Roles) InternalRevokeRole(role, accountID string) error {
+	if !r.HasRole(role, accountID) {
+		return nil
+	}
+	members, err := r.Members.Get(role)
+	if err != nil {
+		return err
+	}
+	if err := members.Remove(accountID); err != nil {
+		return err
+	}
+	if members.Length() == 0 {
+		err = r.Members.Remove(role)
+	} else {
+		err = r.Members.Insert(role, members)
+	}
+	if err != nil {
+		return err
+	}
+	sender, _ := predecessor()
+	RoleRevokedEvent.Emit(RoleChange{Role: role, AccountID: accountID, SenderID: sender})
+	return nil
+}
+
+// RoleMemberCount returns the number of members of role.
+func (r *Roles) RoleMemberCount(role string) uint64 {
+	members, err := r.Members.Get(role)
+	if err != nil {
+		return 0
+	}
+	return members.Length()
+}
+
+// RoleMembers returns up to limit members of role starting at fromIndex, reading only those members. A zero
+// limit, or one above MaxRoleMembersLimit, returns MaxRoleMembersLimit members. The order is stable while the
+// members don't change.
+func (r *Roles) RoleMembers(role string, fromIndex, limit uint64) []string {
+	members, err := r.Members.Get(role)
+	if err != nil {
+		return nil
+	}
+	if limit == 0 || limit > MaxRoleMembersLimit {
+		limit = MaxRoleMembersLimit
+	}
+	page, err := members.Range(fromIndex, limit)
+	if err != nil || len(page) == 0 {
+		return nil
+	}
+	return page
+}
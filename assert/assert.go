@@ -0,0 +1,182 @@
+// Package assert checks the context of a call the way near-sdk-rs does with assert_one_yocto,
+// require_private and its siblings.
+//
+// Every check returns nil or an *Error, whose Kind tells which check failed and whose Expected and
+// Actual hold the values compared, so callers can inspect the failure instead of parsing the message:
+//
+//	// @contract:mutating
+//	func (c *Contract) FtTransfer(receiverId string, amount string) error {
+//		if err := assert.OneYocto(); err != nil {
+//			return err
+//		}
+//		...
+//	}
+package assert
+
+import (
+	"strconv"
+
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+// The kinds of Error, which are also the start of its message.
+const (
+	ErrNotOneYocto       = "(ASSERT_ERROR): requires an attached deposit of exactly 1 yoctoNEAR"
+	ErrNotPrivate        = "(ASSERT_ERROR): the method is private"
+	ErrWrongPredecessor  = "(ASSERT_ERROR): the predecessor is not the expected account"
+	ErrWrongSigner       = "(ASSERT_ERROR): the signer is not the expected account"
+	ErrDepositTooLow     = "(ASSERT_ERROR): the attached deposit is too low"
+	ErrGasTooLow         = "(ASSERT_ERROR): the prepaid gas is too low"
+	ErrDepositAttached   = "(ASSERT_ERROR): the method doesn't accept deposits"
+	ErrStorageNotCovered = "(ASSERT_ERROR): the account balance doesn't cover its storage"
+	ErrContextUnknown    = "(ASSERT_ERROR): failed to read the context of the call"
+)
+
+// Error is a failed check.
+type Error struct {
+	// Kind is one of the Err constants.
+	Kind     string `json:"kind"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Expected == "" && e.Actual == "" {
+		return e.Kind
+	}
+	return e.Kind + ": expected " + e.Expected + ", got " + e.Actual
+}
+
+func fail(kind, expected, actual string) error {
+	return &Error{Kind: kind, Expected: expected, Actual: actual}
+}
+
+var (
+	zero     = types.Uint128{Hi: 0, Lo: 0}
+	oneYocto = types.Uint128{Hi: 0, Lo: 1}
+)
+
+func deposit() (types.Uint128, error) {
+	amount, err := env.GetAttachedDeposit()
+	if err != nil {
+		return zero, fail(ErrContextUnknown, "", err.Error())
+	}
+	return amount, nil
+}
+
+// OneYocto fails unless exactly 1 yoctoNEAR is attached, which proves the call was signed with a full
+// access key.
+func OneYocto() error {
+	amount, err := deposit()
+	if err != nil {
+		return err
+	}
+	if amount.Cmp(oneYocto) != 0 {
+		return fail(ErrNotOneYocto, oneYocto.String(), amount.String())
+	}
+	return nil
+}
+
+// Private fails unless the contract calls itself, as callbacks do.
+func Private() error {
+	current, err := env.GetCurrentAccountId()
+	if err != nil {
+		return fail(ErrContextUnknown, "", err.Error())
+	}
+	predecessor, err := env.GetPredecessorAccountID()
+	if err != nil {
+		return fail(ErrContextUnknown, "", err.Error())
+	}
+	if predecessor != current {
+		return fail(ErrNotPrivate, current, predecessor)
+	}
+	return nil
+}
+
+// Predecessor fails unless accountID is the predecessor of the call.
+func Predecessor(accountID string) error {
+	predecessor, err := env.GetPredecessorAccountID()
+	if err != nil {
+		return fail(ErrContextUnknown, "", err.Error())
+	}
+	if predecessor != accountID {
+		return fail(ErrWrongPredecessor, accountID, predecessor)
+	}
+	return nil
+}
+
+// Signer fails unless accountID signed the transaction.
+func Signer(accountID string) error {
+	signer, err := env.GetSignerAccountID()
+	if err != nil {
+		return fail(ErrContextUnknown, "", err.Error())
+	}
+	if signer != accountID {
+		return fail(ErrWrongSigner, accountID, signer)
+	}
+	return nil
+}
+
+// MinDeposit fails when less than minDeposit yoctoNEAR is attached.
+func MinDeposit(minDeposit types.Uint128) error {
+	amount, err := deposit()
+	if err != nil {
+		return err
+	}
+	if amount.Cmp(minDeposit) < 0 {
+		return fail(ErrDepositTooLow, minDeposit.String(), amount.String())
+	}
+	return nil
+}
+
+// MinGas fails when less than gas is left to the call.
+func MinGas(gas uint64) error {
+	prepaid, used := env.GetPrepaidGas().Inner, env.GetUsedGas().Inner
+	left := uint64(0)
+	if prepaid > used {
+		left = prepaid - used
+	}
+	if left < gas {
+		return fail(ErrGasTooLow, strconv.FormatUint(gas, 10), strconv.FormatUint(left, 10))
+	}
+	return nil
+}
+
+// NoDeposit fails when a deposit is attached, for mutating methods that would otherwise keep it.
+func NoDeposit() error {
+	amount, err := deposit()
+	if err != nil {
+		return err
+	}
+	if amount.Cmp(zero) != 0 {
+		return fail(ErrDepositAttached, zero.String(), amount.String())
+	}
+	return nil
+}
+
+// StorageCovered fails when the balance of the contract, locked balance included, doesn't cover the
+// storage it uses. Calling it after the writes of a method turns the LackBalanceForState failure of the
+// runtime into an error the method returns.
+func StorageCovered() error {
+	required, err := types.U64ToUint128(env.GetStorageUsage()).SafeMul64(types.STORAGE_PRICE_PER_BYTE)
+	if err != nil {
+		return fail(ErrContextUnknown, "", err.Error())
+	}
+	balance, err := env.GetAccountBalance()
+	if err != nil {
+		return fail(ErrContextUnknown, "", err.Error())
+	}
+	locked, err := env.GetAccountLockedBalance()
+	if err != nil {
+		return fail(ErrContextUnknown, "", err.Error())
+	}
+	available, err := balance.Add(locked)
+	if err != nil {
+		return fail(ErrContextUnknown, "", err.Error())
+	}
+	if available.Cmp(required) < 0 {
+		return fail(ErrStorageNotCovered, required.String(), available.String())
+	}
+	return nil
+}
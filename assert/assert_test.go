@@ -0,0 +1,94 @@
+package assert
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/testutils"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+func expectKind(t *testing.T, err error, kind string) *Error {
+	t.Helper()
+	var failure *Error
+	if !errors.As(err, &failure) || failure.Kind != kind {
+		t.Fatalf("expected a %q failure, got %v", kind, err)
+	}
+	return failure
+}
+
+func TestOneYocto(t *testing.T) {
+	testutils.NewContextBuilder().DepositYocto(types.Uint128{Hi: 0, Lo: 1}).Build()
+	if err := OneYocto(); err != nil {
+		t.Errorf("expected 1 yocto to pass, got %v", err)
+	}
+
+	testutils.NewContextBuilder().DepositYocto(types.Uint128{Hi: 1, Lo: 1}).Build()
+	failure := expectKind(t, OneYocto(), ErrNotOneYocto)
+	if failure.Expected != "1" || failure.Actual != "18446744073709551617" {
+		t.Errorf("unexpected failure %+v", failure)
+	}
+
+	testutils.NewContextBuilder().Build()
+	expectKind(t, OneYocto(), ErrNotOneYocto)
+}
+
+func TestAccounts(t *testing.T) {
+	testutils.NewContextBuilder().CurrentAccount("contract.near").Predecessor("contract.near").Signer("alice.near").Build()
+	if err := Private(); err != nil {
+		t.Errorf("expected a call from the contract to be private, got %v", err)
+	}
+	if err := Signer("alice.near"); err != nil {
+		t.Errorf("expected alice.near to be the signer, got %v", err)
+	}
+	expectKind(t, Predecessor("alice.near"), ErrWrongPredecessor)
+
+	testutils.NewContextBuilder().CurrentAccount("contract.near").Predecessor("alice.near").Signer("alice.near").Build()
+	failure := expectKind(t, Private(), ErrNotPrivate)
+	if failure.Error() != ErrNotPrivate+": expected contract.near, got alice.near" {
+		t.Errorf("unexpected message %q", failure.Error())
+	}
+	if err := Predecessor("alice.near"); err != nil {
+		t.Errorf("expected alice.near to be the predecessor, got %v", err)
+	}
+	expectKind(t, Signer("bob.near"), ErrWrongSigner)
+}
+
+func TestDeposits(t *testing.T) {
+	minimum := types.Uint128{Hi: 1, Lo: 5}
+
+	testutils.NewContextBuilder().DepositYocto(types.Uint128{Hi: 2, Lo: 0}).Build()
+	if err := MinDeposit(minimum); err != nil {
+		t.Errorf("expected a larger Hi to cover the minimum, got %v", err)
+	}
+	expectKind(t, NoDeposit(), ErrDepositAttached)
+
+	testutils.NewContextBuilder().DepositYocto(types.Uint128{Hi: 0, Lo: 6}).Build()
+	expectKind(t, MinDeposit(minimum), ErrDepositTooLow)
+
+	testutils.NewContextBuilder().Build()
+	if err := NoDeposit(); err != nil {
+		t.Errorf("expected no deposit to pass, got %v", err)
+	}
+}
+
+func TestMinGas(t *testing.T) {
+	testutils.NewContextBuilder().Gas(30 * types.ONE_TERA_GAS).Build()
+	if err := MinGas(10 * types.ONE_TERA_GAS); err != nil {
+		t.Errorf("expected enough gas, got %v", err)
+	}
+	expectKind(t, MinGas(300*types.ONE_TERA_GAS), ErrGasTooLow)
+}
+
+func TestStorageCovered(t *testing.T) {
+	state := map[string][]byte{"key": make([]byte, 1000)}
+
+	testutils.NewContextBuilder().Balance(types.Uint128{Hi: 0, Lo: 0}).State(state).Build()
+	expectKind(t, StorageCovered(), ErrStorageNotCovered)
+
+	balance, _ := types.U128FromString("1000000000000000000000000")
+	testutils.NewContextBuilder().Balance(balance).State(state).Build()
+	if err := StorageCovered(); err != nil {
+		t.Errorf("expected 1 NEAR to cover the storage, got %v", err)
+	}
+}
@@ -370,6 +370,29 @@ func (m *UnorderedMap[K, V]) Keys() ([]K, error) {
 	return result, nil
 }
 
+// KeysRange returns up to limit keys in index order, starting at fromIndex, reading only those keys.
+// A zero limit returns all the remaining keys.
+func (m *UnorderedMap[K, V]) KeysRange(fromIndex, limit uint64) ([]K, error) {
+	if fromIndex >= m.Len {
+		return []K{}, nil
+	}
+	end := m.Len
+	if limit > 0 && limit < end-fromIndex {
+		end = fromIndex + limit
+	}
+	result := make([]K, 0, end-fromIndex)
+	for i := fromIndex; i < end; i++ {
+		data, err := env.StorageRead([]byte(createKey(m.keyPrefix(), i)))
+		if err != nil {
+			return nil, err
+		}
+		var k K
+		json.Unmarshal(data, &k)
+		result = append(result, k)
+	}
+	return result, nil
+}
+
 func (m *UnorderedMap[K, V]) Values() ([]V, error) {
 	keys, err := m.Keys()
 	if err != nil {
@@ -503,6 +526,29 @@ func (s *UnorderedSet[T]) All() ([]T, error) {
 	return result, nil
 }
 
+// Range returns up to limit elements in index order, starting at fromIndex, reading only those elements.
+// A zero limit returns all the remaining elements.
+func (s *UnorderedSet[T]) Range(fromIndex, limit uint64) ([]T, error) {
+	if fromIndex >= s.Len {
+		return []T{}, nil
+	}
+	end := s.Len
+	if limit > 0 && limit < end-fromIndex {
+		end = fromIndex + limit
+	}
+	result := make([]T, 0, end-fromIndex)
+	for i := fromIndex; i < end; i++ {
+		data, err := env.StorageRead([]byte(createKey(s.elemPrefix(), i)))
+		if err != nil {
+			return nil, err
+		}
+		var val T
+		json.Unmarshal(data, &val)
+		result = append(result, val)
+	}
+	return result, nil
+}
+
 func (s *UnorderedSet[T]) Clear() error {
 	items, err := s.All()
 	if err != nil {
@@ -681,6 +727,27 @@ func (m *TreeMap[K, V]) Keys() ([]K, error) {
 	return result, nil
 }
 
+// KeysRange returns up to limit keys in order, starting at fromIndex, reading only those keys.
+// A zero limit returns all the remaining keys.
+func (m *TreeMap[K, V]) KeysRange(fromIndex, limit uint64) ([]K, error) {
+	if fromIndex >= m.Len {
+		return []K{}, nil
+	}
+	end := m.Len
+	if limit > 0 && limit < end-fromIndex {
+		end = fromIndex + limit
+	}
+	result := make([]K, 0, end-fromIndex)
+	for i := fromIndex; i < end; i++ {
+		k, err := m.getKeyAt(i)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, k)
+	}
+	return result, nil
+}
+
 func (m *TreeMap[K, V]) Clear() error {
 	keys, err := m.Keys()
 	if err != nil {
@@ -264,6 +264,25 @@ func TestUnorderedMap_Clear(t *testing.T) {
 	}
 }
 
+func TestUnorderedMap_KeysRange(t *testing.T) {
+	defer cleanupStorage(t)
+	m := NewUnorderedMap[string, int]("um")
+
+	for i, k := range []string{"a", "b", "c", "d"} {
+		m.Insert(k, i)
+	}
+
+	if keys, err := m.KeysRange(1, 2); err != nil || len(keys) != 2 || keys[0] != "b" || keys[1] != "c" {
+		t.Errorf("Expected [b c], got %v: %v", keys, err)
+	}
+	if keys, _ := m.KeysRange(2, 0); len(keys) != 2 || keys[0] != "c" || keys[1] != "d" {
+		t.Errorf("Expected [c d], got %v", keys)
+	}
+	if keys, _ := m.KeysRange(4, 1); len(keys) != 0 {
+		t.Errorf("Expected no keys, got %v", keys)
+	}
+}
+
 // ============================================================================
 // UnorderedSet Tests
 // ============================================================================
@@ -301,6 +320,25 @@ func TestUnorderedSet(t *testing.T) {
 	}
 }
 
+func TestUnorderedSet_Range(t *testing.T) {
+	defer cleanupStorage(t)
+	s := NewUnorderedSet[string]("us")
+
+	for _, v := range []string{"a", "b", "c", "d"} {
+		s.Insert(v)
+	}
+
+	if values, err := s.Range(1, 2); err != nil || len(values) != 2 || values[0] != "b" || values[1] != "c" {
+		t.Errorf("Expected [b c], got %v: %v", values, err)
+	}
+	if values, _ := s.Range(2, 0); len(values) != 2 || values[0] != "c" || values[1] != "d" {
+		t.Errorf("Expected [c d], got %v", values)
+	}
+	if values, _ := s.Range(4, 1); len(values) != 0 {
+		t.Errorf("Expected no values, got %v", values)
+	}
+}
+
 // ============================================================================
 // TreeMap Tests
 // ============================================================================
@@ -349,6 +387,32 @@ func TestTreeMap_MinMax(t *testing.T) {
 	}
 }
 
+func TestTreeMap_KeysRange(t *testing.T) {
+	defer cleanupStorage(t)
+	tm := NewTreeMap[int, int]("tm")
+
+	for _, k := range []int{40, 10, 30, 20, 50} {
+		tm.Insert(k, k)
+	}
+
+	keys, err := tm.KeysRange(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0] != 20 || keys[1] != 30 {
+		t.Errorf("Expected [20, 30], got %v", keys)
+	}
+	if keys, _ := tm.KeysRange(3, 0); len(keys) != 2 || keys[0] != 40 || keys[1] != 50 {
+		t.Errorf("Expected [40, 50], got %v", keys)
+	}
+	if keys, _ := tm.KeysRange(4, 10); len(keys) != 1 || keys[0] != 50 {
+		t.Errorf("Expected [50], got %v", keys)
+	}
+	if keys, _ := tm.KeysRange(5, 1); len(keys) != 0 {
+		t.Errorf("Expected no keys, got %v", keys)
+	}
+}
+
 func TestTreeMap_Remove_Rebalance(t *testing.T) {
 	defer cleanupStorage(t)
 	tm := NewTreeMap[int, int]("tm")
@@ -13,51 +13,51 @@ import (
 func HandleClientJSONInput(fn func(*ContractInput) error) {
 	input, err := GetJSONInput()
 	if err != nil {
-		env.PanicStr("failed to get input: " + err.Error())
+		PanicError("failed to get input: ", err)
 	}
 	if err := fn(input); err != nil {
-		env.PanicStr(err.Error())
+		PanicError("", err)
 	}
 }
 
 func HandleClientRawBytesInput(fn func(*ContractInput) error) {
 	input, err := GetRawBytesInput()
 	if err != nil {
-		env.PanicStr("failed to get input: " + err.Error())
+		PanicError("failed to get input: ", err)
 	}
 	if err := fn(input); err != nil {
-		env.PanicStr(err.Error())
+		PanicError("", err)
 	}
 }
 
 func HandlePromiseResult(fn func(*promise.PromiseResult) error) {
 	if err := promise.CallbackGuard(); err != nil {
-		env.PanicStr("callback rejected: " + err.Error())
+		PanicError("callback rejected: ", err)
 	}
 
 	result, err := promise.GetPromiseResultSafe(0)
 	if err != nil {
-		env.PanicStr("failed to get promise result: " + err.Error())
+		PanicError("failed to get promise result: ", err)
 	}
 
 	if err := fn(&result); err != nil {
-		env.PanicStr(err.Error())
+		PanicError("", err)
 	}
 }
 
 // HandlePromiseResults fetches ALL promise results as a slice
 func HandlePromiseResults(fn func([]promise.PromiseResult) error) {
 	if err := promise.CallbackGuard(); err != nil {
-		env.PanicStr("callback rejected: " + err.Error())
+		PanicError("callback rejected: ", err)
 	}
 
 	results, err := promise.GetAllPromiseResults()
 	if err != nil {
-		env.PanicStr("failed to get promise results: " + err.Error())
+		PanicError("failed to get promise results: ", err)
 	}
 
 	if err := fn(results); err != nil {
-		env.PanicStr(err.Error())
+		PanicError("", err)
 	}
 }
 
@@ -133,8 +133,7 @@ func GetContext() *ContractContext {
 
 func RequireDeposit(minDeposit types.Uint128) error {
 	context := GetContext()
-	if context.AttachedDeposit.Lo < minDeposit.Lo ||
-		(context.AttachedDeposit.Lo == minDeposit.Lo && context.AttachedDeposit.Hi < minDeposit.Hi) {
+	if context.AttachedDeposit.Cmp(minDeposit) < 0 {
 		return errors.New("insufficient deposit")
 	}
 	return nil
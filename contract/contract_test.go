@@ -98,6 +98,8 @@ func TestRequireDeposit(t *testing.T) {
 	equalDeposit, _ := types.U128FromString("10000000000000000000000000")
 	sufficientDeposit, _ := types.U128FromString("11000000000000000000000000")
 	insufficientDeposit, _ := types.U128FromString("1000")
+	largerHiSmallerLo := types.Uint128{Hi: 2, Lo: 0}
+	smallerHiLargerLo := types.Uint128{Hi: 1, Lo: 5}
 
 	tests := []struct {
 		name            string
@@ -123,6 +125,18 @@ func TestRequireDeposit(t *testing.T) {
 			attachedDeposit: equalDeposit,
 			wantErr:         false,
 		},
+		{
+			name:            "Larger Hi with a smaller Lo",
+			minDeposit:      smallerHiLargerLo,
+			attachedDeposit: largerHiSmallerLo,
+			wantErr:         false,
+		},
+		{
+			name:            "Smaller Hi with a larger Lo",
+			minDeposit:      largerHiSmallerLo,
+			attachedDeposit: smallerHiLargerLo,
+			wantErr:         true,
+		},
 	}
 
 	for _, tt := range tests {
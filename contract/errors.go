@@ -0,0 +1,134 @@
+package contract
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/vlmoon99/near-sdk-go/env"
+)
+
+// ContractError is an error clients can match by its code instead of its text. It panics with a JSON
+// object whose fields always come in the same order:
+//
+//	{"code":"NOT_ENOUGH_BALANCE","message":"the sender balance is too low","details":{"balance":"10"}}
+//
+// Details is omitted when empty. Codes are part of the contract interface: declare them with DeclareErrors
+// so clients can list them through the contract_errors view, and don't reuse a code for another failure.
+type ContractError struct {
+	Code    string          `json:"code"`
+	Message string          `json:"message"`
+	Details json.RawMessage `json:"details,omitempty"`
+}
+
+// NewError creates an error without details. Define errors once, as package variables, and attach details
+// to copies with WithDetails.
+func NewError(code, message string) *ContractError {
+	return &ContractError{Code: code, Message: message}
+}
+
+// WithDetails returns a copy of e with details encoded to JSON. When details can't be encoded, the copy
+// has none.
+func (e *ContractError) WithDetails(details interface{}) *ContractError {
+	copied := *e
+	copied.Details = nil
+	if data, err := json.Marshal(details); err == nil {
+		copied.Details = data
+	}
+	return &copied
+}
+
+// Error returns the JSON panic message. Details that aren't valid JSON are left out.
+func (e *ContractError) Error() string {
+	data, err := json.Marshal(e)
+	if err != nil {
+		data, _ = json.Marshal(ContractError{Code: e.Code, Message: e.Message})
+	}
+	return string(data)
+}
+
+// Is reports whether target is a ContractError with the same code, so errors.Is matches errors created by
+// WithDetails against the variable they were copied from.
+func (e *ContractError) Is(target error) bool {
+	var other *ContractError
+	return errors.As(target, &other) && other.Code == e.Code
+}
+
+// ParseError reads a ContractError back from a panic message, as clients and tests receive it.
+func ParseError(message string) (*ContractError, bool) {
+	var e ContractError
+	if err := json.Unmarshal([]byte(message), &e); err != nil || e.Code == "" {
+		return nil, false
+	}
+	return &e, true
+}
+
+// PanicError panics with err. A ContractError anywhere in the chain of err panics with its JSON message,
+// without the text of the errors wrapping it; other errors panic with prefix followed by their text.
+func PanicError(prefix string, err error) {
+	var contractError *ContractError
+	if errors.As(err, &contractError) {
+		env.PanicStr(contractError.Error())
+		return
+	}
+	env.PanicStr(prefix + err.Error())
+}
+
+// ErrorsMethod is the view method returning the declared errors of every method.
+const ErrorsMethod = "contract_errors"
+
+// MethodErrors lists the errors a method can fail with.
+type MethodErrors struct {
+	Name   string          `json:"name"`
+	Errors []ContractError `json:"errors"`
+}
+
+var declaredErrors = map[string][]ContractError{}
+
+// DeclareErrors records that method can fail with errs. It is called from init functions, and the contract
+// exports the contract_errors view returning the declarations. The SDK generates no ABI, so this view stands
+// in for the errors section of one: clients read it to learn the codes each method can fail with.
+//
+//	func init() {
+//		contract.DeclareErrors("transfer", ErrNotEnoughBalance, ErrUnknownAccount)
+//		contract.DeclareErrors("transfer", contract.HandleErrors...)
+//	}
+//
+//	//go:export contract_errors
+//	func ContractErrors() {
+//		contract.ErrorsView()
+//	}
+//
+// Methods exported with Handle can also fail with HandleErrors; declare them as well.
+func DeclareErrors(method string, errs ...*ContractError) {
+	for _, e := range errs {
+		declared := ContractError{Code: e.Code, Message: e.Message}
+		duplicate := false
+		for _, existing := range declaredErrors[method] {
+			if existing.Code == declared.Code {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			declaredErrors[method] = append(declaredErrors[method], declared)
+		}
+	}
+}
+
+// DeclaredErrors returns the declared errors of every method, sorted by method name.
+func DeclaredErrors() []MethodErrors {
+	methods := make([]MethodErrors, 0, len(declaredErrors))
+	for name, errs := range declaredErrors {
+		methods = append(methods, MethodErrors{Name: name, Errors: append([]ContractError{}, errs...)})
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name < methods[j].Name })
+	return methods
+}
+
+// ErrorsView returns DeclaredErrors to the caller; it's the body of the exported contract_errors method.
+func ErrorsView() {
+	if err := ReturnValue(DeclaredErrors()); err != nil {
+		env.PanicStr(err.Error())
+	}
+}
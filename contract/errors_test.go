@@ -0,0 +1,128 @@
+package contract
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/sim"
+	"github.com/vlmoon99/near-sdk-go/testutils"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+var errNotEnoughBalance = NewError("NOT_ENOUGH_BALANCE", "the sender balance is too low")
+
+func TestContractError(t *testing.T) {
+	detailed := errNotEnoughBalance.WithDetails(map[string]string{"balance": "10"})
+	expected := `{"code":"NOT_ENOUGH_BALANCE","message":"the sender balance is too low","details":{"balance":"10"}}`
+	if detailed.Error() != expected {
+		t.Errorf("expected %s, got %s", expected, detailed.Error())
+	}
+	if errNotEnoughBalance.Error() != `{"code":"NOT_ENOUGH_BALANCE","message":"the sender balance is too low"}` {
+		t.Errorf("expected the details to be omitted, got %s", errNotEnoughBalance.Error())
+	}
+	if errNotEnoughBalance.Details != nil {
+		t.Errorf("expected WithDetails to leave the original untouched")
+	}
+	if !errors.Is(fmt.Errorf("transfer: %w", detailed), errNotEnoughBalance) {
+		t.Errorf("expected errors.Is to match the code through wrapping")
+	}
+	if errors.Is(detailed, NewError("OTHER", "")) {
+		t.Errorf("expected errors with other codes not to match")
+	}
+
+	parsed, ok := ParseError(expected)
+	if !ok || parsed.Code != "NOT_ENOUGH_BALANCE" || string(parsed.Details) != `{"balance":"10"}` {
+		t.Errorf("unexpected parsed error %+v", parsed)
+	}
+	if _, ok := ParseError("insufficient deposit"); ok {
+		t.Errorf("expected plain messages not to parse")
+	}
+}
+
+func TestHandleContractError(t *testing.T) {
+	t.Cleanup(func() { testutils.NewContextBuilder().Build() })
+
+	chain := sim.New()
+	chain.CreateAccount("contract.near", sim.NEAR(10))
+	chain.CreateAccount("alice.near", sim.NEAR(10))
+	chain.Deploy("contract.near", sim.Methods{
+		"wrapped": func() {
+			HandleClientJSONInput(func(input *ContractInput) error {
+				return fmt.Errorf("transfer: %w", errNotEnoughBalance.WithDetails(map[string]string{"balance": "10"}))
+			})
+		},
+		"plain": func() {
+			HandleClientJSONInput(func(input *ContractInput) error {
+				return errors.New("insufficient deposit")
+			})
+		},
+	})
+
+	result, err := chain.Call("alice.near", "contract.near", "wrapped", map[string]string{}, types.Uint128{})
+	if err != nil || result.Failure == nil {
+		t.Fatalf("expected wrapped to fail: %v", err)
+	}
+	expected := sim.ErrContractPanicPrefix + `{"code":"NOT_ENOUGH_BALANCE","message":"the sender balance is too low","details":{"balance":"10"}}`
+	if result.Failure.Error() != expected {
+		t.Errorf("expected %s, got %s", expected, result.Failure.Error())
+	}
+
+	result, err = chain.Call("alice.near", "contract.near", "plain", map[string]string{}, types.Uint128{})
+	if err != nil || result.Failure == nil || result.Failure.Error() != sim.ErrContractPanicPrefix+"insufficient deposit" {
+		t.Errorf("expected plain errors to keep their text, got %v %v", err, result.Failure)
+	}
+}
+
+func TestDeclareErrors(t *testing.T) {
+	errUnknownAccount := NewError("UNKNOWN_ACCOUNT", "the account is not registered")
+	DeclareErrors("transfer", errNotEnoughBalance.WithDetails(1), errUnknownAccount)
+	DeclareErrors("transfer", errUnknownAccount)
+	DeclareErrors("burn", errNotEnoughBalance)
+
+	declared := DeclaredErrors()
+	if len(declared) != 2 || declared[0].Name != "burn" || declared[1].Name != "transfer" {
+		t.Fatalf("unexpected declared methods %+v", declared)
+	}
+	transfer := declared[1].Errors
+	if len(transfer) != 2 || transfer[0].Code != "NOT_ENOUGH_BALANCE" || transfer[0].Details != nil || transfer[1].Code != "UNKNOWN_ACCOUNT" {
+		t.Errorf("unexpected transfer errors %+v", transfer)
+	}
+
+	ctx := testutils.NewContextBuilder().Build()
+	ErrorsView()
+	expected := `[{"name":"burn","errors":[{"code":"NOT_ENOUGH_BALANCE","message":"the sender balance is too low"}]},` +
+		`{"name":"transfer","errors":[{"code":"NOT_ENOUGH_BALANCE","message":"the sender balance is too low"},{"code":"UNKNOWN_ACCOUNT","message":"the account is not registered"}]}]`
+	if returned := string(ctx.Registers[0]); returned != expected {
+		t.Errorf("expected the view to return %s, got %s", expected, returned)
+	}
+}
+
+func TestDeclareHandleErrors(t *testing.T) {
+	defer delete(declaredErrors, "handled")
+	DeclareErrors("handled", HandleErrors...)
+
+	for _, method := range DeclaredErrors() {
+		if method.Name != "handled" {
+			continue
+		}
+		codes := []string{}
+		for _, e := range method.Errors {
+			codes = append(codes, e.Code)
+		}
+		if strings.Join(codes, ",") != "INVALID_ARGUMENTS,UNKNOWN_ARGUMENT,MISSING_ARGUMENTS,INVALID_RESULT,EXECUTION_ERROR" {
+			t.Errorf("unexpected Handle errors %v", codes)
+		}
+		return
+	}
+	t.Errorf("expected the Handle errors to be declared")
+}
+
+func TestContractErrorInvalidDetails(t *testing.T) {
+	e := &ContractError{Code: `QUOTED"CODE`, Message: `a "quoted" message`, Details: []byte("{")}
+	parsed, ok := ParseError(e.Error())
+	if !ok || parsed.Code != e.Code || parsed.Message != e.Message || parsed.Details != nil {
+		t.Errorf("expected valid JSON without the details, got %s", e.Error())
+	}
+}
@@ -0,0 +1,173 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// Errors of Handle. Their details name the offending fields.
+var (
+	ErrInvalidArguments = NewError("INVALID_ARGUMENTS", "the arguments don't match the method parameters")
+	ErrUnknownArgument  = NewError("UNKNOWN_ARGUMENT", "the arguments contain an unknown field")
+	ErrMissingArguments = NewError("MISSING_ARGUMENTS", "required arguments are missing")
+	ErrInvalidResult    = NewError("INVALID_RESULT", "failed to encode the result")
+	ErrExecution        = NewError("EXECUTION_ERROR", "the method failed")
+)
+
+// HandleErrors are the errors every method exported with Handle can fail with, for DeclareErrors.
+var HandleErrors = []*ContractError{ErrInvalidArguments, ErrUnknownArgument, ErrMissingArguments, ErrInvalidResult, ErrExecution}
+
+// NoResult is the Out of methods that return nothing; Handle doesn't set a return value for it.
+type NoResult struct{}
+
+// Handle is the body of an exported method: it decodes the JSON arguments into In, calls fn and returns
+// Out encoded to JSON.
+//
+//	type TransferArgs struct {
+//		ReceiverID string  `json:"receiver_id"`
+//		Amount     string  `json:"amount"`
+//		Memo       *string `json:"memo"`
+//	}
+//
+//	//go:export transfer
+//	func Transfer() {
+//		contract.Handle(func(args TransferArgs) (contract.NoResult, error) {
+//			return contract.NoResult{}, transfer(args.ReceiverID, args.Amount)
+//		})
+//	}
+//
+// When In is a struct, arguments without a matching field are rejected, and so are missing fields unless
+// they are pointers or tagged omitempty; missing input counts as an empty object. Failures panic with a
+// ContractError: the errors above, the ContractError returned by fn, or ErrExecution with the message of
+// any other error fn returns.
+func Handle[In, Out any](fn func(In) (Out, error)) {
+	var data []byte
+	if input, err := GetRawBytesInput(); err == nil {
+		data = input.Data
+	}
+
+	args, err := DecodeArgs[In](data)
+	if err != nil {
+		PanicError("", err)
+		return
+	}
+
+	result, err := fn(args)
+	if err != nil {
+		var contractError *ContractError
+		if !errors.As(err, &contractError) {
+			err = ErrExecution.WithDetails(map[string]string{"reason": err.Error()})
+		}
+		PanicError("", err)
+		return
+	}
+
+	if _, ok := any(result).(NoResult); ok {
+		return
+	}
+	if err := ReturnValue(result); err != nil {
+		PanicError("", ErrInvalidResult.WithDetails(map[string]string{"reason": err.Error()}))
+	}
+}
+
+// DecodeArgs decodes the JSON arguments of a method into In with the checks of Handle.
+func DecodeArgs[In any](data []byte) (In, error) {
+	var args In
+	if len(bytes.TrimSpace(data)) == 0 {
+		data = []byte("{}")
+	}
+
+	structType := reflect.TypeOf(args)
+	for structType != nil && structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType == nil || structType.Kind() != reflect.Struct {
+		if err := json.Unmarshal(data, &args); err != nil {
+			return args, ErrInvalidArguments.WithDetails(map[string]string{"reason": err.Error()})
+		}
+		return args, nil
+	}
+
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal(data, &present); err != nil {
+		return args, ErrInvalidArguments.WithDetails(map[string]string{"reason": err.Error()})
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&args); err != nil {
+		if field, ok := unknownField(err); ok {
+			return args, ErrUnknownArgument.WithDetails(map[string]string{"field": field})
+		}
+		return args, ErrInvalidArguments.WithDetails(map[string]string{"reason": err.Error()})
+	}
+
+	var missing []string
+	for _, field := range requiredFields(structType) {
+		if !hasKey(present, field) {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return args, ErrMissingArguments.WithDetails(map[string][]string{"fields": missing})
+	}
+	return args, nil
+}
+
+// unknownField extracts the field name from the error DisallowUnknownFields makes the decoder return.
+func unknownField(err error) (string, bool) {
+	const prefix = `json: unknown field "`
+	message := err.Error()
+	if !strings.HasPrefix(message, prefix) {
+		return "", false
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(message, prefix), `"`), true
+}
+
+// requiredFields returns the JSON names of the fields of structType that aren't pointers or tagged
+// omitempty, including those of embedded structs.
+func requiredFields(structType reflect.Type) []string {
+	var fields []string
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				continue
+			}
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, requiredFields(embedded)...)
+				continue
+			}
+		}
+		if !field.IsExported() || field.Type.Kind() == reflect.Ptr || strings.Contains(","+options+",", ",omitempty,") {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+// hasKey reports whether name is in present, ignoring case like encoding/json does.
+func hasKey(present map[string]json.RawMessage, name string) bool {
+	if _, ok := present[name]; ok {
+		return true
+	}
+	for key := range present {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package contract
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/sim"
+	"github.com/vlmoon99/near-sdk-go/testutils"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+type base struct {
+	AccountID string `json:"account_id"`
+}
+
+type transferArgs struct {
+	base
+	Amount  string  `json:"amount"`
+	Memo    *string `json:"memo"`
+	Comment string  `json:"comment,omitempty"`
+}
+
+func TestDecodeArgs(t *testing.T) {
+	args, err := DecodeArgs[transferArgs]([]byte(`{"account_id":"alice.near","amount":"10"}`))
+	if err != nil || args.AccountID != "alice.near" || args.Amount != "10" || args.Memo != nil {
+		t.Errorf("unexpected args %+v: %v", args, err)
+	}
+
+	_, err = DecodeArgs[transferArgs]([]byte(`{"account_id":"alice.near","amount":"10","fee":"1"}`))
+	if !errors.Is(err, ErrUnknownArgument) || err.Error() != `{"code":"UNKNOWN_ARGUMENT","message":"the arguments contain an unknown field","details":{"field":"fee"}}` {
+		t.Errorf("expected the unknown field to be reported, got %v", err)
+	}
+
+	_, err = DecodeArgs[transferArgs](nil)
+	if !errors.Is(err, ErrMissingArguments) || err.Error() != `{"code":"MISSING_ARGUMENTS","message":"required arguments are missing","details":{"fields":["account_id","amount"]}}` {
+		t.Errorf("expected the missing fields to be reported, got %v", err)
+	}
+
+	if _, err := DecodeArgs[transferArgs]([]byte(`{"account_id":1}`)); !errors.Is(err, ErrInvalidArguments) {
+		t.Errorf("expected mistyped arguments to be invalid, got %v", err)
+	}
+	if _, err := DecodeArgs[transferArgs]([]byte(`[]`)); !errors.Is(err, ErrInvalidArguments) {
+		t.Errorf("expected an array to be invalid, got %v", err)
+	}
+
+	if ids, err := DecodeArgs[[]string]([]byte(`["a","b"]`)); err != nil || len(ids) != 2 {
+		t.Errorf("expected non-struct arguments to decode as is, got %v: %v", ids, err)
+	}
+	if _, err := DecodeArgs[NoResult](nil); err != nil {
+		t.Errorf("expected methods without arguments to accept no input, got %v", err)
+	}
+}
+
+func TestHandle(t *testing.T) {
+	ctx := testutils.NewContextBuilder().Input(map[string]string{"account_id": "alice.near", "amount": "10"}).Build()
+	Handle(func(args transferArgs) (map[string]string, error) {
+		return map[string]string{"received": args.Amount}, nil
+	})
+	if returned := string(ctx.Registers[0]); returned != `{"received":"10"}` {
+		t.Errorf("unexpected return value %s", returned)
+	}
+}
+
+func TestHandleFailures(t *testing.T) {
+	t.Cleanup(func() { testutils.NewContextBuilder().Build() })
+
+	chain := sim.New()
+	chain.CreateAccount("contract.near", sim.NEAR(10))
+	chain.CreateAccount("alice.near", sim.NEAR(10))
+	chain.Deploy("contract.near", sim.Methods{
+		"transfer": func() {
+			Handle(func(args transferArgs) (NoResult, error) {
+				if args.Amount == "0" {
+					return NoResult{}, errors.New("zero amount")
+				}
+				if args.Amount == "-1" {
+					return NoResult{}, errNotEnoughBalance
+				}
+				return NoResult{}, nil
+			})
+		},
+	})
+
+	tests := []struct {
+		name     string
+		args     interface{}
+		expected string
+	}{
+		{"success", map[string]string{"account_id": "bob.near", "amount": "1"}, ""},
+		{"missing", map[string]string{"account_id": "bob.near"}, `{"code":"MISSING_ARGUMENTS","message":"required arguments are missing","details":{"fields":["amount"]}}`},
+		{"plain error", map[string]string{"account_id": "bob.near", "amount": "0"}, `{"code":"EXECUTION_ERROR","message":"the method failed","details":{"reason":"zero amount"}}`},
+		{"contract error", map[string]string{"account_id": "bob.near", "amount": "-1"}, `{"code":"NOT_ENOUGH_BALANCE","message":"the sender balance is too low"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := chain.Call("alice.near", "contract.near", "transfer", tt.args, types.Uint128{})
+			if err != nil {
+				t.Fatalf("transfer failed: %v", err)
+			}
+			if tt.expected == "" {
+				if result.Failed() || len(result.Value) != 0 {
+					t.Errorf("expected no failure and no value, got %v %q", result.Failure, result.Value)
+				}
+				return
+			}
+			if result.Failure == nil || result.Failure.Error() != sim.ErrContractPanicPrefix+tt.expected {
+				t.Errorf("expected %s, got %v", tt.expected, result.Failure)
+			}
+		})
+	}
+}
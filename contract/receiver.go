@@ -0,0 +1,188 @@
+package contract
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+const (
+	ErrInvalidReceiverArgs = "(CONTRACT_ERROR): failed to parse the receiver arguments: "
+	ErrInvalidAmount       = "(CONTRACT_ERROR): the amount is not a valid U128: "
+	ErrUnusedExceedsAmount = "(CONTRACT_ERROR): the unused amount is greater than the transferred amount"
+	ErrUnhandledMessage    = "(CONTRACT_ERROR): no handler for the transfer message: "
+)
+
+// FtTransfer describes the tokens a fungible token contract sent with ft_transfer_call.
+type FtTransfer struct {
+	// TokenID is the account of the token contract, the predecessor of ft_on_transfer.
+	TokenID  string
+	SenderID string
+	Amount   types.Uint128
+	Msg      string
+}
+
+// FungibleTokenReceiver is implemented by contracts that accept fungible tokens. FtOnTransfer returns the
+// amount the token contract should refund to the sender; an error refunds everything.
+type FungibleTokenReceiver interface {
+	FtOnTransfer(transfer FtTransfer) (types.Uint128, error)
+}
+
+// NftTransfer describes the token a non-fungible token contract sent with nft_transfer_call.
+type NftTransfer struct {
+	// ContractID is the account of the token contract, the predecessor of nft_on_transfer.
+	ContractID      string
+	SenderID        string
+	PreviousOwnerID string
+	TokenID         string
+	Msg             string
+}
+
+// NonFungibleTokenReceiver is implemented by contracts that accept non-fungible tokens. NftOnTransfer returns
+// true when the token should go back to the previous owner; an error returns it too.
+type NonFungibleTokenReceiver interface {
+	NftOnTransfer(transfer NftTransfer) (bool, error)
+}
+
+type ftOnTransferArgs struct {
+	SenderID string `json:"sender_id"`
+	Amount   string `json:"amount"`
+	Msg      string `json:"msg"`
+}
+
+type nftOnTransferArgs struct {
+	SenderID        string `json:"sender_id"`
+	PreviousOwnerID string `json:"previous_owner_id"`
+	TokenID         string `json:"token_id"`
+	Msg             string `json:"msg"`
+}
+
+// ParseFtTransfer reads the ft_on_transfer arguments from input.
+func ParseFtTransfer(input *ContractInput) (FtTransfer, error) {
+	var args ftOnTransferArgs
+	if err := json.Unmarshal(input.Data, &args); err != nil {
+		return FtTransfer{}, errors.New(ErrInvalidReceiverArgs + err.Error())
+	}
+	amount, err := types.U128FromString(args.Amount)
+	if err != nil {
+		return FtTransfer{}, errors.New(ErrInvalidAmount + args.Amount)
+	}
+	tokenID, _ := env.GetPredecessorAccountID()
+	return FtTransfer{TokenID: tokenID, SenderID: args.SenderID, Amount: amount, Msg: args.Msg}, nil
+}
+
+// ParseNftTransfer reads the nft_on_transfer arguments from input.
+func ParseNftTransfer(input *ContractInput) (NftTransfer, error) {
+	var args nftOnTransferArgs
+	if err := json.Unmarshal(input.Data, &args); err != nil {
+		return NftTransfer{}, errors.New(ErrInvalidReceiverArgs + err.Error())
+	}
+	contractID, _ := env.GetPredecessorAccountID()
+	return NftTransfer{
+		ContractID:      contractID,
+		SenderID:        args.SenderID,
+		PreviousOwnerID: args.PreviousOwnerID,
+		TokenID:         args.TokenID,
+		Msg:             args.Msg,
+	}, nil
+}
+
+// HandleFtOnTransfer is the body of an exported ft_on_transfer method: it parses the arguments, calls the
+// receiver and returns the unused amount as a U128 string.
+//
+//	//go:export ft_on_transfer
+//	func FtOnTransfer() {
+//		contract.HandleFtOnTransfer(receiver)
+//	}
+func HandleFtOnTransfer(receiver FungibleTokenReceiver) {
+	HandleClientJSONInput(func(input *ContractInput) error {
+		transfer, err := ParseFtTransfer(input)
+		if err != nil {
+			return err
+		}
+		unused, err := receiver.FtOnTransfer(transfer)
+		if err != nil {
+			return err
+		}
+		if unused.Cmp(transfer.Amount) > 0 {
+			return errors.New(ErrUnusedExceedsAmount)
+		}
+		return ReturnValue(unused.String())
+	})
+}
+
+// HandleNftOnTransfer is the body of an exported nft_on_transfer method: it parses the arguments, calls the
+// receiver and returns whether the token goes back to the previous owner.
+func HandleNftOnTransfer(receiver NonFungibleTokenReceiver) {
+	HandleClientJSONInput(func(input *ContractInput) error {
+		transfer, err := ParseNftTransfer(input)
+		if err != nil {
+			return err
+		}
+		returnToken, err := receiver.NftOnTransfer(transfer)
+		if err != nil {
+			return err
+		}
+		return ReturnValue(returnToken)
+	})
+}
+
+// FtReceiverRouter is a FungibleTokenReceiver that routes transfers to handlers by their msg. Transfers
+// with other messages go to Fallback, or fail when it's nil.
+type FtReceiverRouter struct {
+	Handlers map[string]func(FtTransfer) (types.Uint128, error)
+	Fallback func(FtTransfer) (types.Uint128, error)
+}
+
+// NewFtReceiverRouter creates a router without handlers.
+func NewFtReceiverRouter() *FtReceiverRouter {
+	return &FtReceiverRouter{Handlers: make(map[string]func(FtTransfer) (types.Uint128, error))}
+}
+
+// On routes the transfers with msg to handler.
+func (r *FtReceiverRouter) On(msg string, handler func(FtTransfer) (types.Uint128, error)) *FtReceiverRouter {
+	r.Handlers[msg] = handler
+	return r
+}
+
+// FtOnTransfer implements FungibleTokenReceiver.
+func (r *FtReceiverRouter) FtOnTransfer(transfer FtTransfer) (types.Uint128, error) {
+	if handler, ok := r.Handlers[transfer.Msg]; ok {
+		return handler(transfer)
+	}
+	if r.Fallback != nil {
+		return r.Fallback(transfer)
+	}
+	return types.Uint128{}, errors.New(ErrUnhandledMessage + transfer.Msg)
+}
+
+// NftReceiverRouter is a NonFungibleTokenReceiver that routes transfers to handlers by their msg. Transfers
+// with other messages go to Fallback, or fail when it's nil.
+type NftReceiverRouter struct {
+	Handlers map[string]func(NftTransfer) (bool, error)
+	Fallback func(NftTransfer) (bool, error)
+}
+
+// NewNftReceiverRouter creates a router without handlers.
+func NewNftReceiverRouter() *NftReceiverRouter {
+	return &NftReceiverRouter{Handlers: make(map[string]func(NftTransfer) (bool, error))}
+}
+
+// On routes the transfers with msg to handler.
+func (r *NftReceiverRouter) On(msg string, handler func(NftTransfer) (bool, error)) *NftReceiverRouter {
+	r.Handlers[msg] = handler
+	return r
+}
+
+// NftOnTransfer implements NonFungibleTokenReceiver.
+func (r *NftReceiverRouter) NftOnTransfer(transfer NftTransfer) (bool, error) {
+	if handler, ok := r.Handlers[transfer.Msg]; ok {
+		return handler(transfer)
+	}
+	if r.Fallback != nil {
+		return r.Fallback(transfer)
+	}
+	return true, errors.New(ErrUnhandledMessage + transfer.Msg)
+}
@@ -0,0 +1,75 @@
+package contract
+
+import (
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/testutils"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+func TestHandleFtOnTransfer(t *testing.T) {
+	router := NewFtReceiverRouter().
+		On("deposit", func(transfer FtTransfer) (types.Uint128, error) {
+			if transfer.TokenID != "token.near" || transfer.SenderID != "alice.near" {
+				t.Errorf("unexpected transfer %+v", transfer)
+			}
+			return transfer.Amount.Sub(types.U64ToUint128(60))
+		})
+
+	ctx := testutils.NewContextBuilder().
+		Predecessor("token.near").
+		Input(map[string]string{"sender_id": "alice.near", "amount": "100", "msg": "deposit"}).
+		Build()
+	HandleFtOnTransfer(router)
+
+	if returned := string(ctx.Registers[0]); returned != `"40"` {
+		t.Errorf(`expected "40" to be returned, got %s`, returned)
+	}
+}
+
+func TestFtReceiverRouter(t *testing.T) {
+	router := NewFtReceiverRouter()
+	transfer := FtTransfer{Amount: types.U64ToUint128(5), Msg: "swap"}
+	if _, err := router.FtOnTransfer(transfer); err == nil || err.Error() != ErrUnhandledMessage+"swap" {
+		t.Errorf("expected %q, got %v", ErrUnhandledMessage+"swap", err)
+	}
+
+	router.Fallback = func(transfer FtTransfer) (types.Uint128, error) { return transfer.Amount, nil }
+	if unused, err := router.FtOnTransfer(transfer); err != nil || unused.Cmp(transfer.Amount) != 0 {
+		t.Errorf("expected the fallback to refund everything, got %s: %v", unused.String(), err)
+	}
+}
+
+func TestParseFtTransferErrors(t *testing.T) {
+	testutils.NewContextBuilder().Predecessor("token.near").Build()
+
+	if _, err := ParseFtTransfer(&ContractInput{Data: []byte(`{"sender_id":"alice.near","amount":"x"}`)}); err == nil || err.Error() != ErrInvalidAmount+"x" {
+		t.Errorf("expected %q, got %v", ErrInvalidAmount+"x", err)
+	}
+	if _, err := ParseFtTransfer(&ContractInput{Data: []byte(`[]`)}); err == nil {
+		t.Errorf("expected malformed arguments to fail")
+	}
+}
+
+func TestHandleNftOnTransfer(t *testing.T) {
+	router := NewNftReceiverRouter().
+		On("keep", func(transfer NftTransfer) (bool, error) {
+			if transfer.ContractID != "nft.near" || transfer.PreviousOwnerID != "alice.near" || transfer.TokenID != "1" {
+				t.Errorf("unexpected transfer %+v", transfer)
+			}
+			return false, nil
+		})
+
+	ctx := testutils.NewContextBuilder().
+		Predecessor("nft.near").
+		Input(map[string]string{"sender_id": "alice.near", "previous_owner_id": "alice.near", "token_id": "1", "msg": "keep"}).
+		Build()
+	HandleNftOnTransfer(router)
+	if returned := string(ctx.Registers[0]); returned != "false" {
+		t.Errorf("expected false to be returned, got %s", returned)
+	}
+
+	if returnToken, err := router.NftOnTransfer(NftTransfer{Msg: "other"}); !returnToken || err == nil {
+		t.Errorf("expected unhandled messages to return the token, got %v %v", returnToken, err)
+	}
+}
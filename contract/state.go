@@ -0,0 +1,145 @@
+package contract
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/vlmoon99/near-sdk-go/env"
+)
+
+const (
+	ErrStateNotInitialized     = "(CONTRACT_ERROR): the contract is not initialized"
+	ErrStateAlreadyInitialized = "(CONTRACT_ERROR): the contract is already initialized"
+	ErrStateVersionMismatch    = "(CONTRACT_ERROR): the stored state has another version, migrate it first: "
+	ErrStateDecode             = "(CONTRACT_ERROR): failed to decode the state: "
+	ErrStateEncode             = "(CONTRACT_ERROR): failed to encode the state: "
+	ErrStateWrite              = "(CONTRACT_ERROR): failed to write the state: "
+	ErrNilState                = "(CONTRACT_ERROR): the state is nil"
+)
+
+// Codec encodes the contract state.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec is the default Codec, encoding the state with encoding/json.
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+var (
+	// StateCodec encodes the state stored by SaveState. Set it from an init function; changing it makes the
+	// states stored with the previous codec unreadable.
+	StateCodec Codec = JSONCodec{}
+
+	// StateVersion is the version of the state type. SaveState tags the state with it and LoadState
+	// refuses a state tagged with another version, so a contract upgraded with a new state type fails
+	// until its migrate method converts the state. States saved without a tag have version 0.
+	StateVersion uint64 = 0
+)
+
+// stateVersionKey is the key of the version tag, next to env.StateKey.
+func stateVersionKey() []byte {
+	return append(append([]byte{}, env.StateKey...), ":version"...)
+}
+
+// IsInitialized reports whether the contract state exists.
+func IsInitialized() bool {
+	return env.StateExists()
+}
+
+// RequireInitialized fails until the contract is initialized, for methods other than init.
+func RequireInitialized() error {
+	if !IsInitialized() {
+		return errors.New(ErrStateNotInitialized)
+	}
+	return nil
+}
+
+// RequireNotInitialized fails once the contract is initialized, so init methods can't be called twice.
+func RequireNotInitialized() error {
+	if IsInitialized() {
+		return errors.New(ErrStateAlreadyInitialized)
+	}
+	return nil
+}
+
+// StoredStateVersion returns the version the stored state is tagged with.
+func StoredStateVersion() uint64 {
+	data, err := env.StorageRead(stateVersionKey())
+	if err != nil {
+		return 0
+	}
+	version, err := strconv.ParseUint(string(data), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
+// LoadState reads the contract state with StateCodec. It fails when the contract is not initialized or
+// when the state is tagged with another version than StateVersion.
+func LoadState[T any]() (*T, error) {
+	data, err := env.StateRead()
+	if err != nil {
+		return nil, errors.New(ErrStateNotInitialized)
+	}
+	if version := StoredStateVersion(); version != StateVersion {
+		return nil, errors.New(ErrStateVersionMismatch + strconv.FormatUint(version, 10))
+	}
+	state := new(T)
+	if err := StateCodec.Unmarshal(data, state); err != nil {
+		return nil, errors.New(ErrStateDecode + err.Error())
+	}
+	return state, nil
+}
+
+// SaveState writes the contract state with StateCodec, tagged with StateVersion.
+func SaveState[T any](state *T) error {
+	if state == nil {
+		return errors.New(ErrNilState)
+	}
+	data, err := StateCodec.Marshal(state)
+	if err != nil {
+		return errors.New(ErrStateEncode + err.Error())
+	}
+	if err := env.StateWrite(data); err != nil {
+		return errors.New(ErrStateWrite + err.Error())
+	}
+	if StateVersion == 0 {
+		env.StorageRemove(stateVersionKey())
+		return nil
+	}
+	if _, err := env.StorageWrite(stateVersionKey(), []byte(strconv.FormatUint(StateVersion, 10))); err != nil {
+		return errors.New(ErrStateWrite + err.Error())
+	}
+	return nil
+}
+
+// InitState creates the contract state with fn and saves it. It fails when the contract is already
+// initialized, without calling fn.
+//
+//	// @contract:init
+//	func Init(owner string) error {
+//		return contract.InitState(func() (*Contract, error) {
+//			return &Contract{Owner: owner}, nil
+//		})
+//	}
+func InitState[T any](fn func() (*T, error)) error {
+	if err := RequireNotInitialized(); err != nil {
+		return err
+	}
+	state, err := fn()
+	if err != nil {
+		return err
+	}
+	return SaveState(state)
+}
@@ -0,0 +1,101 @@
+package contract
+
+import (
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/testutils"
+)
+
+type counterState struct {
+	Owner string `json:"owner"`
+	Count int    `json:"count"`
+}
+
+func TestInitAndLoadState(t *testing.T) {
+	ctx := testutils.NewContextBuilder().Build()
+	if err := RequireInitialized(); err == nil || err.Error() != ErrStateNotInitialized {
+		t.Errorf("expected %q, got %v", ErrStateNotInitialized, err)
+	}
+	if _, err := LoadState[counterState](); err == nil || err.Error() != ErrStateNotInitialized {
+		t.Errorf("expected %q, got %v", ErrStateNotInitialized, err)
+	}
+
+	err := InitState(func() (*counterState, error) {
+		return &counterState{Owner: "alice.near"}, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to init: %v", err)
+	}
+	if string(ctx.Storage["STATE"]) != `{"owner":"alice.near","count":0}` {
+		t.Errorf("unexpected stored state %s", ctx.Storage["STATE"])
+	}
+
+	called := false
+	err = InitState(func() (*counterState, error) {
+		called = true
+		return &counterState{}, nil
+	})
+	if err == nil || err.Error() != ErrStateAlreadyInitialized || called {
+		t.Errorf("expected a second init to fail without calling fn, got %v", err)
+	}
+	if err := RequireNotInitialized(); err == nil {
+		t.Errorf("expected RequireNotInitialized to fail")
+	}
+
+	testutils.NewContextBuilder().State(ctx.Storage).Build()
+	state, err := LoadState[counterState]()
+	if err != nil || state.Owner != "alice.near" {
+		t.Fatalf("failed to load: %+v %v", state, err)
+	}
+	state.Count++
+	if err := SaveState(state); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+	if state, _ := LoadState[counterState](); state.Count != 1 {
+		t.Errorf("expected the count to be saved, got %+v", state)
+	}
+	if err := SaveState[counterState](nil); err == nil || err.Error() != ErrNilState {
+		t.Errorf("expected %q, got %v", ErrNilState, err)
+	}
+}
+
+type countingCodec struct {
+	JSONCodec
+	marshaled int
+}
+
+func (c *countingCodec) Marshal(v interface{}) ([]byte, error) {
+	c.marshaled++
+	return c.JSONCodec.Marshal(v)
+}
+
+func TestStateVersionAndCodec(t *testing.T) {
+	codec := &countingCodec{}
+	StateCodec = codec
+	defer func() { StateCodec, StateVersion = JSONCodec{}, 0 }()
+
+	ctx := testutils.NewContextBuilder().Build()
+	SaveState(&counterState{Owner: "alice.near"})
+	if codec.marshaled != 1 || StoredStateVersion() != 0 {
+		t.Errorf("expected the state to be encoded by the codec without a version tag")
+	}
+
+	StateVersion = 2
+	expected := ErrStateVersionMismatch + "0"
+	if _, err := LoadState[counterState](); err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+	SaveState(&counterState{Owner: "bob.near"})
+	if string(ctx.Storage["STATE:version"]) != "2" || StoredStateVersion() != 2 {
+		t.Errorf("expected the state to be tagged with version 2, got %q", ctx.Storage["STATE:version"])
+	}
+	if state, err := LoadState[counterState](); err != nil || state.Owner != "bob.near" {
+		t.Errorf("failed to load the tagged state: %+v %v", state, err)
+	}
+
+	StateVersion = 0
+	SaveState(&counterState{Owner: "carol.near"})
+	if _, tagged := ctx.Storage["STATE:version"]; tagged {
+		t.Errorf("expected version 0 to drop the tag")
+	}
+}
@@ -752,10 +752,17 @@ func TestPromiseBatchActionFunctionCallWeight(t *testing.T) {
 }
 
 func TestPromiseBatchActionTransfer(t *testing.T) {
+	mockSys := NearBlockchainImports.(*system.MockSystem)
+	mockSys.AccountBalanceSys = types.Uint128{Hi: 0, Lo: 1500}
+
 	promiseIdx := uint64(0)
 	amount := types.Uint128{Hi: 0, Lo: 1000}
 	PromiseBatchActionTransfer(promiseIdx, amount)
-	// Verify actions
+
+	expected := types.Uint128{Hi: 0, Lo: 500}
+	if mockSys.AccountBalanceSys != expected {
+		t.Fatalf("Expected balance %v after transfer, got %v", expected, mockSys.AccountBalanceSys)
+	}
 }
 
 func TestPromiseBatchActionStake(t *testing.T) {
@@ -0,0 +1,147 @@
+// Package events emits and parses NEP-297 events, the structured logs indexers read:
+//
+//	EVENT_JSON:{"standard":"nep141","version":"1.0.0","event":"ft_mint","data":[{"owner_id":"alice.near","amount":"100"}]}
+//
+// Emit logs any event. Every event of the supported standards also has a Kind, typed with its data, so a
+// contract can't log an event with the wrong payload:
+//
+//	events.FtMintEvent.Emit(events.FtMint{OwnerID: "alice.near", Amount: "100"})
+//
+// Tests read the events back from the logs of the mocked environment:
+//
+//	mints, err := events.FtMintEvent.Find(ctx.Logs())
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/vlmoon99/near-sdk-go/env"
+)
+
+// LogPrefix starts every NEP-297 event log.
+const LogPrefix = "EVENT_JSON:"
+
+const (
+	ErrNotAnEvent     = "(EVENTS_ERROR): the log is not an " + LogPrefix + " event"
+	ErrInvalidEvent   = "(EVENTS_ERROR): failed to decode the event: "
+	ErrEncodingFailed = "(EVENTS_ERROR): failed to encode the event: "
+	ErrKindMismatch   = "(EVENTS_ERROR): the event is not a "
+)
+
+// Event is a decoded NEP-297 event. Data is kept encoded until DecodeData.
+type Event struct {
+	Standard string          `json:"standard"`
+	Version  string          `json:"version"`
+	Event    string          `json:"event"`
+	Data     json.RawMessage `json:"data,omitempty"`
+}
+
+// DecodeData decodes the data of the event into v.
+func (e Event) DecodeData(v interface{}) error {
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return errors.New(ErrInvalidEvent + err.Error())
+	}
+	return nil
+}
+
+// Format returns the log line of an event. Data is encoded to JSON; NEP-297 standards use an array of
+// objects. Nil data is left out.
+func Format(standard, version, event string, data interface{}) (string, error) {
+	e := Event{Standard: standard, Version: version, Event: event}
+	if data != nil {
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return "", errors.New(ErrEncodingFailed + err.Error())
+		}
+		e.Data = encoded
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return "", errors.New(ErrEncodingFailed + err.Error())
+	}
+	return LogPrefix + string(payload), nil
+}
+
+// Emit logs an event; it panics when the data can't be encoded.
+func Emit(standard, version, event string, data interface{}) {
+	log, err := Format(standard, version, event, data)
+	if err != nil {
+		env.PanicStr(err.Error())
+	}
+	env.LogString(log)
+}
+
+// Parse decodes the event in a log line.
+func Parse(log string) (Event, error) {
+	if !strings.HasPrefix(log, LogPrefix) {
+		return Event{}, errors.New(ErrNotAnEvent)
+	}
+	var e Event
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(log, LogPrefix)), &e); err != nil {
+		return Event{}, errors.New(ErrInvalidEvent + err.Error())
+	}
+	return e, nil
+}
+
+// ParseLogs returns the events among logs, skipping the other messages and malformed events.
+func ParseLogs(logs []string) []Event {
+	var events []Event
+	for _, log := range logs {
+		if e, err := Parse(log); err == nil {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// Kind is an event of a standard whose data is a list of T.
+type Kind[T any] struct {
+	Standard string
+	Version  string
+	Event    string
+}
+
+// NewKind declares the event named event of a standard version.
+func NewKind[T any](standard, version, event string) Kind[T] {
+	return Kind[T]{Standard: standard, Version: version, Event: event}
+}
+
+// Emit logs the event with data.
+func (k Kind[T]) Emit(data ...T) {
+	Emit(k.Standard, k.Version, k.Event, data)
+}
+
+// Is reports whether e is an event of this kind. Any version of the standard matches.
+func (k Kind[T]) Is(e Event) bool {
+	return e.Standard == k.Standard && e.Event == k.Event
+}
+
+// Decode returns the data of an event of this kind.
+func (k Kind[T]) Decode(e Event) ([]T, error) {
+	if !k.Is(e) {
+		return nil, errors.New(ErrKindMismatch + k.Standard + " " + k.Event + " event")
+	}
+	var data []T
+	if err := e.DecodeData(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Find returns the data of all the events of this kind in logs, in order.
+func (k Kind[T]) Find(logs []string) ([]T, error) {
+	var found []T
+	for _, e := range ParseLogs(logs) {
+		if !k.Is(e) {
+			continue
+		}
+		data, err := k.Decode(e)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, data...)
+	}
+	return found, nil
+}
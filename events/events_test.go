@@ -0,0 +1,84 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/system"
+)
+
+func setupMock() *system.MockSystem {
+	mock := system.NewMockSystem()
+	env.SetEnv(mock)
+	return mock
+}
+
+func TestFormat(t *testing.T) {
+	log, err := Format(NEP141Standard, NEP141Version, "ft_mint", []FtMint{{OwnerID: "alice.near", Amount: "100"}})
+	if err != nil {
+		t.Fatalf("format failed: %v", err)
+	}
+	expected := `EVENT_JSON:{"standard":"nep141","version":"1.0.0","event":"ft_mint","data":[{"owner_id":"alice.near","amount":"100"}]}`
+	if log != expected {
+		t.Errorf("expected %s, got %s", expected, log)
+	}
+
+	if log, _ := Format("nep999", "1.0.0", "ping", nil); log != `EVENT_JSON:{"standard":"nep999","version":"1.0.0","event":"ping"}` {
+		t.Errorf("expected the data to be left out, got %s", log)
+	}
+	if _, err := Format("nep999", "1.0.0", "ping", make(chan int)); err == nil {
+		t.Errorf("expected unencodable data to fail")
+	}
+}
+
+func TestEmitAndParse(t *testing.T) {
+	mock := setupMock()
+
+	env.LogString("not an event")
+	Emit("nep999", "2.0.0", "custom", []map[string]int{{"value": 1}})
+	FtTransferEvent.Emit(
+		FtTransfer{OldOwnerID: "alice.near", NewOwnerID: "bob.near", Amount: "5"},
+		FtTransfer{OldOwnerID: "bob.near", NewOwnerID: "carol.near", Amount: "2", Memo: "change"},
+	)
+	NftMintEvent.Emit(NftMint{OwnerID: "alice.near", TokenIDs: []string{"1"}})
+
+	logged := ParseLogs(mock.LogsSys)
+	if len(logged) != 3 {
+		t.Fatalf("expected 3 events, got %+v", logged)
+	}
+	if logged[0].Standard != "nep999" || logged[0].Version != "2.0.0" || logged[0].Event != "custom" {
+		t.Errorf("unexpected custom event %+v", logged[0])
+	}
+	var custom []map[string]int
+	if err := logged[0].DecodeData(&custom); err != nil || custom[0]["value"] != 1 {
+		t.Errorf("failed to decode the custom data: %v %v", custom, err)
+	}
+
+	transfers, err := FtTransferEvent.Decode(logged[1])
+	if err != nil || len(transfers) != 2 || transfers[1] != (FtTransfer{OldOwnerID: "bob.near", NewOwnerID: "carol.near", Amount: "2", Memo: "change"}) {
+		t.Errorf("unexpected transfers %+v: %v", transfers, err)
+	}
+	if _, err := FtMintEvent.Decode(logged[1]); err == nil || err.Error() != ErrKindMismatch+"nep141 ft_mint event" {
+		t.Errorf("expected %q, got %v", ErrKindMismatch+"nep141 ft_mint event", err)
+	}
+
+	mints, err := NftMintEvent.Find(mock.LogsSys)
+	if err != nil || len(mints) != 1 || mints[0].TokenIDs[0] != "1" {
+		t.Errorf("unexpected mints %+v: %v", mints, err)
+	}
+	if burns, err := NftBurnEvent.Find(mock.LogsSys); err != nil || len(burns) != 0 {
+		t.Errorf("expected no burns, got %+v: %v", burns, err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := Parse("hello"); err == nil || err.Error() != ErrNotAnEvent {
+		t.Errorf("expected %q, got %v", ErrNotAnEvent, err)
+	}
+	if _, err := Parse(LogPrefix + "{"); err == nil {
+		t.Errorf("expected malformed JSON to fail")
+	}
+	if logged := ParseLogs([]string{LogPrefix + "{", "hello"}); len(logged) != 0 {
+		t.Errorf("expected malformed events to be skipped, got %+v", logged)
+	}
+}
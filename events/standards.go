@@ -0,0 +1,109 @@
+package events
+
+// The standards and versions of the events the SDK emits.
+const (
+	NEP141Standard = "nep141"
+	NEP141Version  = "1.0.0"
+
+	NEP171Standard = "nep171"
+	NEP171Version  = "1.2.0"
+
+	NEP245Standard = "nep245"
+	NEP245Version  = "1.0.0"
+)
+
+// NEP-141 fungible token events.
+var (
+	FtMintEvent     = NewKind[FtMint](NEP141Standard, NEP141Version, "ft_mint")
+	FtTransferEvent = NewKind[FtTransfer](NEP141Standard, NEP141Version, "ft_transfer")
+	FtBurnEvent     = NewKind[FtBurn](NEP141Standard, NEP141Version, "ft_burn")
+)
+
+// FtMint is the data of an ft_mint event. Amounts are decimal strings.
+type FtMint struct {
+	OwnerID string `json:"owner_id"`
+	Amount  string `json:"amount"`
+	Memo    string `json:"memo,omitempty"`
+}
+
+// FtTransfer is the data of an ft_transfer event.
+type FtTransfer struct {
+	OldOwnerID string `json:"old_owner_id"`
+	NewOwnerID string `json:"new_owner_id"`
+	Amount     string `json:"amount"`
+	Memo       string `json:"memo,omitempty"`
+}
+
+// FtBurn is the data of an ft_burn event.
+type FtBurn struct {
+	OwnerID string `json:"owner_id"`
+	Amount  string `json:"amount"`
+	Memo    string `json:"memo,omitempty"`
+}
+
+// NEP-171 non-fungible token events.
+var (
+	NftMintEvent     = NewKind[NftMint](NEP171Standard, NEP171Version, "nft_mint")
+	NftTransferEvent = NewKind[NftTransfer](NEP171Standard, NEP171Version, "nft_transfer")
+	NftBurnEvent     = NewKind[NftBurn](NEP171Standard, NEP171Version, "nft_burn")
+)
+
+// NftMint is the data of an nft_mint event.
+type NftMint struct {
+	OwnerID  string   `json:"owner_id"`
+	TokenIDs []string `json:"token_ids"`
+	Memo     string   `json:"memo,omitempty"`
+}
+
+// NftTransfer is the data of an nft_transfer event. AuthorizedID is the approved account that made the
+// transfer on behalf of the owner.
+type NftTransfer struct {
+	AuthorizedID string   `json:"authorized_id,omitempty"`
+	OldOwnerID   string   `json:"old_owner_id"`
+	NewOwnerID   string   `json:"new_owner_id"`
+	TokenIDs     []string `json:"token_ids"`
+	Memo         string   `json:"memo,omitempty"`
+}
+
+// NftBurn is the data of an nft_burn event.
+type NftBurn struct {
+	OwnerID      string   `json:"owner_id"`
+	TokenIDs     []string `json:"token_ids"`
+	AuthorizedID string   `json:"authorized_id,omitempty"`
+	Memo         string   `json:"memo,omitempty"`
+}
+
+// NEP-245 multi token events.
+var (
+	MtMintEvent     = NewKind[MtMint](NEP245Standard, NEP245Version, "mt_mint")
+	MtTransferEvent = NewKind[MtTransfer](NEP245Standard, NEP245Version, "mt_transfer")
+	MtBurnEvent     = NewKind[MtBurn](NEP245Standard, NEP245Version, "mt_burn")
+)
+
+// MtMint is the data of an mt_mint event. Amounts are decimal strings, one per token ID.
+type MtMint struct {
+	OwnerID  string   `json:"owner_id"`
+	TokenIDs []string `json:"token_ids"`
+	Amounts  []string `json:"amounts"`
+	Memo     string   `json:"memo,omitempty"`
+}
+
+// MtTransfer is the data of an mt_transfer event. AuthorizedID is the account that made the transfer on
+// behalf of the owner.
+type MtTransfer struct {
+	AuthorizedID string   `json:"authorized_id,omitempty"`
+	OldOwnerID   string   `json:"old_owner_id"`
+	NewOwnerID   string   `json:"new_owner_id"`
+	TokenIDs     []string `json:"token_ids"`
+	Amounts      []string `json:"amounts"`
+	Memo         string   `json:"memo,omitempty"`
+}
+
+// MtBurn is the data of an mt_burn event.
+type MtBurn struct {
+	OwnerID      string   `json:"owner_id"`
+	AuthorizedID string   `json:"authorized_id,omitempty"`
+	TokenIDs     []string `json:"token_ids"`
+	Amounts      []string `json:"amounts"`
+	Memo         string   `json:"memo,omitempty"`
+}
@@ -0,0 +1,86 @@
+// Package main is a linkdrop contract: it sends NEAR to whoever holds the private key of a claim key.
+//
+// send adds the attached NEAR, minus the gas allowance of the key, to a drop for a public key. The holder
+// of the private key signs claim to receive the drop on an existing account, or create_account_and_claim
+// to create a new account funded with it. A failed claim puts the drop back.
+package main
+
+import (
+	"errors"
+
+	"github.com/vlmoon99/near-sdk-go/keys"
+	"github.com/vlmoon99/near-sdk-go/linkdrop"
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+// @contract:state
+type Contract struct {
+	Linkdrop *linkdrop.Linkdrop
+}
+
+// @contract:init
+func (c *Contract) Init() {
+	c.Linkdrop = linkdrop.New("l")
+}
+
+func parseKey(publicKey string) (types.PublicKey, error) {
+	pk, err := types.PublicKeyFromString(publicKey)
+	if err != nil {
+		return types.PublicKey{}, errors.New(keys.ErrInvalidPublicKey + publicKey)
+	}
+	return *pk, nil
+}
+
+// Send drops the attached deposit, minus the claim key allowance, for publicKey.
+// @contract:payable min_deposit=0.1NEAR
+func (c *Contract) Send(publicKey string) error {
+	pk, err := parseKey(publicKey)
+	if err != nil {
+		return err
+	}
+	return c.Linkdrop.Send(pk)
+}
+
+// Claim sends the drop of the signing claim key to accountId.
+// @contract:mutating
+func (c *Contract) Claim(accountId string) error {
+	return c.Linkdrop.Claim(accountId)
+}
+
+// CreateAccountAndClaim creates newAccountId with newPublicKey as its full access key and the drop of the
+// signing claim key as its balance.
+// @contract:mutating
+func (c *Contract) CreateAccountAndClaim(newAccountId string, newPublicKey string) error {
+	pk, err := parseKey(newPublicKey)
+	if err != nil {
+		return err
+	}
+	return c.Linkdrop.CreateAccountAndClaim(newAccountId, pk)
+}
+
+// @contract:mutating
+// @contract:promise_callback
+func (c *Contract) OnClaimed(input linkdrop.ClaimCallback, result promise.PromiseResult) (bool, error) {
+	return c.Linkdrop.OnClaimed(input, result)
+}
+
+// @contract:mutating
+// @contract:promise_callback
+func (c *Contract) OnAccountCreated(input linkdrop.ClaimCallback, result promise.PromiseResult) (bool, error) {
+	return c.Linkdrop.OnClaimed(input, result)
+}
+
+// GetKeyBalance returns the yoctoNEAR publicKey can claim.
+// @contract:view
+func (c *Contract) GetKeyBalance(publicKey string) (string, error) {
+	pk, err := parseKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	balance, err := c.Linkdrop.GetKeyBalance(pk)
+	if err != nil {
+		return "", err
+	}
+	return balance.String(), nil
+}
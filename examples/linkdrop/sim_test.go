@@ -0,0 +1,174 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/linkdrop"
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/sim"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+const (
+	contractID = "linkdrop.near"
+	aliceID    = "alice.near"
+	bobID      = "bob.near"
+)
+
+// callback plays the role of a generated promise callback entry point taking a ClaimCallback.
+func callback(fn func(c *Contract, input linkdrop.ClaimCallback, result promise.PromiseResult) (bool, error)) func() {
+	return sim.Callback(func(c *Contract, result promise.PromiseResult) (interface{}, error) {
+		var args linkdrop.ClaimCallback
+		sim.Input(&args)
+		return fn(c, args, result)
+	})
+}
+
+func linkdropContract() sim.Methods {
+	return sim.Methods{
+		"init": sim.Init(func(c *Contract) error {
+			c.Init()
+			return nil
+		}),
+		"send": sim.Method(func(c *Contract) (interface{}, error) {
+			var args struct {
+				PublicKey string `json:"public_key"`
+			}
+			sim.Input(&args)
+			return nil, c.Send(args.PublicKey)
+		}),
+		"claim": sim.Method(func(c *Contract) (interface{}, error) {
+			var args struct {
+				AccountID string `json:"account_id"`
+			}
+			sim.Input(&args)
+			return nil, c.Claim(args.AccountID)
+		}),
+		"create_account_and_claim": sim.Method(func(c *Contract) (interface{}, error) {
+			var args struct {
+				NewAccountID string `json:"new_account_id"`
+				NewPublicKey string `json:"new_public_key"`
+			}
+			sim.Input(&args)
+			return nil, c.CreateAccountAndClaim(args.NewAccountID, args.NewPublicKey)
+		}),
+		"on_claimed":         callback((*Contract).OnClaimed),
+		"on_account_created": callback((*Contract).OnAccountCreated),
+		"get_key_balance": sim.View(func(c *Contract) (interface{}, error) {
+			var args struct {
+				PublicKey string `json:"public_key"`
+			}
+			sim.Input(&args)
+			return c.GetKeyBalance(args.PublicKey)
+		}),
+	}
+}
+
+func setupChain(t *testing.T) *sim.Chain {
+	t.Helper()
+	contracts := map[string]sim.Contract{contractID: linkdropContract()}
+	chain := sim.Setup(t, sim.NEAR(100), contracts, contractID, aliceID, bobID)
+	if result := chain.MustCall(t, contractID, contractID, "init", nil); result.Failed() {
+		t.Fatalf("failed to initialize the contract: %v", result.Failure)
+	}
+	return chain
+}
+
+// send drops 1 NEAR for key from alice.near.
+func send(t *testing.T, chain *sim.Chain, key types.PublicKey) {
+	t.Helper()
+	args := map[string]string{"public_key": key.ToBase58String()}
+	if result, err := chain.Call(aliceID, contractID, "send", args, sim.NEAR(1)); err != nil || result.Failed() || len(result.ReceiptFailures()) != 0 {
+		t.Fatalf("send failed: %v %v %v", err, result.Failure, result.ReceiptFailures())
+	}
+}
+
+func keyBalance(t *testing.T, chain *sim.Chain, key types.PublicKey) string {
+	t.Helper()
+	result, err := chain.View(contractID, "get_key_balance", map[string]string{"public_key": key.ToBase58String()})
+	if err != nil {
+		t.Fatalf("view failed: %v", err)
+	}
+	if result.Failed() {
+		return ""
+	}
+	var balance string
+	if err := result.Unmarshal(&balance); err != nil {
+		t.Fatalf("failed to decode the balance: %v", err)
+	}
+	return balance
+}
+
+func dropAmount() types.Uint128 {
+	amount, _ := sim.NEAR(1).Sub(linkdrop.DefaultAllowance)
+	return amount
+}
+
+func TestSimSendAndClaim(t *testing.T) {
+	chain := setupChain(t)
+	key := sim.NewKey(1)
+	send(t, chain, key)
+	if balance := keyBalance(t, chain, key); balance != dropAmount().String() {
+		t.Errorf("expected the key to hold %s, got %s", dropAmount().String(), balance)
+	}
+
+	before := chain.Account(bobID).Balance
+	args := map[string]string{"account_id": bobID}
+	result, err := chain.CallWithKey(contractID, key.Bytes(), contractID, "claim", args, types.Uint128{})
+	if err != nil || result.Failed() || len(result.ReceiptFailures()) != 0 {
+		t.Fatalf("claim failed: %v %v %v", err, result.Failure, result.ReceiptFailures())
+	}
+	expected, _ := before.Add(dropAmount())
+	if balance := chain.Account(bobID).Balance; balance.Cmp(expected) != 0 {
+		t.Errorf("expected bob.near to have %s, got %s", expected.String(), balance.String())
+	}
+	if balance := keyBalance(t, chain, key); balance != "" {
+		t.Errorf("expected the drop to be claimed, got %s", balance)
+	}
+	if _, err := chain.CallWithKey(contractID, key.Bytes(), contractID, "claim", args, types.Uint128{}); err == nil {
+		t.Errorf("expected the claim key to be deleted")
+	}
+}
+
+func TestSimCreateAccountAndClaim(t *testing.T) {
+	chain := setupChain(t)
+	key, owner := sim.NewKey(1), sim.NewKey(2)
+	send(t, chain, key)
+
+	args := map[string]string{"new_account_id": "carol.near", "new_public_key": owner.ToBase58String()}
+	result, err := chain.CallWithKey(contractID, key.Bytes(), contractID, "create_account_and_claim", args, types.Uint128{})
+	if err != nil || result.Failed() || len(result.ReceiptFailures()) != 0 {
+		t.Fatalf("create_account_and_claim failed: %v %v %v", err, result.Failure, result.ReceiptFailures())
+	}
+	carol := chain.Account("carol.near")
+	if carol == nil || carol.Balance.Cmp(dropAmount()) != 0 {
+		t.Fatalf("expected carol.near to be created with the drop, got %+v", carol)
+	}
+	if accessKey, ok := carol.Keys[string(owner.Bytes())]; !ok || !accessKey.FullAccess {
+		t.Errorf("expected carol.near to get a full access key, got %+v", carol.Keys)
+	}
+}
+
+func TestSimFailedClaimRefunds(t *testing.T) {
+	chain := setupChain(t)
+	key := sim.NewKey(1)
+	send(t, chain, key)
+	before := chain.Account(contractID).Balance
+
+	args := map[string]string{"account_id": "missing.near"}
+	result, err := chain.CallWithKey(contractID, key.Bytes(), contractID, "claim", args, types.Uint128{})
+	if err != nil || len(result.ReceiptFailures()) != 1 {
+		t.Fatalf("expected the transfer to fail, got %v %v", err, result.ReceiptFailures())
+	}
+	if balance := keyBalance(t, chain, key); balance != dropAmount().String() {
+		t.Errorf("expected the drop to be restored, got %s", balance)
+	}
+	if balance := chain.Account(contractID).Balance; balance.Cmp(before) < 0 {
+		t.Errorf("expected the NEAR to come back, got %s instead of %s", balance.String(), before.String())
+	}
+
+	args = map[string]string{"account_id": bobID}
+	if result, err := chain.CallWithKey(contractID, key.Bytes(), contractID, "claim", args, types.Uint128{}); err != nil || result.Failed() || len(result.ReceiptFailures()) != 0 {
+		t.Fatalf("expected the key to claim again: %v %v", err, result.ReceiptFailures())
+	}
+}
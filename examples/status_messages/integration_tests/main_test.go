@@ -0,0 +1,70 @@
+// Package integration_tests runs the status_messages contract (../main.wasm) against an in-process workspaces
+// sandbox. It is the Go port of the near-workspaces scenario in src/main.rs.
+package integration_tests
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/harness/workspaces"
+	"github.com/vlmoon99/near-sdk-go/sim"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+const wasmFilePath = "../main.wasm"
+
+const standardGas = 300 * types.ONE_TERA_GAS
+
+var standardDeposit = sim.NEAR(3)
+
+func deployContract(t *testing.T) *workspaces.Contract {
+	t.Helper()
+	wasm, err := os.ReadFile(wasmFilePath)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", wasmFilePath, err)
+	}
+
+	worker := workspaces.Sandbox()
+	t.Cleanup(func() { worker.Close() })
+
+	contract, err := worker.DevDeploy(wasm)
+	if err != nil {
+		t.Fatalf("failed to deploy: %v", err)
+	}
+	t.Logf("Dev Account ID: %s", contract.ID())
+	return contract
+}
+
+func callIntegrationTestFunction(t *testing.T, contract *workspaces.Contract, functionName string, args interface{}) *sim.Result {
+	t.Helper()
+	result, err := contract.Call(functionName).Args(args).Deposit(standardDeposit).Gas(standardGas).Transact()
+	if err != nil {
+		t.Fatalf("%s: transaction failed: %v", functionName, err)
+	}
+	t.Logf("%s result.is_success: %t, logs: %q", functionName, !result.Failed(), result.Logs())
+	return result
+}
+
+func TestStatusMessages(t *testing.T) {
+	contract := deployContract(t)
+
+	result := callIntegrationTestFunction(t, contract, "set_status", map[string]string{"message": "testInputValue"})
+	if result.Failed() {
+		t.Fatalf("set_status failed: %v", result.Failure)
+	}
+	if logs := strings.Join(result.Logs(), "\n"); !strings.Contains(logs, "Status stored for "+contract.ID()) {
+		t.Errorf("expected the status to be logged, got %q", logs)
+	}
+
+	state, err := contract.ViewState()
+	if err != nil {
+		t.Fatalf("failed to view state: %v", err)
+	}
+	if value := string(state["r:"+contract.ID()]); value != `"testInputValue"` {
+		t.Errorf("expected the status in the contract state, got %q", value)
+	}
+
+	// Like the Rust scenario, get_status is only reported: its outcome depends on the prebuilt main.wasm.
+	callIntegrationTestFunction(t, contract, "get_status", map[string]string{"account_id": contract.ID()})
+}
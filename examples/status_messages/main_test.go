@@ -4,23 +4,13 @@ import (
 	"testing"
 
 	"github.com/vlmoon99/near-sdk-go/collections"
-	"github.com/vlmoon99/near-sdk-go/env"
-	"github.com/vlmoon99/near-sdk-go/system"
+	"github.com/vlmoon99/near-sdk-go/testutils"
 )
 
-// Initialize the MockSystem globally for the tests
-func init() {
-	env.SetEnv(system.NewMockSystem())
-}
-
 // Helper to setup the test environment and initialize the contract
 func setupTest(t *testing.T) *StatusMessage {
-	mockSys, ok := env.NearBlockchainImports.(*system.MockSystem)
-	if !ok {
-		t.Fatal("Environment is not set to MockSystem")
-	}
-	// Reset storage between tests
-	mockSys.Storage = make(map[string][]byte)
+	// Every Build starts with an empty storage
+	testutils.NewContextBuilder().Build()
 
 	contract := &StatusMessage{}
 	contract.Init()
@@ -43,11 +33,10 @@ func TestStatusMessage_Init(t *testing.T) {
 
 func TestStatusMessage_SetStatus(t *testing.T) {
 	contract := setupTest(t)
-	mockSys := env.NearBlockchainImports.(*system.MockSystem)
 
 	// Simulate "bob.near" calling the contract
 	caller := "bob.near"
-	mockSys.PredecessorAccountIdSys = caller
+	testutils.NewContextBuilder().Predecessor(caller).Build()
 
 	message := "Hello form Bob!"
 	contract.SetStatus(message)
@@ -61,8 +50,7 @@ func TestStatusMessage_SetStatus(t *testing.T) {
 
 func TestStatusMessage_GetStatus_NotFound(t *testing.T) {
 	// We don't use setupTest here to simulate a manual setup or partial state
-	mockSys := env.NearBlockchainImports.(*system.MockSystem)
-	mockSys.Storage = make(map[string][]byte)
+	testutils.NewContextBuilder().Build()
 
 	contract := &StatusMessage{}
 	// Manually initialize the map with prefix "r"
@@ -79,14 +67,13 @@ func TestStatusMessage_GetStatus_NotFound(t *testing.T) {
 
 func TestStatusMessage_MultipleUsers(t *testing.T) {
 	contract := setupTest(t)
-	mockSys := env.NearBlockchainImports.(*system.MockSystem)
 
 	// 1. Alice sets status
-	mockSys.PredecessorAccountIdSys = "alice.near"
+	ctx := testutils.NewContextBuilder().Predecessor("alice.near").Build()
 	contract.SetStatus("I am Alice")
 
-	// 2. Bob sets status
-	mockSys.PredecessorAccountIdSys = "bob.near"
+	// 2. Bob sets status, on top of the state left by Alice's call
+	testutils.NewContextBuilder().Predecessor("bob.near").State(ctx.Storage).Build()
 	contract.SetStatus("I am Bob")
 
 	// 3. Verify Alice's data is distinct
@@ -104,13 +91,12 @@ func TestStatusMessage_MultipleUsers(t *testing.T) {
 
 func TestStatusMessage_Persistence_Simulation(t *testing.T) {
 	contract := setupTest(t)
-	mockSys := env.NearBlockchainImports.(*system.MockSystem)
 
 	// 1. Set state with the original contract instance
 	user := "persistent.user"
 	message := "Data that should survive"
 
-	mockSys.PredecessorAccountIdSys = user
+	ctx := testutils.NewContextBuilder().Predecessor(user).Build()
 	contract.SetStatus(message)
 
 	// 2. Simulate a new WASM execution (new struct instance)
@@ -118,7 +104,8 @@ func TestStatusMessage_Persistence_Simulation(t *testing.T) {
 	newContractInstance := &StatusMessage{}
 	newContractInstance.Records = collections.NewLookupMap[string, string]("r")
 
-	// 3. Verify data exists in the new instance (reading from the same MockSystem storage)
+	// 3. Verify data exists in the new instance, in a view call over the stored state
+	testutils.NewContextBuilder().State(ctx.Storage).View().Build()
 	result := newContractInstance.GetStatus(user)
 	if result != message {
 		t.Errorf("Persistence check failed. Expected '%s', got '%s'", message, result)
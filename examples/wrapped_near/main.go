@@ -0,0 +1,180 @@
+// Package main is a wrapped NEAR (wNEAR) contract: a NEP-141 token backed 1:1 by the NEAR it holds.
+//
+// near_deposit mints as many tokens as NEAR is attached, near_withdraw burns tokens and sends the same
+// amount of NEAR back. Holders register through NEP-145 storage_deposit before their first deposit.
+package main
+
+import (
+	"github.com/vlmoon99/near-sdk-go/contract"
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/standards/ft"
+	"github.com/vlmoon99/near-sdk-go/standards/sourcemeta"
+	"github.com/vlmoon99/near-sdk-go/standards/storage"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+// Errors of the contract, listed by the contract_errors view.
+var (
+	ErrRequiresOneYocto = contract.NewError("REQUIRES_ONE_YOCTO", "requires attached deposit of exactly 1 yoctoNEAR")
+	ErrInvalidAmount    = contract.NewError("INVALID_AMOUNT", "the amount is not a valid U128")
+)
+
+var oneYocto = types.Uint128{Hi: 0, Lo: 1}
+
+func invalidAmount(amount string) error {
+	return ErrInvalidAmount.WithDetails(map[string]string{"amount": amount})
+}
+
+func init() {
+	contract.DeclareErrors("near_withdraw", ErrRequiresOneYocto, ErrInvalidAmount)
+	contract.DeclareErrors("ft_transfer", ErrInvalidAmount)
+	contract.DeclareErrors("ft_transfer_call", ErrInvalidAmount)
+	contract.DeclareErrors("ft_resolve_transfer", ErrInvalidAmount)
+
+	sourcemeta.Declare(sourcemeta.ContractSourceMetadata{
+		Link: sourcemeta.String("https://github.com/vlmoon99/near-sdk-go"),
+		Standards: []sourcemeta.Standard{
+			{Standard: "nep141", Version: "1.0.0"},
+			{Standard: "nep145", Version: "1.0.0"},
+		},
+	})
+}
+
+// ContractSourceMetadata is the NEP-330 contract_source_metadata view.
+//
+//go:export contract_source_metadata
+func ContractSourceMetadata() {
+	sourcemeta.View()
+}
+
+// ContractErrors is the contract_errors view listing the errors declared above.
+//
+//go:export contract_errors
+func ContractErrors() {
+	contract.ErrorsView()
+}
+
+// @contract:state
+type Contract struct {
+	Token   *ft.FungibleToken
+	Storage *storage.Management
+}
+
+// @contract:init
+func (c *Contract) Init() {
+	c.Token = ft.New("t")
+	c.Storage = c.Token.NewStorageManagement("s")
+}
+
+// NearDeposit wraps the attached NEAR into the same amount of tokens for the predecessor, who must be
+// registered.
+// @contract:payable min_deposit=0.000000000000000000000001NEAR
+func (c *Contract) NearDeposit() error {
+	accountID, err := env.GetPredecessorAccountID()
+	if err != nil {
+		return err
+	}
+	amount, err := env.GetAttachedDeposit()
+	if err != nil {
+		return err
+	}
+	return c.Token.Mint(accountID, amount, "Deposit")
+}
+
+// NearWithdraw unwraps amount tokens of the predecessor and sends them back as NEAR, together with the
+// attached yoctoNEAR.
+// @contract:payable min_deposit=0.000000000000000000000001NEAR
+func (c *Contract) NearWithdraw(amount string) error {
+	attached, _ := env.GetAttachedDeposit()
+	if attached.Cmp(oneYocto) != 0 {
+		return ErrRequiresOneYocto
+	}
+	value, err := types.U128FromString(amount)
+	if err != nil {
+		return invalidAmount(amount)
+	}
+	accountID, err := env.GetPredecessorAccountID()
+	if err != nil {
+		return err
+	}
+	if err := c.Token.Burn(accountID, value, "Withdraw"); err != nil {
+		return err
+	}
+
+	refund, err := value.Add(oneYocto)
+	if err != nil {
+		return err
+	}
+	promise.CreateBatch(accountID).Transfer(refund)
+	return nil
+}
+
+// @contract:payable min_deposit=0.000000000000000000000001NEAR
+func (c *Contract) FtTransfer(receiverId string, amount string, memo string) error {
+	value, err := types.U128FromString(amount)
+	if err != nil {
+		return invalidAmount(amount)
+	}
+	return c.Token.FtTransfer(receiverId, value, memo)
+}
+
+// @contract:payable min_deposit=0.000000000000000000000001NEAR
+func (c *Contract) FtTransferCall(receiverId string, amount string, memo string, msg string) error {
+	value, err := types.U128FromString(amount)
+	if err != nil {
+		return invalidAmount(amount)
+	}
+	transfer, err := c.Token.FtTransferCall(receiverId, value, memo, msg)
+	if err != nil {
+		return err
+	}
+	transfer.Value()
+	return nil
+}
+
+// @contract:mutating
+// @contract:promise_callback
+func (c *Contract) FtResolveTransfer(input ft.ResolveTransferArgs, result promise.PromiseResult) (string, error) {
+	value, err := types.U128FromString(input.Amount)
+	if err != nil {
+		return "", invalidAmount(input.Amount)
+	}
+	used, err := c.Token.FtResolveTransfer(input.SenderID, input.ReceiverID, value, result)
+	if err != nil {
+		return "", err
+	}
+	return used.String(), nil
+}
+
+// @contract:view
+func (c *Contract) FtTotalSupply() string {
+	return c.Token.FtTotalSupply()
+}
+
+// @contract:view
+func (c *Contract) FtBalanceOf(accountId string) string {
+	return c.Token.FtBalanceOf(accountId)
+}
+
+// @contract:payable min_deposit=0.000000000000000000000001NEAR
+func (c *Contract) StorageDeposit(accountId string, registrationOnly bool) (storage.StorageBalance, error) {
+	return c.Storage.StorageDeposit(accountId, registrationOnly, c.Token)
+}
+
+// StorageUnregister removes the predecessor. With force, its remaining tokens are burned and the NEAR
+// backing them stays in the contract.
+// @contract:payable min_deposit=0.000000000000000000000001NEAR
+func (c *Contract) StorageUnregister(force bool) (bool, error) {
+	return c.Storage.StorageUnregister(force, c.Token)
+}
+
+// @contract:view
+func (c *Contract) StorageBalanceOf(accountId string) *storage.StorageBalance {
+	return c.Storage.StorageBalanceOf(accountId)
+}
+
+// @contract:view
+func (c *Contract) StorageBalanceBounds() storage.StorageBalanceBounds {
+	return c.Storage.StorageBalanceBounds()
+}
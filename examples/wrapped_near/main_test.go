@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/contract"
+	"github.com/vlmoon99/near-sdk-go/events"
+	"github.com/vlmoon99/near-sdk-go/standards/ft"
+	"github.com/vlmoon99/near-sdk-go/testutils"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+const contractID = "wrap.near"
+
+func near(amount uint64) types.Uint128 {
+	oneNear, _ := types.U128FromString("1000000000000000000000000")
+	value, _ := oneNear.Mul(types.U64ToUint128(amount))
+	return value
+}
+
+// call builds the context of a call made by predecessor on top of the state left by the previous one.
+func call(previous *testutils.Context, predecessor string) *testutils.ContextBuilder {
+	return testutils.NewContextBuilder().
+		CurrentAccount(contractID).
+		Predecessor(predecessor).
+		Balance(near(100)).
+		State(previous.Storage)
+}
+
+// setup initializes the contract and registers alice.near.
+func setup(t *testing.T) (*Contract, *testutils.Context) {
+	t.Helper()
+	ctx := testutils.NewContextBuilder().CurrentAccount(contractID).Build()
+	c := &Contract{}
+	c.Init()
+
+	ctx = call(ctx, "alice.near").DepositYocto(c.Storage.MinBalance).Build()
+	if _, err := c.StorageDeposit("", true); err != nil {
+		t.Fatalf("failed to register alice.near: %v", err)
+	}
+	return c, ctx
+}
+
+func deposit(t *testing.T, c *Contract, previous *testutils.Context, accountID string, amount uint64) *testutils.Context {
+	t.Helper()
+	ctx := call(previous, accountID).DepositYocto(near(amount)).Build()
+	if err := c.NearDeposit(); err != nil {
+		t.Fatalf("failed to deposit: %v", err)
+	}
+	return ctx
+}
+
+func TestNearDeposit(t *testing.T) {
+	c, ctx := setup(t)
+	ctx = deposit(t, c, ctx, "alice.near", 5)
+
+	if balance := c.FtBalanceOf("alice.near"); balance != near(5).String() {
+		t.Errorf("expected a balance of 5 NEAR, got %s", balance)
+	}
+	if supply := c.FtTotalSupply(); supply != near(5).String() {
+		t.Errorf("expected a total supply of 5 NEAR, got %s", supply)
+	}
+	mints, err := events.FtMintEvent.Find(ctx.Logs())
+	if err != nil || len(mints) != 1 || mints[0] != (events.FtMint{OwnerID: "alice.near", Amount: near(5).String(), Memo: "Deposit"}) {
+		t.Errorf("unexpected mint events %+v: %v", mints, err)
+	}
+}
+
+func TestNearDepositRequiresRegistration(t *testing.T) {
+	c, ctx := setup(t)
+	call(ctx, "bob.near").DepositYocto(near(1)).Build()
+
+	if err := c.NearDeposit(); err == nil || err.Error() != ft.ErrAccountNotRegistered+"bob.near" {
+		t.Errorf("expected %q, got %v", ft.ErrAccountNotRegistered+"bob.near", err)
+	}
+}
+
+func TestNearWithdraw(t *testing.T) {
+	c, ctx := setup(t)
+	ctx = deposit(t, c, ctx, "alice.near", 5)
+
+	ctx = call(ctx, "alice.near").DepositYocto(oneYocto).Build()
+	before := ctx.AccountBalanceSys
+	if err := c.NearWithdraw(near(2).String()); err != nil {
+		t.Fatalf("failed to withdraw: %v", err)
+	}
+
+	if balance := c.FtBalanceOf("alice.near"); balance != near(3).String() {
+		t.Errorf("expected a balance of 3 NEAR, got %s", balance)
+	}
+	expected, _ := before.Sub(near(2))
+	expected, _ = expected.Sub(oneYocto)
+	if ctx.AccountBalanceSys.Cmp(expected) != 0 {
+		t.Errorf("expected 2 NEAR and 1 yoctoNEAR to be sent back, the balance went from %s to %s", before.String(), ctx.AccountBalanceSys.String())
+	}
+	burns, err := events.FtBurnEvent.Find(ctx.Logs())
+	if err != nil || len(burns) != 1 || burns[0].Amount != near(2).String() {
+		t.Errorf("unexpected burn events %+v: %v", burns, err)
+	}
+}
+
+func TestNearWithdrawErrors(t *testing.T) {
+	c, ctx := setup(t)
+	ctx = deposit(t, c, ctx, "alice.near", 1)
+
+	call(ctx, "alice.near").Build()
+	if err := c.NearWithdraw(near(1).String()); !errors.Is(err, ErrRequiresOneYocto) {
+		t.Errorf("expected %q, got %v", ErrRequiresOneYocto, err)
+	}
+
+	call(ctx, "alice.near").DepositYocto(oneYocto).Build()
+	if err := c.NearWithdraw(near(2).String()); err == nil || err.Error() != ft.ErrInsufficientBalance {
+		t.Errorf("expected %q, got %v", ft.ErrInsufficientBalance, err)
+	}
+	if err := c.NearWithdraw("one"); err == nil || err.Error() != invalidAmount("one").Error() {
+		t.Errorf("expected %v, got %v", invalidAmount("one"), err)
+	}
+}
+
+func TestContractSourceMetadata(t *testing.T) {
+	ctx := testutils.NewContextBuilder().CurrentAccount(contractID).View().Build()
+	ContractSourceMetadata()
+
+	expected := `{"version":null,"link":"https://github.com/vlmoon99/near-sdk-go",` +
+		`"standards":[{"standard":"nep141","version":"1.0.0"},{"standard":"nep145","version":"1.0.0"},{"standard":"nep330","version":"1.2.0"}],` +
+		`"build_info":null}`
+	if returned := string(ctx.Registers[0]); returned != expected {
+		t.Errorf("expected %s, got %s", expected, returned)
+	}
+}
+
+func TestContractErrors(t *testing.T) {
+	ctx := testutils.NewContextBuilder().CurrentAccount(contractID).View().Build()
+	ContractErrors()
+
+	var declared []contract.MethodErrors
+	if err := json.Unmarshal(ctx.Registers[0], &declared); err != nil {
+		t.Fatalf("failed to decode the errors: %v", err)
+	}
+	codes := map[string][]string{}
+	for _, method := range declared {
+		for _, e := range method.Errors {
+			codes[method.Name] = append(codes[method.Name], e.Code)
+		}
+	}
+	if len(codes) != 4 || len(codes["near_withdraw"]) != 2 || codes["near_withdraw"][0] != ErrRequiresOneYocto.Code ||
+		codes["near_withdraw"][1] != ErrInvalidAmount.Code || codes["ft_resolve_transfer"][0] != ErrInvalidAmount.Code {
+		t.Errorf("unexpected declared errors %v", codes)
+	}
+}
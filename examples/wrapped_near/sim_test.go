@@ -0,0 +1,191 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/contract"
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/sim"
+	"github.com/vlmoon99/near-sdk-go/standards/ft"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+const (
+	aliceID = "alice.near"
+	poolID  = "pool.near"
+)
+
+func wrappedNear() sim.Methods {
+	return sim.Methods{
+		"init": sim.Init(func(c *Contract) error {
+			c.Init()
+			return nil
+		}),
+		"storage_deposit": sim.Method(func(c *Contract) (interface{}, error) {
+			var args struct {
+				AccountID        string `json:"account_id"`
+				RegistrationOnly bool   `json:"registration_only"`
+			}
+			sim.Input(&args)
+			return c.StorageDeposit(args.AccountID, args.RegistrationOnly)
+		}),
+		"near_deposit": sim.Method(func(c *Contract) (interface{}, error) {
+			return nil, c.NearDeposit()
+		}),
+		"near_withdraw": sim.Method(func(c *Contract) (interface{}, error) {
+			var args struct {
+				Amount string `json:"amount"`
+			}
+			sim.Input(&args)
+			return nil, c.NearWithdraw(args.Amount)
+		}),
+		"ft_transfer_call": sim.Method(func(c *Contract) (interface{}, error) {
+			var args struct {
+				ReceiverID string `json:"receiver_id"`
+				Amount     string `json:"amount"`
+				Msg        string `json:"msg"`
+			}
+			sim.Input(&args)
+			return nil, c.FtTransferCall(args.ReceiverID, args.Amount, "", args.Msg)
+		}),
+		"ft_resolve_transfer": sim.Callback(func(c *Contract, result promise.PromiseResult) (interface{}, error) {
+			var args ft.ResolveTransferArgs
+			sim.Input(&args)
+			return c.FtResolveTransfer(args, result)
+		}),
+		"ft_balance_of": sim.View(func(c *Contract) (interface{}, error) {
+			var args struct {
+				AccountID string `json:"account_id"`
+			}
+			sim.Input(&args)
+			return c.FtBalanceOf(args.AccountID), nil
+		}),
+		"ft_total_supply": sim.View(func(c *Contract) (interface{}, error) {
+			return c.FtTotalSupply(), nil
+		}),
+	}
+}
+
+// pool keeps the tokens sent with the "stake" message and returns the others.
+func pool() sim.Methods {
+	router := contract.NewFtReceiverRouter().
+		On("stake", func(transfer contract.FtTransfer) (types.Uint128, error) {
+			return types.Uint128{}, nil
+		})
+	router.Fallback = func(transfer contract.FtTransfer) (types.Uint128, error) {
+		return transfer.Amount, nil
+	}
+	return sim.Methods{
+		"ft_on_transfer": func() {
+			contract.HandleFtOnTransfer(router)
+		},
+	}
+}
+
+func setupChain(t *testing.T) *sim.Chain {
+	t.Helper()
+	contracts := map[string]sim.Contract{contractID: wrappedNear(), poolID: pool()}
+	chain := sim.Setup(t, sim.NEAR(100), contracts, contractID, aliceID, poolID)
+	if result := chain.MustCall(t, contractID, contractID, "init", nil); result.Failed() {
+		t.Fatalf("failed to initialize the contract: %v", result.Failure)
+	}
+
+	for _, accountID := range []string{aliceID, poolID} {
+		args := map[string]interface{}{"account_id": accountID, "registration_only": true}
+		if result, err := chain.Call(aliceID, contractID, "storage_deposit", args, sim.NEAR(1)); err != nil || result.Failed() {
+			t.Fatalf("failed to register %s: %v %v", accountID, err, result.Failure)
+		}
+	}
+	return chain
+}
+
+func viewBalance(t *testing.T, chain *sim.Chain, accountID string) string {
+	t.Helper()
+	result, err := chain.View(contractID, "ft_balance_of", map[string]string{"account_id": accountID})
+	if err != nil {
+		t.Fatalf("view failed: %v", err)
+	}
+	var balance string
+	if err := result.Unmarshal(&balance); err != nil {
+		t.Fatalf("failed to decode the balance: %v", err)
+	}
+	return balance
+}
+
+func TestSimDepositAndWithdraw(t *testing.T) {
+	chain := setupChain(t)
+	before := chain.Account(aliceID).Balance
+
+	if result, err := chain.Call(aliceID, contractID, "near_deposit", nil, sim.NEAR(10)); err != nil || result.Failed() {
+		t.Fatalf("deposit failed: %v %v", err, result.Failure)
+	}
+	if balance := viewBalance(t, chain, aliceID); balance != sim.NEAR(10).String() {
+		t.Errorf("expected 10 wNEAR, got %s", balance)
+	}
+
+	args := map[string]string{"amount": sim.NEAR(4).String()}
+	if result, err := chain.Call(aliceID, contractID, "near_withdraw", args, oneYocto); err != nil || result.Failed() {
+		t.Fatalf("withdraw failed: %v %v", err, result.Failure)
+	}
+	if balance := viewBalance(t, chain, aliceID); balance != sim.NEAR(6).String() {
+		t.Errorf("expected 6 wNEAR, got %s", balance)
+	}
+
+	expected, _ := before.Sub(sim.NEAR(6))
+	if balance := chain.Account(aliceID).Balance; balance.Cmp(expected) != 0 {
+		t.Errorf("expected alice.near to have %s, got %s", expected.String(), balance.String())
+	}
+	result, err := chain.View(contractID, "ft_total_supply", nil)
+	var supply string
+	if err != nil || result.Unmarshal(&supply) != nil || supply != sim.NEAR(6).String() {
+		t.Errorf("expected a total supply of 6 NEAR, got %s: %v", supply, err)
+	}
+}
+
+func TestSimWithdrawMoreThanBalance(t *testing.T) {
+	chain := setupChain(t)
+	if result, err := chain.Call(aliceID, contractID, "near_deposit", nil, sim.NEAR(1)); err != nil || result.Failed() {
+		t.Fatalf("deposit failed: %v %v", err, result.Failure)
+	}
+	before := chain.Account(aliceID).Balance
+
+	args := map[string]string{"amount": sim.NEAR(2).String()}
+	result, err := chain.Call(aliceID, contractID, "near_withdraw", args, oneYocto)
+	if err != nil || !result.Failed() {
+		t.Fatalf("expected the withdrawal to fail, got %v", err)
+	}
+	if balance := chain.Account(aliceID).Balance; balance.Cmp(before) != 0 {
+		t.Errorf("expected the balance to be unchanged, got %s instead of %s", balance.String(), before.String())
+	}
+	if balance := viewBalance(t, chain, aliceID); balance != sim.NEAR(1).String() {
+		t.Errorf("expected 1 wNEAR, got %s", balance)
+	}
+}
+
+func TestSimTransferCall(t *testing.T) {
+	chain := setupChain(t)
+	if result, err := chain.Call(aliceID, contractID, "near_deposit", nil, sim.NEAR(10)); err != nil || result.Failed() {
+		t.Fatalf("deposit failed: %v %v", err, result.Failure)
+	}
+
+	stake := map[string]string{"receiver_id": poolID, "amount": sim.NEAR(3).String(), "msg": "stake"}
+	if result, err := chain.Call(aliceID, contractID, "ft_transfer_call", stake, oneYocto); err != nil || result.Failed() {
+		t.Fatalf("transfer call failed: %v %v", err, result.Failure)
+	}
+	other := map[string]string{"receiver_id": poolID, "amount": sim.NEAR(2).String(), "msg": "other"}
+	result, err := chain.Call(aliceID, contractID, "ft_transfer_call", other, oneYocto)
+	if err != nil || result.Failed() {
+		t.Fatalf("transfer call failed: %v %v", err, result.Failure)
+	}
+	var used string
+	if err := result.Unmarshal(&used); err != nil || used != "0" {
+		t.Errorf("expected the pool to use nothing, got %s: %v", used, err)
+	}
+
+	if balance := viewBalance(t, chain, poolID); balance != sim.NEAR(3).String() {
+		t.Errorf("expected the pool to hold 3 wNEAR, got %s", balance)
+	}
+	if balance := viewBalance(t, chain, aliceID); balance != sim.NEAR(7).String() {
+		t.Errorf("expected alice.near to hold 7 wNEAR, got %s", balance)
+	}
+}
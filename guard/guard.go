@@ -0,0 +1,182 @@
+// Package guard locks keys of the contract state while a cross-contract call is in flight.
+//
+// Between a call and its callback, other transactions run against the same state: a balance debited in the
+// callback can be spent twice in between. The guard locks a key, such as the account whose balance is at
+// stake, before the call and releases it in the callback; methods touching the key fail while it's locked.
+//
+//	// @contract:mutating
+//	func (c *Contract) Withdraw(amount string) error {
+//		accountID, _ := env.GetPredecessorAccountID()
+//		p, nonce, err := c.Guard.Call(accountID, promise.NewCrossContract(c.Token), "ft_transfer", args)
+//		if err != nil {
+//			return err
+//		}
+//		p.Then("on_withdraw", map[string]interface{}{"account_id": accountID, "amount": amount, "nonce": nonce}).Value()
+//		return nil
+//	}
+//
+//	// @contract:promise_callback
+//	func (c *Contract) OnWithdraw(accountId string, amount string, nonce uint64, result promise.PromiseResult) error {
+//		return c.Guard.Settle(accountId, nonce, result, func(result promise.PromiseResult) error {
+//			if result.Success {
+//				return c.debit(accountId, amount)
+//			}
+//			return nil
+//		})
+//	}
+//
+// Each lock gets a nonce, passed to the callback so that it only releases its own lock: once a lock has
+// expired and was taken over, the late callback of the first call leaves the new lock in place.
+//
+// The locks are kept in an UnorderedMap, so the Guard has to be saved with the contract state.
+package guard
+
+import (
+	"errors"
+
+	"github.com/vlmoon99/near-sdk-go/collections"
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/promise"
+)
+
+const (
+	ErrLocked     = "(GUARD_ERROR): the key is locked by a pending call: "
+	ErrNotLocked  = "(GUARD_ERROR): the key is not locked: "
+	ErrInvalidKey = "(GUARD_ERROR): the key can't be empty"
+	ErrLockFailed = "(GUARD_ERROR): failed to store the lock: "
+)
+
+// MaxListLimit is the most locks List returns at once.
+const MaxListLimit = 100
+
+// LogCallFailed is logged by Settle, followed by the key, when the guarded call failed.
+const LogCallFailed = "the guarded call failed, released the lock on "
+
+// LogLockTakenOver is logged by Settle, followed by the key, when the lock of the call was taken over or
+// released by another call and is left alone.
+const LogLockTakenOver = "the lock was taken over by another call, kept the lock on "
+
+// Lock is a key locked by a pending call. AcquiredAt is the block time in milliseconds; Nonce tells the
+// locks of successive calls apart.
+type Lock struct {
+	Key        string `json:"key"`
+	AccountID  string `json:"account_id"`
+	AcquiredAt uint64 `json:"acquired_at"`
+	Nonce      uint64 `json:"nonce"`
+}
+
+// Guard keeps the locked keys.
+type Guard struct {
+	Locks *collections.UnorderedMap[string, Lock] `json:"locks"`
+	// Timeout is the time in milliseconds after which a lock can be taken over, in case its callback never
+	// released it, e.g. because it ran out of gas. Zero keeps locks until they are released.
+	Timeout uint64 `json:"timeout"`
+	// Nonce is the nonce of the last acquired lock.
+	Nonce uint64 `json:"nonce"`
+}
+
+// New creates a guard storing its locks under prefix. Locks expire after timeout milliseconds, or never
+// when it's zero.
+func New(prefix string, timeout uint64) *Guard {
+	return &Guard{Locks: collections.NewUnorderedMap[string, Lock](prefix), Timeout: timeout}
+}
+
+func (g *Guard) expired(lock Lock) bool {
+	return g.Timeout > 0 && env.GetBlockTimeMs() >= lock.AcquiredAt+g.Timeout
+}
+
+// IsLocked reports whether key is locked by a call that hasn't expired.
+func (g *Guard) IsLocked(key string) bool {
+	lock, err := g.Locks.Get(key)
+	return err == nil && !g.expired(lock)
+}
+
+// RequireUnlocked fails while key is locked, for the methods that must not run during a pending call.
+func (g *Guard) RequireUnlocked(key string) error {
+	if g.IsLocked(key) {
+		return errors.New(ErrLocked + key)
+	}
+	return nil
+}
+
+// Acquire locks key, taking over an expired lock, and returns the nonce of the lock. It fails when key is
+// already locked.
+func (g *Guard) Acquire(key string) (uint64, error) {
+	if key == "" {
+		return 0, errors.New(ErrInvalidKey)
+	}
+	if err := g.RequireUnlocked(key); err != nil {
+		return 0, err
+	}
+	accountID, _ := env.GetPredecessorAccountID()
+	lock := Lock{Key: key, AccountID: accountID, AcquiredAt: env.GetBlockTimeMs(), Nonce: g.Nonce + 1}
+	if err := g.Locks.Insert(key, lock); err != nil {
+		return 0, errors.New(ErrLockFailed + err.Error())
+	}
+	g.Nonce = lock.Nonce
+	return lock.Nonce, nil
+}
+
+// Release unlocks key, whichever call holds it.
+func (g *Guard) Release(key string) error {
+	if contains, _ := g.Locks.Contains(key); !contains {
+		return errors.New(ErrNotLocked + key)
+	}
+	return g.Locks.Remove(key)
+}
+
+// Call locks key and calls method on the contract of cc. The returned promise has to be followed by a
+// callback that calls Settle with the same key and the returned nonce.
+func (g *Guard) Call(key string, cc *promise.CrossContract, method string, args interface{}) (*promise.Promise, uint64, error) {
+	nonce, err := g.Acquire(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	return cc.Call(method, args), nonce, nil
+}
+
+// Settle releases the lock on key with the given nonce in the callback of the guarded call, then passes the
+// result of the call to fn, which may be nil. The lock is released whether the call succeeded or failed; a
+// failed call is logged. When the lock expired and was taken over by another call, it's kept, and fn still
+// gets the result.
+//
+// An error from fn fails the callback and reverts its changes, the release included: the key stays locked
+// until the lock expires. fn should undo the effects of a failed call rather than return an error.
+func (g *Guard) Settle(key string, nonce uint64, result promise.PromiseResult, fn func(promise.PromiseResult) error) error {
+	if lock, err := g.Locks.Get(key); err != nil || lock.Nonce != nonce {
+		env.LogString(LogLockTakenOver + key)
+	} else if err := g.Locks.Remove(key); err != nil {
+		return err
+	} else if !result.Success {
+		env.LogString(LogCallFailed + key)
+	}
+	if fn == nil {
+		return nil
+	}
+	return fn(result)
+}
+
+// List returns up to limit locks starting at fromIndex, reading only those locks, for a view listing the
+// pending calls. A zero limit, or one above MaxListLimit, returns MaxListLimit locks. Expired locks are
+// included.
+func (g *Guard) List(fromIndex, limit uint64) []Lock {
+	if limit == 0 || limit > MaxListLimit {
+		limit = MaxListLimit
+	}
+	keys, err := g.Locks.KeysRange(fromIndex, limit)
+	if err != nil {
+		return []Lock{}
+	}
+	locks := make([]Lock, 0, len(keys))
+	for _, key := range keys {
+		if lock, err := g.Locks.Get(key); err == nil {
+			locks = append(locks, lock)
+		}
+	}
+	return locks
+}
+
+// Count returns the number of locks, expired ones included.
+func (g *Guard) Count() uint64 {
+	return g.Locks.Length()
+}
@@ -0,0 +1,226 @@
+package guard
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/sim"
+	"github.com/vlmoon99/near-sdk-go/testutils"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+func expectError(t *testing.T, err error, expected string) {
+	t.Helper()
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
+
+func TestAcquireRelease(t *testing.T) {
+	ctx := testutils.NewContextBuilder().Predecessor("alice.near").Timestamp(1_000_000_000).Build()
+	guard := New("g", 0)
+
+	nonce, err := guard.Acquire("alice.near")
+	if err != nil || nonce != 1 {
+		t.Fatalf("failed to acquire: %d %v", nonce, err)
+	}
+	_, err = guard.Acquire("alice.near")
+	expectError(t, err, ErrLocked+"alice.near")
+	expectError(t, guard.RequireUnlocked("alice.near"), ErrLocked+"alice.near")
+	_, err = guard.Acquire("")
+	expectError(t, err, ErrInvalidKey)
+	if err := guard.RequireUnlocked("bob.near"); err != nil {
+		t.Errorf("expected bob.near to be unlocked, got %v", err)
+	}
+
+	locks := guard.List(0, 0)
+	if len(locks) != 1 || locks[0] != (Lock{Key: "alice.near", AccountID: "alice.near", AcquiredAt: 1000, Nonce: 1}) {
+		t.Errorf("unexpected locks %+v", locks)
+	}
+
+	if err := guard.Settle("alice.near", nonce, promise.NewPromiseResult(2, nil), nil); err != nil {
+		t.Fatalf("failed to settle: %v", err)
+	}
+	if guard.IsLocked("alice.near") || guard.Count() != 0 {
+		t.Errorf("expected a failed call to release the lock")
+	}
+	if logs := ctx.Logs(); len(logs) != 1 || logs[0] != LogCallFailed+"alice.near" {
+		t.Errorf("unexpected logs %v", logs)
+	}
+	expectError(t, guard.Release("alice.near"), ErrNotLocked+"alice.near")
+}
+
+func TestTimeout(t *testing.T) {
+	ctx := testutils.NewContextBuilder().Predecessor("alice.near").Timestamp(1_000_000_000).Build()
+	guard := New("g", 5_000)
+	stale, _ := guard.Acquire("key")
+
+	ctx = testutils.NewContextBuilder().Predecessor("bob.near").Timestamp(5_000_000_000).State(ctx.Storage).Build()
+	_, err := guard.Acquire("key")
+	expectError(t, err, ErrLocked+"key")
+
+	ctx = testutils.NewContextBuilder().Predecessor("bob.near").Timestamp(6_000_000_000).State(ctx.Storage).Build()
+	nonce, err := guard.Acquire("key")
+	if err != nil || nonce == stale {
+		t.Fatalf("expected the expired lock to be taken over with a new nonce, got %d %v", nonce, err)
+	}
+	if locks := guard.List(0, 0); len(locks) != 1 || locks[0].AccountID != "bob.near" || locks[0].AcquiredAt != 6000 {
+		t.Errorf("unexpected locks %+v", locks)
+	}
+
+	settled := false
+	err = guard.Settle("key", stale, promise.NewPromiseResult(1, nil), func(promise.PromiseResult) error {
+		settled = true
+		return nil
+	})
+	if err != nil || !settled {
+		t.Fatalf("expected the stale callback to get its result, got %v", err)
+	}
+	if !guard.IsLocked("key") {
+		t.Errorf("expected the stale callback to keep the lock of the new call")
+	}
+	if logs := ctx.Logs(); len(logs) != 1 || logs[0] != LogLockTakenOver+"key" {
+		t.Errorf("unexpected logs %v", logs)
+	}
+
+	if err := guard.Settle("key", nonce, promise.NewPromiseResult(1, nil), nil); err != nil || guard.IsLocked("key") {
+		t.Errorf("expected the new call to release its lock, got %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	testutils.NewContextBuilder().Predecessor("alice.near").Build()
+	guard := New("g", 0)
+	for _, key := range []string{"a", "b", "c"} {
+		guard.Acquire(key)
+	}
+
+	if locks := guard.List(1, 1); len(locks) != 1 || locks[0].Key != "b" || locks[0].Nonce != 2 {
+		t.Errorf("unexpected page %+v", locks)
+	}
+	if locks := guard.List(1, 0); len(locks) != 2 || locks[1].Key != "c" {
+		t.Errorf("unexpected page %+v", locks)
+	}
+	if locks := guard.List(3, 1); len(locks) != 0 {
+		t.Errorf("expected no locks past the end, got %+v", locks)
+	}
+}
+
+const (
+	appID  = "app.near"
+	bankID = "bank.near"
+)
+
+type state struct {
+	Guard    *Guard
+	Withdraw map[string]int
+}
+
+type payArgs struct {
+	AccountID string `json:"account_id"`
+	Nonce     uint64 `json:"nonce"`
+	Fail      bool   `json:"fail"`
+	Reenter   bool   `json:"reenter"`
+}
+
+// appContract withdraws through bank.near, locking the account until the callback.
+func appContract() sim.Methods {
+	return sim.Methods{
+		"init": sim.Init(func(s *state) error {
+			s.Guard = New("g", 0)
+			s.Withdraw = map[string]int{}
+			return nil
+		}),
+		"withdraw": sim.Method(func(s *state) (interface{}, error) {
+			var args payArgs
+			sim.Input(&args)
+			p, nonce, err := s.Guard.Call(args.AccountID, promise.NewCrossContract(bankID), "pay", args)
+			if err != nil {
+				return nil, err
+			}
+			p.Then("on_withdraw", payArgs{AccountID: args.AccountID, Nonce: nonce})
+			return nil, nil
+		}),
+		"on_withdraw": sim.Callback(func(s *state, result promise.PromiseResult) (interface{}, error) {
+			var args payArgs
+			sim.Input(&args)
+			return nil, s.Guard.Settle(args.AccountID, args.Nonce, result, func(result promise.PromiseResult) error {
+				if result.Success {
+					s.Withdraw[args.AccountID]++
+				}
+				return nil
+			})
+		}),
+		"locks": sim.View(func(s *state) (interface{}, error) {
+			return s.Guard.List(0, 0), nil
+		}),
+	}
+}
+
+// bankContract pays out, fails on demand, and can call withdraw again, returning its result so the first
+// callback waits for it.
+func bankContract() sim.Methods {
+	return sim.Methods{
+		"pay": func() {
+			var args payArgs
+			sim.Input(&args)
+			if args.Fail {
+				env.PanicStr("payment failed")
+				return
+			}
+			if args.Reenter {
+				promise.NewCrossContract(appID).Call("withdraw", payArgs{AccountID: args.AccountID}).Value()
+			}
+		},
+	}
+}
+
+func TestGuardedCall(t *testing.T) {
+	contracts := map[string]sim.Contract{appID: appContract(), bankID: bankContract()}
+	chain := sim.Setup(t, sim.NEAR(10), contracts, appID, bankID, "alice.near")
+	if result := chain.MustCall(t, "alice.near", appID, "init", nil); result.Failed() {
+		t.Fatalf("init failed: %v", result.Failure)
+	}
+
+	withdraw := func(args payArgs) *sim.Result {
+		t.Helper()
+		result, err := chain.Call("alice.near", appID, "withdraw", args, types.Uint128{})
+		if err != nil || result.Failed() {
+			t.Fatalf("withdraw failed: %v %v", err, result.Failure)
+		}
+		return result
+	}
+	withdrawals := func() int {
+		var s state
+		json.Unmarshal(chain.Account(appID).Storage["STATE"], &s)
+		return s.Withdraw["alice.near"]
+	}
+
+	withdraw(payArgs{AccountID: "alice.near"})
+	if withdrawals() != 1 {
+		t.Errorf("expected 1 withdrawal, got %d", withdrawals())
+	}
+
+	result := withdraw(payArgs{AccountID: "alice.near", Fail: true})
+	if withdrawals() != 1 || !strings.Contains(strings.Join(result.Logs(), "\n"), LogCallFailed+"alice.near") {
+		t.Errorf("expected the failed payment to release the lock only, got %d withdrawals, logs %v", withdrawals(), result.Logs())
+	}
+
+	result = withdraw(payArgs{AccountID: "alice.near", Reenter: true})
+	failures := result.ReceiptFailures()
+	if len(failures) != 1 || failures[0].Error() != sim.ErrContractPanicPrefix+ErrLocked+"alice.near" {
+		t.Errorf("expected the reentrant withdrawal to hit the lock, got %v", failures)
+	}
+	if withdrawals() != 1 {
+		t.Errorf("expected the failed reentrant payment not to count, got %d withdrawals", withdrawals())
+	}
+
+	view, err := chain.View(appID, "locks", nil)
+	var locks []Lock
+	if err != nil || view.Unmarshal(&locks) != nil || len(locks) != 0 {
+		t.Errorf("expected no lock left, got %+v: %v", locks, err)
+	}
+}
@@ -0,0 +1,15 @@
+module github.com/vlmoon99/near-sdk-go/harness
+
+go 1.25.4
+
+require (
+	github.com/tetratelabs/wazero v1.9.0
+	github.com/vlmoon99/near-sdk-go v0.0.0
+)
+
+require (
+	github.com/mr-tron/base58 v1.2.0 // indirect
+	github.com/vlmoon99/jsonparser v0.0.1 // indirect
+)
+
+replace github.com/vlmoon99/near-sdk-go => ../
@@ -0,0 +1,165 @@
+// Package harness runs compiled contracts (the `main.wasm` produced by TinyGo) offline, inside the embedded
+// wazero WebAssembly runtime.
+//
+// Every import declared in system/system_near.go is provided to the module. The host functions copy their
+// arguments out of the wasm linear memory and forward them to a system.System implementation, so the same
+// host environment that backs the Go unit tests (system.MockSystem or a sim.Runtime) also backs the real binary.
+//
+// A Contract implements sim.Contract, which makes the exports callable with JSON arguments through sim.Chain:
+//
+//	contract, _ := harness.LoadFile("main.wasm")
+//	chain := sim.New()
+//	chain.CreateAccount("status.near", sim.NEAR(10))
+//	contract.Deploy(chain, "status.near")
+//	result, _ := chain.Call("status.near", "status.near", "set_status", map[string]string{"message": "hi"}, types.Uint128{})
+//
+// Gas is not metered: PrepaidGas and UsedGas are whatever the system.System reports.
+package harness
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+
+	"github.com/vlmoon99/near-sdk-go/sim"
+	"github.com/vlmoon99/near-sdk-go/system"
+)
+
+// InitializeExport is the export TinyGo generates to run package initialization in reactor builds.
+// It is called before every method when the module exports it.
+const InitializeExport = "_initialize"
+
+const (
+	ErrMethodNotExported = "(HARNESS_ERROR): method is not exported by the contract: "
+	ErrMemoryAccess      = "(HARNESS_ERROR): memory access out of bounds"
+	ErrNoMemory          = "(HARNESS_ERROR): contract doesn't export its memory"
+)
+
+// Contract is a compiled contract ready to be executed.
+type Contract struct {
+	code     []byte
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+}
+
+// Load compiles the contract code and registers the NEAR host imports.
+func Load(code []byte) (*Contract, error) {
+	ctx := context.Background()
+	runtime := wazero.NewRuntime(ctx)
+
+	if err := instantiateHost(ctx, runtime); err != nil {
+		runtime.Close(ctx)
+		return nil, err
+	}
+
+	compiled, err := runtime.CompileModule(ctx, code)
+	if err != nil {
+		runtime.Close(ctx)
+		return nil, err
+	}
+
+	return &Contract{code: code, runtime: runtime, compiled: compiled}, nil
+}
+
+// LoadFile reads and compiles the contract at path.
+func LoadFile(path string) (*Contract, error) {
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Load(code)
+}
+
+// Code returns the contract bytes.
+func (c *Contract) Code() []byte {
+	return c.code
+}
+
+// Exports returns the names of the exported functions in alphabetical order.
+func (c *Contract) Exports() []string {
+	var names []string
+	for name := range c.compiled.ExportedFunctions() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close releases the compiled module and the wazero runtime.
+func (c *Contract) Close() error {
+	return c.runtime.Close(context.Background())
+}
+
+// Run calls the exported method in a fresh instance of the module, with sys as the host environment.
+//
+// As on chain, the linear memory doesn't survive between calls; only what the contract writes through sys does.
+// A wasm trap is returned as an error, while panics raised by sys (for example the abort of a sim.Runtime on
+// panic_utf8) propagate to the caller unchanged.
+func (c *Contract) Run(sys system.System, method string) error {
+	if _, exists := c.compiled.ExportedFunctions()[method]; !exists {
+		return errors.New(ErrMethodNotExported + method)
+	}
+
+	call := &hostCall{sys: sys}
+	ctx := context.WithValue(context.Background(), hostCallKey{}, call)
+
+	module, err := c.runtime.InstantiateModule(ctx, c.compiled, wazero.NewModuleConfig().WithName("").WithStartFunctions())
+	if err != nil {
+		return err
+	}
+	defer module.Close(ctx)
+
+	if initialize := module.ExportedFunction(InitializeExport); initialize != nil && method != InitializeExport {
+		if err := call.invoke(ctx, initialize); err != nil {
+			return err
+		}
+	}
+
+	return call.invoke(ctx, module.ExportedFunction(method))
+}
+
+// Execute implements sim.Contract.
+func (c *Contract) Execute(rt *sim.Runtime, method string) error {
+	return c.Run(rt, method)
+}
+
+// Deploy registers the contract code on the chain and deploys it on an existing account,
+// so DeployContract actions carrying the same bytes deploy it as well.
+func (c *Contract) Deploy(chain *sim.Chain, accountID string) error {
+	chain.RegisterCode(c.code, c)
+	if err := chain.Deploy(accountID, c); err != nil {
+		return err
+	}
+	chain.Account(accountID).Code = c.code
+	return nil
+}
+
+type hostCallKey struct{}
+
+// hostCall is the state of a single Run, shared by the host functions through the call context.
+type hostCall struct {
+	sys system.System
+	// panicked holds a panic raised by sys while wazero unwinds the wasm stack.
+	panicked interface{}
+}
+
+// hostPanic is the value used to unwind the wasm stack once the original panic is saved in hostCall.
+var hostPanic = errors.New("host function panicked")
+
+func (call *hostCall) invoke(ctx context.Context, fn api.Function) error {
+	_, err := fn.Call(ctx)
+	if call.panicked != nil {
+		panicked := call.panicked
+		call.panicked = nil
+		panic(panicked)
+	}
+	return err
+}
+
+func currentCall(ctx context.Context) *hostCall {
+	return ctx.Value(hostCallKey{}).(*hostCall)
+}
@@ -0,0 +1,152 @@
+package harness
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/sim"
+	"github.com/vlmoon99/near-sdk-go/system"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+const (
+	integrationTestsWasm = "../examples/integration_tests/main.wasm"
+	statusMessageWasm    = "../examples/near_docs_actions_pomises/status_message_go.wasm"
+)
+
+func loadContract(t *testing.T, path string) *Contract {
+	t.Helper()
+	contract, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("failed to load %s: %v", path, err)
+	}
+	t.Cleanup(func() { contract.Close() })
+	return contract
+}
+
+func deployOnChain(t *testing.T, path string) (*sim.Chain, *Contract) {
+	t.Helper()
+	contract := loadContract(t, path)
+	chain := sim.New()
+	for _, accountID := range []string{"contract.near", "alice.near"} {
+		if _, err := chain.CreateAccount(accountID, sim.NEAR(10)); err != nil {
+			t.Fatalf("failed to create %s: %v", accountID, err)
+		}
+	}
+	if err := contract.Deploy(chain, "contract.near"); err != nil {
+		t.Fatalf("failed to deploy: %v", err)
+	}
+	return chain, contract
+}
+
+func TestExports(t *testing.T) {
+	contract := loadContract(t, statusMessageWasm)
+
+	exports := strings.Join(contract.Exports(), ",")
+	for _, name := range []string{"GetStatus", "SetStatus", InitializeExport} {
+		if !strings.Contains(exports, name) {
+			t.Errorf("expected export %s in %s", name, exports)
+		}
+	}
+}
+
+func TestRunWithMockSystem(t *testing.T) {
+	contract := loadContract(t, integrationTestsWasm)
+	mock := system.NewMockSystem()
+
+	if err := contract.Run(mock, "TestStorageWrite"); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	if value := string(mock.Storage["testKey"]); value != "testValue" {
+		t.Errorf("expected testValue in storage, got %q", value)
+	}
+	if value := string(mock.Registers[0]); value != "1" {
+		t.Errorf("expected return value 1, got %q", value)
+	}
+}
+
+func TestMethodNotExported(t *testing.T) {
+	contract := loadContract(t, integrationTestsWasm)
+
+	err := contract.Run(system.NewMockSystem(), "missing")
+	if err == nil || err.Error() != ErrMethodNotExported+"missing" {
+		t.Errorf("expected %q, got %v", ErrMethodNotExported+"missing", err)
+	}
+}
+
+func TestCallWithJSONArgs(t *testing.T) {
+	chain, _ := deployOnChain(t, statusMessageWasm)
+
+	result, err := chain.Call("alice.near", "contract.near", "SetStatus", map[string]string{"message": "hello"}, types.Uint128{})
+	if err != nil || result.Failed() {
+		t.Fatalf("SetStatus failed: %v %v", err, result.Failure)
+	}
+
+	result, err = chain.View("contract.near", "GetStatus", map[string]string{"account_id": "alice.near"})
+	if err != nil || result.Failed() {
+		t.Fatalf("GetStatus failed: %v %v", err, result.Failure)
+	}
+	if string(result.Value) != "hello" {
+		t.Errorf("expected hello, got %q", result.Value)
+	}
+}
+
+func TestHostFunctions(t *testing.T) {
+	chain, _ := deployOnChain(t, integrationTestsWasm)
+
+	tests := []struct {
+		method string
+		log    string
+	}{
+		{"TestLogString", "Logged string: test log"},
+		{"TestGetCurrentAccountId", "contract.near"},
+		{"TestGetPredecessorAccountID", "alice.near"},
+		{"TestSha256Hash", sha256Hex("test data")},
+		{"TestContractInputJSON", `Contract input (JSON): {"key":"value"}`},
+		{"TestPromiseBatchActionTransfer", "Promise batch action transfer with index: 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			result, err := chain.Call("alice.near", "contract.near", tt.method, map[string]string{"key": "value"}, types.Uint128{})
+			if err != nil {
+				t.Fatalf("transaction failed: %v", err)
+			}
+			if len(result.Outcomes) == 0 || result.Outcomes[0].Failure != nil {
+				t.Fatalf("call failed: %+v", result.Outcomes)
+			}
+			if string(result.Outcomes[0].Value) != "1" {
+				t.Errorf("expected return value 1, got %q", result.Outcomes[0].Value)
+			}
+
+			logs := strings.Join(result.Outcomes[0].Logs, "\n")
+			if !strings.Contains(logs, tt.log) {
+				t.Errorf("expected log containing %q, got %q", tt.log, logs)
+			}
+		})
+	}
+}
+
+func TestPanicAbortsCall(t *testing.T) {
+	chain, _ := deployOnChain(t, integrationTestsWasm)
+
+	result, err := chain.Call("alice.near", "contract.near", "TestPanicStr", nil, sim.NEAR(1))
+	if err != nil {
+		t.Fatalf("transaction failed: %v", err)
+	}
+	if !result.Failed() || result.Failure.Error() != sim.ErrContractPanicPrefix+"Test panic" {
+		t.Errorf("expected contract panic, got %v", result.Failure)
+	}
+
+	if balance := chain.Account("alice.near").Balance; balance.Cmp(sim.NEAR(10)) != 0 {
+		t.Errorf("expected the deposit to be refunded, got %s", balance.String())
+	}
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,292 @@
+package harness
+
+import (
+	"context"
+	"errors"
+	"math"
+	"runtime"
+	"unsafe"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+
+	"github.com/vlmoon99/near-sdk-go/system"
+)
+
+// HostModule is the name of the module the contract imports the host functions from.
+const HostModule = "env"
+
+// bridge translates the pointers of a single host call from the wasm linear memory to Go memory.
+type bridge struct {
+	sys system.System
+	mem api.Memory
+	// keep holds the Go buffers handed to sys until the host function returns.
+	keep [][]byte
+}
+
+var (
+	errMemoryAccess = errors.New(ErrMemoryAccess)
+	errNoMemory     = errors.New(ErrNoMemory)
+)
+
+func goPtr(buf []byte) uint64 {
+	return uint64(uintptr(unsafe.Pointer(&buf[0])))
+}
+
+func (b *bridge) memory() api.Memory {
+	if b.mem == nil {
+		panic(errNoMemory)
+	}
+	return b.mem
+}
+
+// in copies length bytes at ptr out of the wasm memory and returns the Go pointer to the copy.
+func (b *bridge) in(ptr, length uint64) uint64 {
+	if ptr > math.MaxUint32 || length > math.MaxUint32 {
+		panic(errMemoryAccess)
+	}
+	data, ok := b.memory().Read(uint32(ptr), uint32(length))
+	if !ok {
+		panic(errMemoryAccess)
+	}
+
+	// One extra byte keeps the pointer valid for empty values.
+	buf := make([]byte, length+1)
+	copy(buf, data)
+	b.keep = append(b.keep, buf)
+	return goPtr(buf)
+}
+
+// out passes a Go buffer of length bytes to fill and copies the result to ptr in the wasm memory.
+func (b *bridge) out(ptr, length uint64, fill func(ptr uint64)) {
+	if ptr > math.MaxUint32 {
+		panic(errMemoryAccess)
+	}
+	buf := make([]byte, length+1)
+	fill(goPtr(buf))
+	if !b.memory().Write(uint32(ptr), buf[:length]) {
+		panic(errMemoryAccess)
+	}
+}
+
+type hostImport struct {
+	name    string
+	params  int
+	results []api.ValueType
+	fn      func(b *bridge, stack []uint64)
+}
+
+var (
+	none = []api.ValueType{}
+	i64  = []api.ValueType{api.ValueTypeI64}
+	i32  = []api.ValueType{api.ValueTypeI32}
+)
+
+// imports mirrors the //go:wasmimport declarations of system/system_near.go.
+var imports = []hostImport{
+	// Registers API
+	{"read_register", 2, none, func(b *bridge, s []uint64) {
+		length := b.sys.RegisterLen(s[0])
+		if length == 0 || length == math.MaxUint64 {
+			return
+		}
+		b.out(s[1], length, func(ptr uint64) { b.sys.ReadRegister(s[0], ptr) })
+	}},
+	{"register_len", 1, i64, func(b *bridge, s []uint64) {
+		s[0] = b.sys.RegisterLen(s[0])
+	}},
+	{"write_register", 3, none, func(b *bridge, s []uint64) {
+		b.sys.WriteRegister(s[0], s[1], b.in(s[2], s[1]))
+	}},
+
+	// Storage API
+	{"storage_write", 5, i64, func(b *bridge, s []uint64) {
+		s[0] = b.sys.StorageWrite(s[0], b.in(s[1], s[0]), s[2], b.in(s[3], s[2]), s[4])
+	}},
+	{"storage_read", 3, i64, func(b *bridge, s []uint64) {
+		s[0] = b.sys.StorageRead(s[0], b.in(s[1], s[0]), s[2])
+	}},
+	{"storage_remove", 3, i64, func(b *bridge, s []uint64) {
+		s[0] = b.sys.StorageRemove(s[0], b.in(s[1], s[0]), s[2])
+	}},
+	{"storage_has_key", 2, i64, func(b *bridge, s []uint64) {
+		s[0] = b.sys.StorageHasKey(s[0], b.in(s[1], s[0]))
+	}},
+
+	// Context API
+	{"current_account_id", 1, none, func(b *bridge, s []uint64) { b.sys.CurrentAccountId(s[0]) }},
+	{"signer_account_id", 1, none, func(b *bridge, s []uint64) { b.sys.SignerAccountId(s[0]) }},
+	{"signer_account_pk", 1, none, func(b *bridge, s []uint64) { b.sys.SignerAccountPk(s[0]) }},
+	{"predecessor_account_id", 1, none, func(b *bridge, s []uint64) { b.sys.PredecessorAccountId(s[0]) }},
+	{"input", 1, none, func(b *bridge, s []uint64) { b.sys.Input(s[0]) }},
+	{"block_index", 0, i64, func(b *bridge, s []uint64) { s[0] = b.sys.BlockIndex() }},
+	{"block_timestamp", 0, i64, func(b *bridge, s []uint64) { s[0] = b.sys.BlockTimestamp() }},
+	{"epoch_height", 0, i64, func(b *bridge, s []uint64) { s[0] = b.sys.EpochHeight() }},
+	{"storage_usage", 0, i64, func(b *bridge, s []uint64) { s[0] = b.sys.StorageUsage() }},
+
+	// Economics API
+	{"account_balance", 1, none, func(b *bridge, s []uint64) {
+		b.out(s[0], 16, b.sys.AccountBalance)
+	}},
+	{"account_locked_balance", 1, none, func(b *bridge, s []uint64) {
+		b.out(s[0], 16, b.sys.AccountLockedBalance)
+	}},
+	{"attached_deposit", 1, none, func(b *bridge, s []uint64) {
+		b.out(s[0], 16, b.sys.AttachedDeposit)
+	}},
+	{"prepaid_gas", 0, i64, func(b *bridge, s []uint64) { s[0] = b.sys.PrepaidGas() }},
+	{"used_gas", 0, i64, func(b *bridge, s []uint64) { s[0] = b.sys.UsedGas() }},
+
+	// Math API
+	{"random_seed", 1, none, func(b *bridge, s []uint64) { b.sys.RandomSeed(s[0]) }},
+	{"sha256", 3, none, func(b *bridge, s []uint64) {
+		b.sys.Sha256(s[0], b.in(s[1], s[0]), s[2])
+	}},
+	{"keccak256", 3, none, func(b *bridge, s []uint64) {
+		b.sys.Keccak256(s[0], b.in(s[1], s[0]), s[2])
+	}},
+	{"keccak512", 3, none, func(b *bridge, s []uint64) {
+		b.sys.Keccak512(s[0], b.in(s[1], s[0]), s[2])
+	}},
+	{"ripemd160", 3, none, func(b *bridge, s []uint64) {
+		b.sys.Ripemd160(s[0], b.in(s[1], s[0]), s[2])
+	}},
+	{"ecrecover", 7, i64, func(b *bridge, s []uint64) {
+		s[0] = b.sys.Ecrecover(s[0], b.in(s[1], s[0]), s[2], b.in(s[3], s[2]), s[4], s[5], s[6])
+	}},
+	{"ed25519_verify", 6, i64, func(b *bridge, s []uint64) {
+		s[0] = b.sys.Ed25519Verify(s[0], b.in(s[1], s[0]), s[2], b.in(s[3], s[2]), s[4], b.in(s[5], s[4]))
+	}},
+	{"alt_bn128_g1_multiexp", 3, none, func(b *bridge, s []uint64) {
+		b.sys.AltBn128G1Multiexp(s[0], b.in(s[1], s[0]), s[2])
+	}},
+	{"alt_bn128_g1_sum", 3, none, func(b *bridge, s []uint64) {
+		b.sys.AltBn128G1SumSystem(s[0], b.in(s[1], s[0]), s[2])
+	}},
+	{"alt_bn128_pairing_check", 2, i64, func(b *bridge, s []uint64) {
+		s[0] = b.sys.AltBn128PairingCheckSystem(s[0], b.in(s[1], s[0]))
+	}},
+
+	// Validator API
+	{"validator_stake", 3, none, func(b *bridge, s []uint64) {
+		accountID := b.in(s[1], s[0])
+		b.out(s[2], 16, func(ptr uint64) { b.sys.ValidatorStake(s[0], accountID, ptr) })
+	}},
+	{"validator_total_stake", 1, none, func(b *bridge, s []uint64) {
+		b.out(s[0], 16, b.sys.ValidatorTotalStake)
+	}},
+
+	// Miscellaneous API
+	{"value_return", 2, none, func(b *bridge, s []uint64) {
+		b.sys.ValueReturn(s[0], b.in(s[1], s[0]))
+	}},
+	{"panic_utf8", 2, none, func(b *bridge, s []uint64) {
+		b.sys.PanicUtf8(s[0], b.in(s[1], s[0]))
+	}},
+	{"log_utf8", 2, none, func(b *bridge, s []uint64) {
+		b.sys.LogUtf8(s[0], b.in(s[1], s[0]))
+	}},
+	{"log_utf16", 2, none, func(b *bridge, s []uint64) {
+		b.sys.LogUtf16(s[0], b.in(s[1], s[0]))
+	}},
+
+	// Promises API
+	{"promise_create", 8, i64, func(b *bridge, s []uint64) {
+		s[0] = b.sys.PromiseCreate(s[0], b.in(s[1], s[0]), s[2], b.in(s[3], s[2]), s[4], b.in(s[5], s[4]), b.in(s[6], 16), s[7])
+	}},
+	{"promise_then", 9, i64, func(b *bridge, s []uint64) {
+		s[0] = b.sys.PromiseThen(s[0], s[1], b.in(s[2], s[1]), s[3], b.in(s[4], s[3]), s[5], b.in(s[6], s[5]), b.in(s[7], 16), s[8])
+	}},
+	{"promise_and", 2, i64, func(b *bridge, s []uint64) {
+		s[0] = b.sys.PromiseAnd(b.in(s[0], s[1]*8), s[1])
+	}},
+	{"promise_batch_create", 2, i64, func(b *bridge, s []uint64) {
+		s[0] = b.sys.PromiseBatchCreate(s[0], b.in(s[1], s[0]))
+	}},
+	{"promise_batch_then", 3, i64, func(b *bridge, s []uint64) {
+		s[0] = b.sys.PromiseBatchThen(s[0], s[1], b.in(s[2], s[1]))
+	}},
+
+	// Promise API Actions
+	{"promise_batch_action_create_account", 1, none, func(b *bridge, s []uint64) {
+		b.sys.PromiseBatchActionCreateAccount(s[0])
+	}},
+	{"promise_batch_action_deploy_contract", 3, none, func(b *bridge, s []uint64) {
+		b.sys.PromiseBatchActionDeployContract(s[0], s[1], b.in(s[2], s[1]))
+	}},
+	{"promise_batch_action_function_call", 7, none, func(b *bridge, s []uint64) {
+		b.sys.PromiseBatchActionFunctionCall(s[0], s[1], b.in(s[2], s[1]), s[3], b.in(s[4], s[3]), b.in(s[5], 16), s[6])
+	}},
+	{"promise_batch_action_function_call_weight", 8, none, func(b *bridge, s []uint64) {
+		b.sys.PromiseBatchActionFunctionCallWeight(s[0], s[1], b.in(s[2], s[1]), s[3], b.in(s[4], s[3]), b.in(s[5], 16), s[6], s[7])
+	}},
+	{"promise_batch_action_transfer", 2, none, func(b *bridge, s []uint64) {
+		b.sys.PromiseBatchActionTransfer(s[0], b.in(s[1], 16))
+	}},
+	{"promise_batch_action_stake", 4, none, func(b *bridge, s []uint64) {
+		b.sys.PromiseBatchActionStake(s[0], b.in(s[1], 16), s[2], b.in(s[3], s[2]))
+	}},
+	{"promise_batch_action_add_key_with_full_access", 4, none, func(b *bridge, s []uint64) {
+		b.sys.PromiseBatchActionAddKeyWithFullAccess(s[0], s[1], b.in(s[2], s[1]), s[3])
+	}},
+	{"promise_batch_action_add_key_with_function_call", 9, none, func(b *bridge, s []uint64) {
+		b.sys.PromiseBatchActionAddKeyWithFunctionCall(s[0], s[1], b.in(s[2], s[1]), s[3], b.in(s[4], 16), s[5], b.in(s[6], s[5]), s[7], b.in(s[8], s[7]))
+	}},
+	{"promise_batch_action_delete_key", 3, none, func(b *bridge, s []uint64) {
+		b.sys.PromiseBatchActionDeleteKey(s[0], s[1], b.in(s[2], s[1]))
+	}},
+	{"promise_batch_action_delete_account", 3, none, func(b *bridge, s []uint64) {
+		b.sys.PromiseBatchActionDeleteAccount(s[0], s[1], b.in(s[2], s[1]))
+	}},
+
+	// Promise API Yield/Resume
+	{"promise_yield_create", 7, i64, func(b *bridge, s []uint64) {
+		s[0] = b.sys.PromiseYieldCreate(s[0], b.in(s[1], s[0]), s[2], b.in(s[3], s[2]), s[4], s[5], s[6])
+	}},
+	{"promise_yield_resume", 4, i32, func(b *bridge, s []uint64) {
+		s[0] = uint64(b.sys.PromiseYieldResume(s[0], b.in(s[1], s[0]), s[2], b.in(s[3], s[2])))
+	}},
+
+	// Promise API Results
+	{"promise_results_count", 0, i64, func(b *bridge, s []uint64) { s[0] = b.sys.PromiseResultsCount() }},
+	{"promise_result", 2, i64, func(b *bridge, s []uint64) {
+		s[0] = b.sys.PromiseResult(s[0], s[1])
+	}},
+	{"promise_return", 1, none, func(b *bridge, s []uint64) { b.sys.PromiseReturn(s[0]) }},
+}
+
+// instantiateHost registers the host module exporting every NEAR import in the runtime.
+func instantiateHost(ctx context.Context, r wazero.Runtime) error {
+	builder := r.NewHostModuleBuilder(HostModule)
+	for _, imp := range imports {
+		params := make([]api.ValueType, imp.params)
+		for i := range params {
+			params[i] = api.ValueTypeI64
+		}
+		builder.NewFunctionBuilder().
+			WithGoModuleFunction(hostFunction(imp.fn), params, imp.results).
+			Export(imp.name)
+	}
+	_, err := builder.Instantiate(ctx)
+	return err
+}
+
+// hostFunction adapts fn to wazero. A panic raised by sys is saved in the hostCall and re-raised by Run
+// once wazero has unwound the wasm stack; memory access errors trap the module instead.
+func hostFunction(fn func(b *bridge, stack []uint64)) api.GoModuleFunc {
+	return func(ctx context.Context, mod api.Module, stack []uint64) {
+		call := currentCall(ctx)
+		b := &bridge{sys: call.sys, mem: mod.Memory()}
+		defer func() {
+			runtime.KeepAlive(b.keep)
+			if r := recover(); r != nil {
+				if r == errMemoryAccess || r == errNoMemory {
+					panic(r)
+				}
+				call.panicked = r
+				panic(hostPanic)
+			}
+		}()
+		fn(b, stack)
+	}
+}
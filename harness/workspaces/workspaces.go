@@ -0,0 +1,362 @@
+// Package workspaces is a Go counterpart of near-workspaces for testing compiled contracts.
+//
+// A Worker wraps an in-process sim.Chain where every deployed `.wasm` runs inside the harness. It offers account
+// creation, deployment, calls, views, state inspection and patching, and time travel, without a sandbox node:
+//
+//	worker := workspaces.Sandbox()
+//	defer worker.Close()
+//
+//	contract, _ := worker.DevDeploy(wasm)
+//	alice, _ := worker.DevCreateAccount()
+//	result, _ := alice.Call(contract.ID(), "set_status").Args(map[string]string{"message": "hi"}).Transact()
+package workspaces
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/vlmoon99/near-sdk-go/harness"
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/sim"
+	"github.com/vlmoon99/near-sdk-go/system"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+const (
+	// RootAccountID is the account that funds the accounts created by the worker.
+	RootAccountID = "test.near"
+
+	// DefaultGas is the gas attached to calls unless Gas is set.
+	DefaultGas = 300 * types.ONE_TERA_GAS
+)
+
+const (
+	ErrAccountNotFound = "(WORKSPACES_ERROR): account not found: "
+	ErrEmptyState      = "(WORKSPACES_ERROR): state key can't be empty"
+)
+
+var (
+	rootBalance = sim.NEAR(1_000_000_000)
+
+	// DevAccountBalance is the balance of the accounts created by DevCreateAccount and DevDeploy.
+	DevAccountBalance = sim.NEAR(100)
+)
+
+// Worker is an in-process NEAR network.
+type Worker struct {
+	chain     *sim.Chain
+	contracts map[string]*harness.Contract
+	devCount  int
+}
+
+// Sandbox starts a new in-process network with a funded root account.
+func Sandbox() *Worker {
+	w := &Worker{
+		chain:     sim.New(),
+		contracts: make(map[string]*harness.Contract),
+	}
+	w.chain.CodeLoader = func(code []byte) (sim.Contract, error) {
+		return w.load(code)
+	}
+
+	if _, err := w.chain.CreateAccount(RootAccountID, rootBalance); err != nil {
+		panic(err)
+	}
+	w.addKey(RootAccountID)
+	return w
+}
+
+// Close releases the wasm runtimes of every contract deployed on the worker.
+func (w *Worker) Close() error {
+	var errs []error
+	for _, contract := range w.contracts {
+		errs = append(errs, contract.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Chain returns the simulated chain backing the worker.
+func (w *Worker) Chain() *sim.Chain {
+	return w.chain
+}
+
+// RootAccount returns the root account of the network.
+func (w *Worker) RootAccount() *Account {
+	return &Account{worker: w, id: RootAccountID}
+}
+
+// Account returns a handle for an existing account.
+func (w *Worker) Account(accountID string) (*Account, error) {
+	if w.chain.Account(accountID) == nil {
+		return nil, errors.New(ErrAccountNotFound + accountID)
+	}
+	return &Account{worker: w, id: accountID}, nil
+}
+
+// DevCreateAccount creates a new top-level account with DevAccountBalance.
+func (w *Worker) DevCreateAccount() (*Account, error) {
+	w.devCount++
+	accountID := fmt.Sprintf("dev-%d-%d.%s", w.chain.Height, w.devCount, RootAccountID)
+	return w.RootAccount().createAccount(accountID, DevAccountBalance)
+}
+
+// DevDeploy creates a new dev account and deploys code on it.
+func (w *Worker) DevDeploy(code []byte) (*Contract, error) {
+	account, err := w.DevCreateAccount()
+	if err != nil {
+		return nil, err
+	}
+	return account.Deploy(code)
+}
+
+// View calls a view method of the contract deployed on accountID.
+func (w *Worker) View(accountID, method string, args interface{}) (*sim.Result, error) {
+	return w.chain.View(accountID, method, args)
+}
+
+// ViewAccount returns the balances, storage usage and code hash of the account.
+func (w *Worker) ViewAccount(accountID string) (*AccountDetails, error) {
+	account := w.chain.Account(accountID)
+	if account == nil {
+		return nil, errors.New(ErrAccountNotFound + accountID)
+	}
+
+	details := &AccountDetails{Balance: account.Balance, Locked: account.Locked, StorageUsage: account.StorageUsage}
+	if len(account.Code) > 0 {
+		details.CodeHash = sha256.Sum256(account.Code)
+	}
+	return details, nil
+}
+
+// ViewState returns a copy of the contract storage of the account.
+func (w *Worker) ViewState(accountID string) (map[string][]byte, error) {
+	account := w.chain.Account(accountID)
+	if account == nil {
+		return nil, errors.New(ErrAccountNotFound + accountID)
+	}
+
+	state := make(map[string][]byte, len(account.Storage))
+	for key, value := range account.Storage {
+		state[key] = append([]byte{}, value...)
+	}
+	return state, nil
+}
+
+// PatchState writes value under key in the account storage directly, bypassing the contract.
+// The storage usage is adjusted the same way a storage_write would.
+func (w *Worker) PatchState(accountID string, key, value []byte) error {
+	account := w.chain.Account(accountID)
+	if account == nil {
+		return errors.New(ErrAccountNotFound + accountID)
+	}
+	if len(key) == 0 {
+		return errors.New(ErrEmptyState)
+	}
+
+	if old, exists := account.Storage[string(key)]; exists {
+		account.StorageUsage -= uint64(len(old))
+		account.StorageUsage += uint64(len(value))
+	} else {
+		account.StorageUsage += uint64(len(key)+len(value)) + system.StorageNumExtraBytesRecord
+	}
+	account.Storage[string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+// FastForward produces the given number of blocks.
+func (w *Worker) FastForward(blocks uint64) {
+	w.chain.FastForward(blocks)
+}
+
+// BlockHeight returns the height of the last produced block.
+func (w *Worker) BlockHeight() uint64 {
+	return w.chain.Height
+}
+
+// BlockTimestamp returns the timestamp of the last produced block in nanoseconds.
+func (w *Worker) BlockTimestamp() uint64 {
+	return w.chain.Timestamp
+}
+
+// load compiles code once per worker, so identical binaries share a wasm runtime.
+func (w *Worker) load(code []byte) (*harness.Contract, error) {
+	hash := sha256.Sum256(code)
+	key := string(hash[:])
+	if contract, exists := w.contracts[key]; exists {
+		return contract, nil
+	}
+
+	contract, err := harness.Load(code)
+	if err != nil {
+		return nil, err
+	}
+	w.contracts[key] = contract
+	return contract, nil
+}
+
+// addKey gives the account a deterministic ed25519 full access key.
+func (w *Worker) addKey(accountID string) {
+	publicKey := KeyPair(accountID).Public().(ed25519.PublicKey)
+	data := append([]byte{byte(types.ED25519)}, publicKey...)
+	w.chain.Account(accountID).Keys[string(data)] = sim.AccessKey{PublicKey: data, FullAccess: true}
+}
+
+// KeyPair returns the ed25519 key the worker adds to accountID when it creates the account.
+func KeyPair(accountID string) ed25519.PrivateKey {
+	seed := sha256.Sum256([]byte(accountID))
+	return ed25519.NewKeyFromSeed(seed[:])
+}
+
+// AccountDetails describes the on-chain state of an account.
+type AccountDetails struct {
+	Balance      types.Uint128
+	Locked       types.Uint128
+	StorageUsage uint64
+	// CodeHash is the sha256 hash of the deployed code, zero when no code is deployed.
+	CodeHash [32]byte
+}
+
+// Account is an account that signs transactions on the worker.
+type Account struct {
+	worker *Worker
+	id     string
+}
+
+// ID returns the account ID.
+func (a *Account) ID() string {
+	return a.id
+}
+
+// SecretKey returns the full access key of the account.
+func (a *Account) SecretKey() ed25519.PrivateKey {
+	return KeyPair(a.id)
+}
+
+// PublicKey returns the public part of the full access key of the account.
+func (a *Account) PublicKey() *types.PublicKey {
+	publicKey := a.SecretKey().Public().(ed25519.PublicKey)
+	return &types.PublicKey{Curve: types.ED25519, Data: publicKey}
+}
+
+// Balance returns the current balance of the account.
+func (a *Account) Balance() types.Uint128 {
+	if account := a.worker.chain.Account(a.id); account != nil {
+		return account.Balance
+	}
+	return types.Uint128{Hi: 0, Lo: 0}
+}
+
+// CreateSubaccount creates `name.<account ID>` funded by this account.
+func (a *Account) CreateSubaccount(name string, balance types.Uint128) (*Account, error) {
+	return a.createAccount(name+"."+a.id, balance)
+}
+
+func (a *Account) createAccount(accountID string, balance types.Uint128) (*Account, error) {
+	result, err := a.worker.chain.Transact(a.id, accountID, []sim.Action{
+		{Kind: promise.CreateAccountAction},
+		{Kind: promise.TransferAction, Deposit: balance},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Failed() {
+		return nil, result.Failure
+	}
+
+	a.worker.addKey(accountID)
+	return &Account{worker: a.worker, id: accountID}, nil
+}
+
+// Deploy deploys code on the account through a DeployContract transaction.
+func (a *Account) Deploy(code []byte) (*Contract, error) {
+	contract, err := a.worker.load(code)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := a.worker.chain.Transact(a.id, a.id, []sim.Action{{Kind: promise.DeployContractAction, Code: code}})
+	if err != nil {
+		return nil, err
+	}
+	if result.Failed() {
+		return nil, result.Failure
+	}
+	return &Contract{Account: a, code: contract}, nil
+}
+
+// Transfer sends amount yoctoNEAR to the receiver.
+func (a *Account) Transfer(receiverID string, amount types.Uint128) (*sim.Result, error) {
+	return a.worker.chain.Transact(a.id, receiverID, []sim.Action{{Kind: promise.TransferAction, Deposit: amount}})
+}
+
+// Call prepares a function call transaction signed by this account.
+func (a *Account) Call(contractID, method string) *CallTransaction {
+	return &CallTransaction{worker: a.worker, signerID: a.id, receiverID: contractID, method: method, gas: DefaultGas}
+}
+
+// View calls a view method of the contract deployed on contractID.
+func (a *Account) View(contractID, method string, args interface{}) (*sim.Result, error) {
+	return a.worker.View(contractID, method, args)
+}
+
+// ViewState returns a copy of the account storage.
+func (a *Account) ViewState() (map[string][]byte, error) {
+	return a.worker.ViewState(a.id)
+}
+
+// Contract is an account with deployed code.
+type Contract struct {
+	*Account
+	code *harness.Contract
+}
+
+// Exports returns the methods exported by the deployed code.
+func (c *Contract) Exports() []string {
+	return c.code.Exports()
+}
+
+// Call prepares a function call transaction signed by the contract account itself.
+func (c *Contract) Call(method string) *CallTransaction {
+	return c.Account.Call(c.id, method)
+}
+
+// View calls a view method of the contract.
+func (c *Contract) View(method string, args interface{}) (*sim.Result, error) {
+	return c.worker.View(c.id, method, args)
+}
+
+// CallTransaction is a function call transaction under construction.
+type CallTransaction struct {
+	worker     *Worker
+	signerID   string
+	receiverID string
+	method     string
+	args       interface{}
+	deposit    types.Uint128
+	gas        uint64
+}
+
+// Args sets the call arguments. A []byte is sent as is, anything else is encoded to JSON.
+func (tx *CallTransaction) Args(args interface{}) *CallTransaction {
+	tx.args = args
+	return tx
+}
+
+// Deposit sets the attached deposit in yoctoNEAR.
+func (tx *CallTransaction) Deposit(amount types.Uint128) *CallTransaction {
+	tx.deposit = amount
+	return tx
+}
+
+// Gas sets the prepaid gas.
+func (tx *CallTransaction) Gas(gas uint64) *CallTransaction {
+	tx.gas = gas
+	return tx
+}
+
+// Transact sends the transaction and waits until every receipt it spawned is executed.
+func (tx *CallTransaction) Transact() (*sim.Result, error) {
+	return tx.worker.chain.CallWithGas(tx.signerID, tx.receiverID, tx.method, tx.args, tx.deposit, tx.gas)
+}
@@ -0,0 +1,159 @@
+package workspaces
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/sim"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+const (
+	statusMessageWasm = "../../examples/near_docs_actions_pomises/status_message_go.wasm"
+	actionsWasm       = "../../examples/near_docs_actions_pomises/main.wasm"
+)
+
+func readWasm(t *testing.T, path string) []byte {
+	t.Helper()
+	code, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	return code
+}
+
+func sandbox(t *testing.T) *Worker {
+	t.Helper()
+	worker := Sandbox()
+	t.Cleanup(func() { worker.Close() })
+	return worker
+}
+
+func TestDevDeployCallAndView(t *testing.T) {
+	worker := sandbox(t)
+
+	contract, err := worker.DevDeploy(readWasm(t, statusMessageWasm))
+	if err != nil {
+		t.Fatalf("failed to deploy: %v", err)
+	}
+	alice, err := worker.DevCreateAccount()
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+
+	result, err := alice.Call(contract.ID(), "SetStatus").Args(map[string]string{"message": "hello"}).Transact()
+	if err != nil || result.Failed() {
+		t.Fatalf("SetStatus failed: %v %v", err, result.Failure)
+	}
+
+	result, err = contract.View("GetStatus", map[string]string{"account_id": alice.ID()})
+	if err != nil || result.Failed() {
+		t.Fatalf("GetStatus failed: %v %v", err, result.Failure)
+	}
+	if string(result.Value) != "hello" {
+		t.Errorf("expected hello, got %q", result.Value)
+	}
+
+	state, err := contract.ViewState()
+	if err != nil {
+		t.Fatalf("failed to view state: %v", err)
+	}
+	if value, exists := state["b"+alice.ID()]; !exists || !strings.HasSuffix(string(value), "hello") {
+		t.Errorf("expected the status in the contract state, got %q", state)
+	}
+}
+
+func TestAccountsAndTransfers(t *testing.T) {
+	worker := sandbox(t)
+	root := worker.RootAccount()
+
+	alice, err := root.CreateSubaccount("alice", sim.NEAR(10))
+	if err != nil {
+		t.Fatalf("failed to create subaccount: %v", err)
+	}
+	if alice.ID() != "alice."+RootAccountID {
+		t.Errorf("unexpected subaccount ID %s", alice.ID())
+	}
+
+	if _, err := root.CreateSubaccount("alice", sim.NEAR(10)); err == nil {
+		t.Errorf("expected duplicate account creation to fail")
+	}
+
+	result, err := alice.Transfer(root.ID(), sim.NEAR(4))
+	if err != nil || result.Failed() {
+		t.Fatalf("transfer failed: %v %v", err, result.Failure)
+	}
+	if balance := alice.Balance(); balance.Cmp(sim.NEAR(6)) != 0 {
+		t.Errorf("expected 6 NEAR, got %s", balance.String())
+	}
+
+	details, err := worker.ViewAccount(alice.ID())
+	if err != nil {
+		t.Fatalf("failed to view account: %v", err)
+	}
+	if details.CodeHash != [32]byte{} {
+		t.Errorf("expected no code on a fresh account")
+	}
+	if alice.PublicKey().Curve != types.ED25519 || len(alice.PublicKey().Data) != 32 {
+		t.Errorf("expected an ed25519 key, got %+v", alice.PublicKey())
+	}
+}
+
+func TestContractDeploysContract(t *testing.T) {
+	worker := sandbox(t)
+
+	contract, err := worker.DevDeploy(readWasm(t, actionsWasm))
+	if err != nil {
+		t.Fatalf("failed to deploy: %v", err)
+	}
+
+	result, err := contract.Call("example_deploy_contract").Args(map[string]string{"prefix": "status"}).Deposit(sim.NEAR(2)).Transact()
+	if err != nil || result.Failed() {
+		t.Fatalf("example_deploy_contract failed: %v %v", err, result.Failure)
+	}
+	if failures := result.ReceiptFailures(); len(failures) != 0 {
+		t.Fatalf("unexpected receipt failures: %v", failures)
+	}
+
+	statusID := "status." + contract.ID()
+	result, err = worker.RootAccount().Call(statusID, "SetStatus").Args(map[string]string{"message": "deployed"}).Transact()
+	if err != nil || result.Failed() {
+		t.Fatalf("SetStatus on the deployed contract failed: %v %v", err, result.Failure)
+	}
+
+	result, err = worker.View(statusID, "GetStatus", map[string]string{"account_id": RootAccountID})
+	if err != nil || string(result.Value) != "deployed" {
+		t.Errorf("expected deployed, got %q (%v)", result.Value, err)
+	}
+}
+
+func TestPatchStateAndTimeTravel(t *testing.T) {
+	worker := sandbox(t)
+
+	contract, err := worker.DevDeploy(readWasm(t, statusMessageWasm))
+	if err != nil {
+		t.Fatalf("failed to deploy: %v", err)
+	}
+
+	if err := worker.PatchState(contract.ID(), []byte("bbob.near"), []byte("\x07\x00\x00\x00patched")); err != nil {
+		t.Fatalf("failed to patch state: %v", err)
+	}
+	result, err := contract.View("GetStatus", map[string]string{"account_id": "bob.near"})
+	if err != nil || string(result.Value) != "patched" {
+		t.Errorf("expected patched, got %q (%v)", result.Value, err)
+	}
+
+	height, timestamp := worker.BlockHeight(), worker.BlockTimestamp()
+	worker.FastForward(100)
+	if worker.BlockHeight() != height+100 {
+		t.Errorf("expected height %d, got %d", height+100, worker.BlockHeight())
+	}
+	if worker.BlockTimestamp() != timestamp+100*sim.DefaultBlockTime {
+		t.Errorf("expected timestamp %d, got %d", timestamp+100*sim.DefaultBlockTime, worker.BlockTimestamp())
+	}
+
+	if err := worker.PatchState("missing.near", []byte("k"), []byte("v")); err == nil {
+		t.Errorf("expected patching a missing account to fail")
+	}
+}
@@ -0,0 +1,19 @@
+module github.com/vlmoon99/near-sdk-go/integration_tests
+
+go 1.25.4
+
+replace (
+	github.com/vlmoon99/near-sdk-go => ../
+	github.com/vlmoon99/near-sdk-go/harness => ../harness
+)
+
+require (
+	github.com/vlmoon99/near-sdk-go v0.0.0
+	github.com/vlmoon99/near-sdk-go/harness v0.0.0
+)
+
+require (
+	github.com/mr-tron/base58 v1.2.0 // indirect
+	github.com/tetratelabs/wazero v1.9.0 // indirect
+	github.com/vlmoon99/jsonparser v0.0.1 // indirect
+)
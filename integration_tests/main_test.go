@@ -0,0 +1,179 @@
+// Package integration_tests runs the exported test functions of examples/integration_tests/main.wasm against an
+// in-process workspaces sandbox. It is the Go port of the near-workspaces scenarios in src/main.rs.
+package integration_tests
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/harness/workspaces"
+	"github.com/vlmoon99/near-sdk-go/sim"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+const wasmFilePath = "../examples/integration_tests/main.wasm"
+
+const standardGas = 300 * types.ONE_TERA_GAS
+
+var standardDeposit = sim.NEAR(3)
+
+// unsupported lists the host functions the in-process runtime can't compute, with the reason the scenario is skipped.
+var unsupported = map[string]string{
+	"TestEcrecoverPubKey":               "ecrecover is not implemented by the simulated runtime",
+	"TestAltBn128G1MultiExp":            "alt_bn128 precompiles are not implemented by the simulated runtime",
+	"TestAltBn128G1Sum":                 "alt_bn128 precompiles are not implemented by the simulated runtime",
+	"TestPromiseYieldCreateYieldResume": "yield/resume is not supported by the simulated runtime",
+}
+
+var successScenarios = []string{
+	// Registers API
+	"TestWriteReadRegisterSafe",
+	// Storage API
+	"TestStorageWrite",
+	"TestStorageRead",
+	"TestStorageHasKey",
+	"TestStorageRemove",
+	"TestStateWrite",
+	"TestStateRead",
+	"TestStateExists",
+	"TestStorageGetEvicted",
+	// Context API
+	"TestGetCurrentAccountId",
+	"TestGetSignerAccountID",
+	"TestGetSignerAccountPK",
+	"TestGetPredecessorAccountID",
+	"TestGetCurrentBlockHeight",
+	"TestGetBlockTimeMs",
+	"TestGetEpochHeight",
+	"TestGetStorageUsage",
+	"TestContractInputRawBytes",
+	"TestContractInputJSON",
+	// Economics API
+	"TestGetAccountBalance",
+	"TestGetAccountLockedBalance",
+	"TestGetAttachedDeposit",
+	"TestGetPrepaidGas",
+	"TestGetUsedGas",
+	// Math API
+	"TestGetRandomSeed",
+	"TestSha256Hash",
+	"TestKeccak256Hash",
+	"TestKeccak512Hash",
+	"TestRipemd160Hash",
+	"TestEcrecoverPubKey",
+	"TestEd25519VerifySig",
+	"TestAltBn128G1MultiExp",
+	"TestAltBn128G1Sum",
+	"TestAltBn128PairingCheck",
+	// Validator API
+	"TestValidatorStakeAmount",
+	"TestValidatorTotalStakeAmount",
+	// Miscellaneous API
+	"TestContractValueReturn",
+	"TestLogString",
+	"TestLogStringUtf8",
+	"TestLogStringUtf16",
+	// Promises API
+	"TestPromiseCreate",
+	"TestPromiseThen",
+	"TestPromiseAnd",
+	"TestPromiseBatchCreate",
+	"TestPromiseBatchThen",
+	// Promise API Actions
+	"TestPromiseBatchActionCreateAccount",
+	"TestPromiseBatchActionDeployContract",
+	"TestPromiseBatchActionFunctionCall",
+	"TestPromiseBatchActionFunctionCallWeight",
+	"TestPromiseBatchActionTransfer",
+	"TestPromiseBatchActionStake",
+	"TestPromiseBatchActionAddKeyWithFullAccess",
+	"TestPromiseBatchActionAddKeyWithFunctionCall",
+	"TestPromiseBatchActionDeleteKey",
+	"TestPromiseBatchActionDeleteAccount",
+	// Promise API Yield
+	"TestPromiseYieldCreateYieldResume",
+	// Promise API Results
+	"TestPromiseResultsCount",
+	"TestPromiseResult",
+	"TestPromiseReturn",
+}
+
+var errorScenarios = []string{
+	"TestPanicStr",
+	"TestAbortExecution",
+}
+
+func deployContract(t *testing.T) *workspaces.Contract {
+	t.Helper()
+	wasm, err := os.ReadFile(wasmFilePath)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", wasmFilePath, err)
+	}
+
+	worker := workspaces.Sandbox()
+	t.Cleanup(func() { worker.Close() })
+
+	contract, err := worker.DevDeploy(wasm)
+	if err != nil {
+		t.Fatalf("failed to deploy: %v", err)
+	}
+	return contract
+}
+
+func callIntegrationTestFunction(t *testing.T, contract *workspaces.Contract, functionName string, args interface{}) *sim.Result {
+	t.Helper()
+	result, err := contract.Call(functionName).Args(args).Deposit(standardDeposit).Gas(standardGas).Transact()
+	if err != nil {
+		t.Fatalf("%s: transaction failed: %v", functionName, err)
+	}
+	return result
+}
+
+func TestIntegration(t *testing.T) {
+	contract := deployContract(t)
+
+	result := callIntegrationTestFunction(t, contract, "InitContract", map[string]string{"testInputKey": "testInputValue"})
+	if result.Failed() || string(result.Value) != "1" {
+		t.Fatalf("InitContract failed: %v %q", result.Failure, result.Value)
+	}
+
+	// The scenarios share the contract, like the sequential calls of the Rust version.
+	for _, functionName := range successScenarios {
+		t.Run(functionName, func(t *testing.T) {
+			if reason, skip := unsupported[functionName]; skip {
+				t.Skip(reason)
+			}
+
+			result := callIntegrationTestFunction(t, contract, functionName, map[string]string{})
+			if result.Failed() {
+				t.Fatalf("test failed with error: %v", result.Failure)
+			}
+			if string(result.Value) != "1" {
+				t.Errorf("expected result 1, got %q", result.Value)
+			}
+			t.Logf("logs: %q", result.Logs())
+		})
+	}
+
+	for _, functionName := range errorScenarios {
+		t.Run(functionName, func(t *testing.T) {
+			result := callIntegrationTestFunction(t, contract, functionName, map[string]string{})
+			if !result.Failed() || !strings.HasPrefix(result.Failure.Error(), sim.ErrContractPanicPrefix) {
+				t.Errorf("expected the contract to panic, got %v", result.Failure)
+			}
+		})
+	}
+}
+
+func TestEd25519VerifySig(t *testing.T) {
+	contract := deployContract(t)
+
+	result := callIntegrationTestFunction(t, contract, "TestEd25519VerifySig", map[string]string{})
+	if result.Failed() {
+		t.Fatalf("test failed with error: %v", result.Failure)
+	}
+	if logs := strings.Join(result.Logs(), "\n"); !strings.Contains(logs, "Ed25519 signature valid: true") {
+		t.Errorf("expected a valid signature, got %q", logs)
+	}
+}
@@ -0,0 +1,330 @@
+// Package keys issues function call access keys on the contract account and keeps track of them.
+//
+// A function call key lets its holder call methods of one receiver, paying gas from an allowance, without
+// a full access key: it's how linkdrops hand out claim keys and how dapps open sessions. The Manager adds
+// the keys with a promise batch on the contract account and records the permissions and the expiry of
+// every key, so the contract can check the key that signed a call and remove keys later:
+//
+//	// @contract:mutating
+//	func (c *Contract) OpenSession(publicKey string) error {
+//		pk, err := types.PublicKeyFromString(publicKey)
+//		if err != nil {
+//			return err
+//		}
+//		_, err = c.Keys.Issue(*pk, keys.Permission{
+//			Allowance:   allowance,
+//			MethodNames: []string{"play"},
+//			ExpiresAt:   env.GetBlockTimeMs() + 3_600_000,
+//		})
+//		return err
+//	}
+//
+//	// @contract:mutating
+//	func (c *Contract) Play() error {
+//		if _, err := c.Keys.RequireSignerKey(); err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// The records are kept in an UnorderedMap, so the Manager has to be saved with the contract state.
+package keys
+
+import (
+	"errors"
+
+	"github.com/vlmoon99/near-sdk-go/collections"
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+const (
+	ErrInvalidPublicKey      = "(KEYS_ERROR): invalid public key: "
+	ErrKeyAlreadyIssued      = "(KEYS_ERROR): the key is already issued: "
+	ErrKeyNotIssued          = "(KEYS_ERROR): the key was not issued by the contract: "
+	ErrKeyExpired            = "(KEYS_ERROR): the key expired: "
+	ErrAlreadyExpired        = "(KEYS_ERROR): the expiry is in the past"
+	ErrStorageFailed         = "(KEYS_ERROR): failed to store the key: "
+	ErrSignerKeyUnknown      = "(KEYS_ERROR): failed to get the signer public key: "
+	ErrCurrentAccountUnknown = "(KEYS_ERROR): failed to get the current account: "
+)
+
+// MaxListLimit is the most records List returns at once.
+const MaxListLimit = 100
+
+// Permission is what a function call key allows.
+type Permission struct {
+	// Allowance is the amount of gas fees the key can spend, in yoctoNEAR. Zero means unlimited.
+	Allowance types.Uint128
+	// ReceiverID is the contract the key can call, the current account when empty.
+	ReceiverID string
+	// MethodNames are the methods the key can call, any method of ReceiverID when empty.
+	MethodNames []string
+	// ExpiresAt is the block time in milliseconds from which the contract rejects the key, never when zero.
+	// The key stays on the account until it's revoked.
+	ExpiresAt uint64
+}
+
+// Key is the record of an issued key. Timestamps are in milliseconds.
+type Key struct {
+	PublicKey   string   `json:"public_key"`
+	Allowance   string   `json:"allowance"`
+	ReceiverID  string   `json:"receiver_id"`
+	MethodNames []string `json:"method_names"`
+	IssuedAt    uint64   `json:"issued_at"`
+	ExpiresAt   uint64   `json:"expires_at"`
+}
+
+// Expired reports whether the key expired at the block time now, in milliseconds.
+func (k Key) Expired(now uint64) bool {
+	return k.ExpiresAt != 0 && now >= k.ExpiresAt
+}
+
+// Manager keeps the keys issued by the contract, by their "ed25519:<base58>" string.
+type Manager struct {
+	Keys *collections.UnorderedMap[string, Key] `json:"keys"`
+}
+
+// New creates a manager storing its records under prefix.
+func New(prefix string) *Manager {
+	return &Manager{Keys: collections.NewUnorderedMap[string, Key](prefix)}
+}
+
+func validate(publicKey types.PublicKey) error {
+	if _, err := types.NewPublicKey(publicKey.Curve, publicKey.Data); err != nil {
+		return errors.New(ErrInvalidPublicKey + err.Error())
+	}
+	return nil
+}
+
+// PublicKeyFromBytes parses a public key in the format of the runtime, the curve byte followed by the key,
+// as env.GetSignerAccountPK returns it.
+func PublicKeyFromBytes(data []byte) (*types.PublicKey, error) {
+	if len(data) == 0 {
+		return nil, errors.New(ErrInvalidPublicKey + "empty")
+	}
+	publicKey, err := types.NewPublicKey(types.CurveType(data[0]), data[1:])
+	if err != nil {
+		return nil, errors.New(ErrInvalidPublicKey + err.Error())
+	}
+	return publicKey, nil
+}
+
+func currentAccount() (string, error) {
+	accountID, err := env.GetCurrentAccountId()
+	if err != nil {
+		return "", errors.New(ErrCurrentAccountUnknown + err.Error())
+	}
+	return accountID, nil
+}
+
+// Issue adds publicKey to the contract account as a function call key with permission and records it.
+// The returned batch on the contract account can be extended with more actions. Issue doesn't check the
+// predecessor: guard the method calling it.
+func (m *Manager) Issue(publicKey types.PublicKey, permission Permission) (*promise.PromiseBatch, error) {
+	accountID, err := currentAccount()
+	if err != nil {
+		return nil, err
+	}
+	return m.IssueOn(promise.CreateBatch(accountID), publicKey, permission)
+}
+
+// IssueOn is Issue adding the key with an existing batch on the contract account, e.g. after the other
+// actions of the method.
+func (m *Manager) IssueOn(batch *promise.PromiseBatch, publicKey types.PublicKey, permission Permission) (*promise.PromiseBatch, error) {
+	if err := m.checkIssuable(publicKey, permission.ExpiresAt); err != nil {
+		return nil, err
+	}
+	id := publicKey.ToBase58String()
+	now := env.GetBlockTimeMs()
+	if permission.ReceiverID == "" {
+		accountID, err := currentAccount()
+		if err != nil {
+			return nil, err
+		}
+		permission.ReceiverID = accountID
+	}
+	methodNames := permission.MethodNames
+	if methodNames == nil {
+		methodNames = []string{}
+	}
+
+	key := Key{
+		PublicKey:   id,
+		Allowance:   permission.Allowance.String(),
+		ReceiverID:  permission.ReceiverID,
+		MethodNames: methodNames,
+		IssuedAt:    now,
+		ExpiresAt:   permission.ExpiresAt,
+	}
+	if err := m.Keys.Insert(id, key); err != nil {
+		return nil, errors.New(ErrStorageFailed + err.Error())
+	}
+	return batch.AddAccessKey(publicKey.Bytes(), permission.Allowance, permission.ReceiverID, methodNames, 0), nil
+}
+
+// checkIssuable fails when publicKey is invalid or already issued, or when expiresAt is in the past.
+func (m *Manager) checkIssuable(publicKey types.PublicKey, expiresAt uint64) error {
+	if err := validate(publicKey); err != nil {
+		return err
+	}
+	id := publicKey.ToBase58String()
+	if issued, _ := m.Keys.Contains(id); issued {
+		return errors.New(ErrKeyAlreadyIssued + id)
+	}
+	if expiresAt != 0 && expiresAt <= env.GetBlockTimeMs() {
+		return errors.New(ErrAlreadyExpired)
+	}
+	return nil
+}
+
+// Get returns the record of publicKey.
+func (m *Manager) Get(publicKey types.PublicKey) (Key, error) {
+	id := publicKey.ToBase58String()
+	key, err := m.Keys.Get(id)
+	if err != nil {
+		return Key{}, errors.New(ErrKeyNotIssued + id)
+	}
+	return key, nil
+}
+
+// RequireKey fails unless publicKey was issued by the contract and hasn't expired.
+func (m *Manager) RequireKey(publicKey types.PublicKey) (Key, error) {
+	key, err := m.Get(publicKey)
+	if err != nil {
+		return Key{}, err
+	}
+	if key.Expired(env.GetBlockTimeMs()) {
+		return Key{}, errors.New(ErrKeyExpired + key.PublicKey)
+	}
+	return key, nil
+}
+
+// SignerKey returns the public key that signed the transaction.
+func SignerKey() (*types.PublicKey, error) {
+	data, err := env.GetSignerAccountPK()
+	if err != nil {
+		return nil, errors.New(ErrSignerKeyUnknown + err.Error())
+	}
+	return PublicKeyFromBytes(data)
+}
+
+// RequireSignerKey is RequireKey for the key that signed the transaction, for the methods only issued keys
+// can call.
+func (m *Manager) RequireSignerKey() (Key, error) {
+	publicKey, err := SignerKey()
+	if err != nil {
+		return Key{}, err
+	}
+	return m.RequireKey(*publicKey)
+}
+
+// Revoke deletes publicKey from the contract account and forgets it.
+func (m *Manager) Revoke(publicKey types.PublicKey) (*promise.PromiseBatch, error) {
+	accountID, err := currentAccount()
+	if err != nil {
+		return nil, err
+	}
+	return m.RevokeOn(promise.CreateBatch(accountID), publicKey)
+}
+
+// RevokeOn is Revoke deleting the key with an existing batch on the contract account.
+func (m *Manager) RevokeOn(batch *promise.PromiseBatch, publicKey types.PublicKey) (*promise.PromiseBatch, error) {
+	if _, err := m.Get(publicKey); err != nil {
+		return nil, err
+	}
+	if err := m.Keys.Remove(publicKey.ToBase58String()); err != nil {
+		return nil, errors.New(ErrStorageFailed + err.Error())
+	}
+	return batch.DeleteKey(publicKey.Bytes()), nil
+}
+
+// Rotate replaces oldKey with newKey in a single batch: newKey gets the permissions of oldKey, which is
+// deleted. expiresAt is the expiry of newKey; zero keeps the expiry of oldKey. newKey is checked before
+// oldKey is touched, so a failed rotation leaves oldKey in place.
+func (m *Manager) Rotate(oldKey, newKey types.PublicKey, expiresAt uint64) (*promise.PromiseBatch, error) {
+	key, err := m.Get(oldKey)
+	if err != nil {
+		return nil, err
+	}
+	allowance, err := types.U128FromString(key.Allowance)
+	if err != nil {
+		return nil, errors.New(ErrStorageFailed + err.Error())
+	}
+	if expiresAt == 0 {
+		expiresAt = key.ExpiresAt
+	}
+	if err := m.checkIssuable(newKey, expiresAt); err != nil {
+		return nil, err
+	}
+	batch, err := m.Revoke(oldKey)
+	if err != nil {
+		return nil, err
+	}
+	return m.IssueOn(batch, newKey, Permission{
+		Allowance:   allowance,
+		ReceiverID:  key.ReceiverID,
+		MethodNames: key.MethodNames,
+		ExpiresAt:   expiresAt,
+	})
+}
+
+// RevokeExpired deletes up to limit expired keys, all of them when limit is zero, in a single batch. It
+// reads the records one by one and stops once it found limit expired keys. It returns nil when no key
+// expired.
+func (m *Manager) RevokeExpired(limit uint64) (*promise.PromiseBatch, error) {
+	now := env.GetBlockTimeMs()
+	var batch *promise.PromiseBatch
+	revoked := uint64(0)
+	// Removing a record moves the last one to its index, so the index only moves past kept records.
+	for index := uint64(0); index < m.Keys.Length() && (limit == 0 || revoked < limit); {
+		ids, err := m.Keys.KeysRange(index, 1)
+		if err != nil {
+			return nil, errors.New(ErrStorageFailed + err.Error())
+		}
+		key, err := m.Keys.Get(ids[0])
+		if err != nil {
+			return nil, errors.New(ErrStorageFailed + err.Error())
+		}
+		if !key.Expired(now) {
+			index++
+			continue
+		}
+		publicKey, err := types.PublicKeyFromString(key.PublicKey)
+		if err != nil {
+			return nil, errors.New(ErrInvalidPublicKey + err.Error())
+		}
+		if batch == nil {
+			accountID, err := currentAccount()
+			if err != nil {
+				return nil, err
+			}
+			batch = promise.CreateBatch(accountID)
+		}
+		if _, err := m.RevokeOn(batch, *publicKey); err != nil {
+			return nil, err
+		}
+		revoked++
+	}
+	return batch, nil
+}
+
+// List returns up to limit records starting at fromIndex, reading only those records. A zero limit, or one
+// above MaxListLimit, returns MaxListLimit records.
+func (m *Manager) List(fromIndex, limit uint64) []Key {
+	if limit == 0 || limit > MaxListLimit {
+		limit = MaxListLimit
+	}
+	ids, err := m.Keys.KeysRange(fromIndex, limit)
+	if err != nil {
+		return []Key{}
+	}
+	keys := make([]Key, 0, len(ids))
+	for _, id := range ids {
+		if key, err := m.Keys.Get(id); err == nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
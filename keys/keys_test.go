@@ -0,0 +1,232 @@
+package keys
+
+import (
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/sim"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+const appID = "app.near"
+
+type keyArgs struct {
+	PublicKey string `json:"public_key"`
+	NewKey    string `json:"new_key"`
+	ExpiresAt uint64 `json:"expires_at"`
+	Limit     uint64 `json:"limit"`
+}
+
+func appContract() sim.Methods {
+	return sim.Methods{
+		"init": sim.Init(func(m *Manager) error {
+			*m = *New("k")
+			return nil
+		}),
+		"issue": sim.Method(func(m *Manager) (interface{}, error) {
+			var args keyArgs
+			sim.Input(&args)
+			allowance, _ := types.U128FromString("250000000000000000000000")
+			_, err := m.Issue(sim.ParseKey(args.PublicKey), Permission{Allowance: allowance, MethodNames: []string{"play"}, ExpiresAt: args.ExpiresAt})
+			return nil, err
+		}),
+		"rotate": sim.Method(func(m *Manager) (interface{}, error) {
+			var args keyArgs
+			sim.Input(&args)
+			_, err := m.Rotate(sim.ParseKey(args.PublicKey), sim.ParseKey(args.NewKey), 0)
+			return nil, err
+		}),
+		// try_rotate returns the error of Rotate instead of panicking, as a caller handling it would.
+		"try_rotate": sim.Method(func(m *Manager) (interface{}, error) {
+			var args keyArgs
+			sim.Input(&args)
+			if _, err := m.Rotate(sim.ParseKey(args.PublicKey), sim.ParseKey(args.NewKey), args.ExpiresAt); err != nil {
+				return err.Error(), nil
+			}
+			return "", nil
+		}),
+		"revoke": sim.Method(func(m *Manager) (interface{}, error) {
+			var args keyArgs
+			sim.Input(&args)
+			_, err := m.Revoke(sim.ParseKey(args.PublicKey))
+			return nil, err
+		}),
+		"revoke_expired": sim.Method(func(m *Manager) (interface{}, error) {
+			var args keyArgs
+			sim.Input(&args)
+			_, err := m.RevokeExpired(args.Limit)
+			return nil, err
+		}),
+		"play": sim.Method(func(m *Manager) (interface{}, error) {
+			key, err := m.RequireSignerKey()
+			if err != nil {
+				return nil, err
+			}
+			return key.PublicKey, nil
+		}),
+		"keys": sim.View(func(m *Manager) (interface{}, error) {
+			return m.List(0, 0), nil
+		}),
+	}
+}
+
+func setupChain(t *testing.T) *sim.Chain {
+	t.Helper()
+	chain := sim.Setup(t, sim.NEAR(10), map[string]sim.Contract{appID: appContract()}, appID)
+	if result := chain.MustCall(t, appID, appID, "init", nil); result.Failed() {
+		t.Fatalf("init failed: %v", result.Failure)
+	}
+	return chain
+}
+
+func listKeys(t *testing.T, chain *sim.Chain) []Key {
+	t.Helper()
+	view, err := chain.View(appID, "keys", nil)
+	var keys []Key
+	if err != nil || view.Unmarshal(&keys) != nil {
+		t.Fatalf("failed to list the keys: %v", err)
+	}
+	return keys
+}
+
+func TestIssueAndRequireSignerKey(t *testing.T) {
+	chain := setupChain(t)
+	session := sim.NewKey(1)
+	id := session.ToBase58String()
+	expiresAt := chain.Timestamp/1_000_000 + 10_000
+
+	if result := chain.MustCall(t, appID, appID, "issue", keyArgs{PublicKey: id, ExpiresAt: expiresAt}); result.Failed() || len(result.ReceiptFailures()) != 0 {
+		t.Fatalf("failed to issue: %v %v", result.Failure, result.ReceiptFailures())
+	}
+	added, ok := chain.Account(appID).Keys[string(session.Bytes())]
+	if !ok || added.FullAccess || added.ReceiverID != appID || len(added.MethodNames) != 1 || added.MethodNames[0] != "play" || added.Allowance.String() != "250000000000000000000000" {
+		t.Fatalf("unexpected access key %+v", added)
+	}
+	if keys := listKeys(t, chain); len(keys) != 1 || keys[0].PublicKey != id || keys[0].ExpiresAt != expiresAt || keys[0].ReceiverID != appID {
+		t.Errorf("unexpected records %+v", keys)
+	}
+	if result := chain.MustCall(t, appID, appID, "issue", keyArgs{PublicKey: id}); result.Failure == nil || result.Failure.Error() != sim.ErrContractPanicPrefix+ErrKeyAlreadyIssued+id {
+		t.Errorf("expected issuing twice to fail, got %v", result.Failure)
+	}
+
+	result, err := chain.CallWithKey(appID, session.Bytes(), appID, "play", nil, types.Uint128{})
+	var signer string
+	if err != nil || result.Failed() || result.Unmarshal(&signer) != nil || signer != id {
+		t.Errorf("expected the session key to play, got %q: %v", signer, err)
+	}
+	if result := chain.MustCall(t, appID, appID, "play", keyArgs{}); result.Failure == nil {
+		t.Errorf("expected a key the contract didn't issue to be rejected")
+	}
+
+	chain.FastForward(10)
+	result, _ = chain.CallWithKey(appID, session.Bytes(), appID, "play", nil, types.Uint128{})
+	if result.Failure == nil || result.Failure.Error() != sim.ErrContractPanicPrefix+ErrKeyExpired+id {
+		t.Errorf("expected the expired key to be rejected, got %v", result.Failure)
+	}
+
+	if result := chain.MustCall(t, appID, appID, "revoke_expired", keyArgs{}); result.Failed() || len(result.ReceiptFailures()) != 0 {
+		t.Fatalf("failed to revoke the expired keys: %v %v", result.Failure, result.ReceiptFailures())
+	}
+	if _, ok := chain.Account(appID).Keys[string(session.Bytes())]; ok || len(listKeys(t, chain)) != 0 {
+		t.Errorf("expected the expired key to be deleted")
+	}
+}
+
+func TestRotateAndRevoke(t *testing.T) {
+	chain := setupChain(t)
+	oldKey, newKey := sim.NewKey(1), sim.NewKey(2)
+
+	chain.MustCall(t, appID, appID, "issue", keyArgs{PublicKey: oldKey.ToBase58String()})
+	if result := chain.MustCall(t, appID, appID, "rotate", keyArgs{PublicKey: oldKey.ToBase58String(), NewKey: newKey.ToBase58String()}); result.Failed() || len(result.ReceiptFailures()) != 0 {
+		t.Fatalf("failed to rotate: %v %v", result.Failure, result.ReceiptFailures())
+	}
+	accessKeys := chain.Account(appID).Keys
+	if _, ok := accessKeys[string(oldKey.Bytes())]; ok {
+		t.Errorf("expected the old key to be deleted")
+	}
+	if rotated, ok := accessKeys[string(newKey.Bytes())]; !ok || rotated.MethodNames[0] != "play" {
+		t.Errorf("expected the new key to get the permissions of the old one, got %+v", rotated)
+	}
+	if keys := listKeys(t, chain); len(keys) != 1 || keys[0].PublicKey != newKey.ToBase58String() {
+		t.Errorf("unexpected records %+v", keys)
+	}
+
+	expected := sim.ErrContractPanicPrefix + ErrKeyNotIssued + oldKey.ToBase58String()
+	if result := chain.MustCall(t, appID, appID, "revoke", keyArgs{PublicKey: oldKey.ToBase58String()}); result.Failure == nil || result.Failure.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, result.Failure)
+	}
+	if result := chain.MustCall(t, appID, appID, "revoke", keyArgs{PublicKey: newKey.ToBase58String()}); result.Failed() || len(result.ReceiptFailures()) != 0 {
+		t.Fatalf("failed to revoke: %v %v", result.Failure, result.ReceiptFailures())
+	}
+	if len(chain.Account(appID).Keys) != 0 || len(listKeys(t, chain)) != 0 {
+		t.Errorf("expected no key left")
+	}
+}
+
+func TestRotateChecksNewKeyFirst(t *testing.T) {
+	chain := setupChain(t)
+	oldKey, otherKey, newKey := sim.NewKey(1), sim.NewKey(2), sim.NewKey(3)
+	chain.MustCall(t, appID, appID, "issue", keyArgs{PublicKey: oldKey.ToBase58String()})
+	chain.MustCall(t, appID, appID, "issue", keyArgs{PublicKey: otherKey.ToBase58String()})
+
+	rotations := []struct {
+		args     keyArgs
+		expected string
+	}{
+		{keyArgs{PublicKey: oldKey.ToBase58String(), NewKey: otherKey.ToBase58String()}, ErrKeyAlreadyIssued + otherKey.ToBase58String()},
+		{keyArgs{PublicKey: oldKey.ToBase58String(), NewKey: newKey.ToBase58String(), ExpiresAt: 1}, ErrAlreadyExpired},
+	}
+	for _, rotation := range rotations {
+		result := chain.MustCall(t, appID, appID, "try_rotate", rotation.args)
+		var message string
+		if result.Failed() || result.Unmarshal(&message) != nil || message != rotation.expected {
+			t.Errorf("expected %q, got %q: %v", rotation.expected, message, result.Failure)
+		}
+		if len(result.ReceiptFailures()) != 0 {
+			t.Errorf("unexpected receipt failures %v", result.ReceiptFailures())
+		}
+	}
+
+	if keys := listKeys(t, chain); len(keys) != 2 {
+		t.Errorf("expected both records to be kept, got %+v", keys)
+	}
+	if _, ok := chain.Account(appID).Keys[string(oldKey.Bytes())]; !ok {
+		t.Errorf("expected the old access key to stay on the account")
+	}
+}
+
+func TestRevokeExpiredStopsAtLimit(t *testing.T) {
+	chain := setupChain(t)
+	expiresAt := chain.Timestamp/1_000_000 + 10_000
+	for seed := byte(1); seed <= 3; seed++ {
+		key := sim.NewKey(seed)
+		chain.MustCall(t, appID, appID, "issue", keyArgs{PublicKey: key.ToBase58String(), ExpiresAt: expiresAt})
+	}
+	kept := sim.NewKey(4)
+	chain.MustCall(t, appID, appID, "issue", keyArgs{PublicKey: kept.ToBase58String()})
+	chain.FastForward(20)
+
+	if result := chain.MustCall(t, appID, appID, "revoke_expired", keyArgs{Limit: 2}); result.Failed() || len(result.ReceiptFailures()) != 0 {
+		t.Fatalf("failed to revoke the expired keys: %v %v", result.Failure, result.ReceiptFailures())
+	}
+	if keys := listKeys(t, chain); len(keys) != 2 {
+		t.Errorf("expected 2 revoked keys out of 4, got %+v", keys)
+	}
+	chain.MustCall(t, appID, appID, "revoke_expired", keyArgs{})
+	if keys := listKeys(t, chain); len(keys) != 1 || keys[0].PublicKey != kept.ToBase58String() {
+		t.Errorf("expected only the key without expiry to be left, got %+v", keys)
+	}
+}
+
+func TestPublicKeyFromBytes(t *testing.T) {
+	key := sim.NewKey(7)
+	parsed, err := PublicKeyFromBytes(key.Bytes())
+	if err != nil || parsed.ToBase58String() != key.ToBase58String() {
+		t.Errorf("expected %s, got %v: %v", key.ToBase58String(), parsed, err)
+	}
+	if _, err := PublicKeyFromBytes([]byte{0, 1, 2}); err == nil {
+		t.Errorf("expected a short key to be rejected")
+	}
+	if _, err := PublicKeyFromBytes(nil); err == nil {
+		t.Errorf("expected an empty key to be rejected")
+	}
+}
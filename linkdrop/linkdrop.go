@@ -0,0 +1,252 @@
+// Package linkdrop sends NEAR through claim keys, the way near-linkdrop does.
+//
+// The sender attaches NEAR to send with a public key. The contract adds the key to its own account as a
+// function call key that can only call claim and create_account_and_claim, and hands the private key out,
+// e.g. in a link. Whoever holds it signs, as the contract account, either claim to receive the NEAR on an
+// existing account, or create_account_and_claim to create a new account with its own full access key.
+// The claim key is deleted once the NEAR arrived; if the transfer or the account creation fails, a
+// callback puts the drop back so the key can claim again.
+//
+//	// @contract:payable min_deposit=0.1NEAR
+//	func (c *Contract) Send(publicKey string) error {
+//		pk, err := types.PublicKeyFromString(publicKey)
+//		if err != nil {
+//			return err
+//		}
+//		return c.Linkdrop.Send(*pk)
+//	}
+//
+// The drops and the claim keys are kept in collections, so the Linkdrop has to be saved with the contract
+// state.
+package linkdrop
+
+import (
+	"errors"
+
+	"github.com/vlmoon99/near-sdk-go/collections"
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/keys"
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+// The methods claim keys can call, and the callbacks of the claims.
+const (
+	ClaimMethod                 = "claim"
+	CreateAccountAndClaimMethod = "create_account_and_claim"
+	OnClaimedMethod             = "on_claimed"
+	OnAccountCreatedMethod      = "on_account_created"
+)
+
+// CallbackGas is the gas attached to the claim callbacks.
+const CallbackGas = 20 * types.ONE_TERA_GAS
+
+const (
+	ErrDepositTooLow    = "(LINKDROP_ERROR): the deposit must exceed the claim key allowance of "
+	ErrDropNotFound     = "(LINKDROP_ERROR): no drop for the key: "
+	ErrNotSelf          = "(LINKDROP_ERROR): the method can only be called by the contract account with a claim key"
+	ErrInvalidAccountID = "(LINKDROP_ERROR): invalid account ID: "
+	ErrInvalidAmount    = "(LINKDROP_ERROR): the amount is not a valid U128: "
+	ErrStorageFailed    = "(LINKDROP_ERROR): failed to store the drop: "
+	ErrContextUnknown   = "(LINKDROP_ERROR): failed to read the context of the call: "
+	ErrDifferentSender  = "(LINKDROP_ERROR): the key already holds a drop from another sender: "
+	ErrNotPrivate       = "(LINKDROP_ERROR): the callback can only be called by the contract"
+)
+
+// DefaultAllowance is the gas allowance of claim keys, 0.02 NEAR, taken from the deposit of Send.
+var DefaultAllowance = types.U64ToUint128(20_000_000_000).Mul64(1_000_000_000_000)
+
+// Drop is the NEAR a claim key can claim.
+type Drop struct {
+	SenderID string `json:"sender_id"`
+	Amount   string `json:"amount"`
+}
+
+// ClaimCallback are the arguments of the on_claimed and on_account_created callbacks.
+type ClaimCallback struct {
+	PublicKey string `json:"public_key"`
+	SenderID  string `json:"sender_id"`
+	Amount    string `json:"amount"`
+}
+
+// Linkdrop keeps the drops by the "ed25519:<base58>" string of their claim key.
+type Linkdrop struct {
+	Drops *collections.LookupMap[string, Drop] `json:"drops"`
+	Keys  *keys.Manager                        `json:"keys"`
+	// Allowance is the gas allowance of the claim keys.
+	Allowance types.Uint128 `json:"allowance"`
+}
+
+// New creates a linkdrop storing its drops and claim keys under prefix.
+func New(prefix string) *Linkdrop {
+	return &Linkdrop{
+		Drops:     collections.NewLookupMap[string, Drop](prefix + "d"),
+		Keys:      keys.New(prefix + "k"),
+		Allowance: DefaultAllowance,
+	}
+}
+
+func context() (current, predecessor string, err error) {
+	current, err = env.GetCurrentAccountId()
+	if err != nil {
+		return "", "", errors.New(ErrContextUnknown + err.Error())
+	}
+	predecessor, err = env.GetPredecessorAccountID()
+	if err != nil {
+		return "", "", errors.New(ErrContextUnknown + err.Error())
+	}
+	return current, predecessor, nil
+}
+
+// Send creates a drop of the attached deposit minus the key allowance for publicKey, and adds publicKey to
+// the contract account as a claim key. Sending again to the same key tops the drop up without paying for
+// the key again.
+func (l *Linkdrop) Send(publicKey types.PublicKey) error {
+	_, sender, err := context()
+	if err != nil {
+		return err
+	}
+	deposit, err := env.GetAttachedDeposit()
+	if err != nil {
+		return errors.New(ErrContextUnknown + err.Error())
+	}
+
+	id := publicKey.ToBase58String()
+	if drop, err := l.Drops.Get(id); err == nil {
+		if drop.SenderID != sender {
+			return errors.New(ErrDifferentSender + id)
+		}
+		amount, err := types.U128FromString(drop.Amount)
+		if err != nil {
+			return errors.New(ErrInvalidAmount + drop.Amount)
+		}
+		total, err := amount.Add(deposit)
+		if err != nil {
+			return errors.New(ErrInvalidAmount + err.Error())
+		}
+		return l.store(id, Drop{SenderID: sender, Amount: total.String()})
+	}
+
+	if deposit.Cmp(l.Allowance) <= 0 {
+		return errors.New(ErrDepositTooLow + l.Allowance.String())
+	}
+	amount, _ := deposit.Sub(l.Allowance)
+	if _, err := l.Keys.Issue(publicKey, keys.Permission{
+		Allowance:   l.Allowance,
+		MethodNames: []string{ClaimMethod, CreateAccountAndClaimMethod},
+	}); err != nil {
+		return err
+	}
+	return l.store(id, Drop{SenderID: sender, Amount: amount.String()})
+}
+
+func (l *Linkdrop) store(id string, drop Drop) error {
+	if err := l.Drops.Insert(id, drop); err != nil {
+		return errors.New(ErrStorageFailed + err.Error())
+	}
+	return nil
+}
+
+// GetKeyBalance returns the NEAR publicKey can claim.
+func (l *Linkdrop) GetKeyBalance(publicKey types.PublicKey) (types.Uint128, error) {
+	id := publicKey.ToBase58String()
+	drop, err := l.Drops.Get(id)
+	if err != nil {
+		return types.Uint128{}, errors.New(ErrDropNotFound + id)
+	}
+	amount, err := types.U128FromString(drop.Amount)
+	if err != nil {
+		return types.Uint128{}, errors.New(ErrInvalidAmount + drop.Amount)
+	}
+	return amount, nil
+}
+
+// take removes the drop of the claim key that signed the call, which has to come from the contract
+// account itself.
+func (l *Linkdrop) take() (string, ClaimCallback, types.Uint128, error) {
+	current, predecessor, err := context()
+	if err != nil {
+		return "", ClaimCallback{}, types.Uint128{}, err
+	}
+	if predecessor != current {
+		return "", ClaimCallback{}, types.Uint128{}, errors.New(ErrNotSelf)
+	}
+	key, err := l.Keys.RequireSignerKey()
+	if err != nil {
+		return "", ClaimCallback{}, types.Uint128{}, err
+	}
+	drop, err := l.Drops.Get(key.PublicKey)
+	if err != nil {
+		return "", ClaimCallback{}, types.Uint128{}, errors.New(ErrDropNotFound + key.PublicKey)
+	}
+	amount, err := types.U128FromString(drop.Amount)
+	if err != nil {
+		return "", ClaimCallback{}, types.Uint128{}, errors.New(ErrInvalidAmount + drop.Amount)
+	}
+	if err := l.Drops.Remove(key.PublicKey); err != nil {
+		return "", ClaimCallback{}, types.Uint128{}, errors.New(ErrStorageFailed + err.Error())
+	}
+	return current, ClaimCallback{PublicKey: key.PublicKey, SenderID: drop.SenderID, Amount: drop.Amount}, amount, nil
+}
+
+// Claim sends the drop of the signing claim key to accountID, then calls on_claimed.
+func (l *Linkdrop) Claim(accountID string) error {
+	if accountID == "" {
+		return errors.New(ErrInvalidAccountID + accountID)
+	}
+	current, callback, amount, err := l.take()
+	if err != nil {
+		return err
+	}
+	promise.CreateBatch(accountID).
+		Transfer(amount).
+		Then(current).
+		FunctionCall(OnClaimedMethod, callback, types.Uint128{Hi: 0, Lo: 0}, CallbackGas)
+	return nil
+}
+
+// CreateAccountAndClaim creates newAccountID with newPublicKey as its full access key and the drop of the
+// signing claim key as its balance, then calls on_account_created.
+func (l *Linkdrop) CreateAccountAndClaim(newAccountID string, newPublicKey types.PublicKey) error {
+	if newAccountID == "" {
+		return errors.New(ErrInvalidAccountID + newAccountID)
+	}
+	if _, err := types.NewPublicKey(newPublicKey.Curve, newPublicKey.Data); err != nil {
+		return errors.New(keys.ErrInvalidPublicKey + err.Error())
+	}
+	current, callback, amount, err := l.take()
+	if err != nil {
+		return err
+	}
+	promise.CreateBatch(newAccountID).
+		CreateAccount().
+		AddFullAccessKey(newPublicKey.Bytes(), 0).
+		Transfer(amount).
+		Then(current).
+		FunctionCall(OnAccountCreatedMethod, callback, types.Uint128{Hi: 0, Lo: 0}, CallbackGas)
+	return nil
+}
+
+// OnClaimed is the callback of Claim and CreateAccountAndClaim. When the claim succeeded, the claim key is
+// deleted; otherwise the NEAR came back to the contract and the drop is restored, so the key can claim
+// again. It returns whether the claim succeeded.
+func (l *Linkdrop) OnClaimed(callback ClaimCallback, result promise.PromiseResult) (bool, error) {
+	current, predecessor, err := context()
+	if err != nil {
+		return false, err
+	}
+	if predecessor != current {
+		return false, errors.New(ErrNotPrivate)
+	}
+	publicKey, err := types.PublicKeyFromString(callback.PublicKey)
+	if err != nil {
+		return false, errors.New(keys.ErrInvalidPublicKey + err.Error())
+	}
+	if !result.Success {
+		return false, l.store(callback.PublicKey, Drop{SenderID: callback.SenderID, Amount: callback.Amount})
+	}
+	if _, err := l.Keys.Revoke(*publicKey); err != nil {
+		return false, err
+	}
+	return true, nil
+}
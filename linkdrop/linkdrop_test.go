@@ -0,0 +1,204 @@
+package linkdrop
+
+import (
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/sim"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+const (
+	linkdropID = "linkdrop.near"
+	aliceID    = "alice.near"
+	bobID      = "bob.near"
+	dropAmount = "980000000000000000000000"
+)
+
+type args struct {
+	PublicKey    string `json:"public_key"`
+	AccountID    string `json:"account_id"`
+	NewAccountID string `json:"new_account_id"`
+	NewPublicKey string `json:"new_public_key"`
+}
+
+func onClaimed(l *Linkdrop, result promise.PromiseResult) (interface{}, error) {
+	var callback ClaimCallback
+	sim.Input(&callback)
+	return l.OnClaimed(callback, result)
+}
+
+func linkdropContract() sim.Methods {
+	return sim.Methods{
+		"init": sim.Init(func(l *Linkdrop) error {
+			*l = *New("l")
+			return nil
+		}),
+		"send": sim.Method(func(l *Linkdrop) (interface{}, error) {
+			var a args
+			sim.Input(&a)
+			return nil, l.Send(sim.ParseKey(a.PublicKey))
+		}),
+		ClaimMethod: sim.Method(func(l *Linkdrop) (interface{}, error) {
+			var a args
+			sim.Input(&a)
+			return nil, l.Claim(a.AccountID)
+		}),
+		CreateAccountAndClaimMethod: sim.Method(func(l *Linkdrop) (interface{}, error) {
+			var a args
+			sim.Input(&a)
+			return nil, l.CreateAccountAndClaim(a.NewAccountID, sim.ParseKey(a.NewPublicKey))
+		}),
+		OnClaimedMethod:        sim.Callback(onClaimed),
+		OnAccountCreatedMethod: sim.Callback(onClaimed),
+		"get_key_balance": sim.View(func(l *Linkdrop) (interface{}, error) {
+			var a args
+			sim.Input(&a)
+			balance, err := l.GetKeyBalance(sim.ParseKey(a.PublicKey))
+			if err != nil {
+				return nil, err
+			}
+			return balance.String(), nil
+		}),
+	}
+}
+
+func setupChain(t *testing.T) *sim.Chain {
+	t.Helper()
+	contracts := map[string]sim.Contract{linkdropID: linkdropContract()}
+	chain := sim.Setup(t, sim.NEAR(10), contracts, linkdropID, aliceID, bobID)
+	if result := chain.MustCall(t, aliceID, linkdropID, "init", nil); result.Failed() {
+		t.Fatalf("init failed: %v", result.Failure)
+	}
+	return chain
+}
+
+func send(t *testing.T, chain *sim.Chain, key types.PublicKey) {
+	t.Helper()
+	result, err := chain.Call(aliceID, linkdropID, "send", args{PublicKey: key.ToBase58String()}, sim.NEAR(1))
+	if err != nil || result.Failed() || len(result.ReceiptFailures()) != 0 {
+		t.Fatalf("failed to send: %v %v %v", err, result.Failure, result.ReceiptFailures())
+	}
+}
+
+func claim(t *testing.T, chain *sim.Chain, key types.PublicKey, method string, a args) *sim.Result {
+	t.Helper()
+	result, err := chain.CallWithKey(linkdropID, key.Bytes(), linkdropID, method, a, types.Uint128{})
+	if err != nil {
+		t.Fatalf("%s failed: %v", method, err)
+	}
+	return result
+}
+
+func expectBalance(t *testing.T, chain *sim.Chain, key types.PublicKey, expected string) {
+	t.Helper()
+	var balance string
+	result, err := chain.Call(aliceID, linkdropID, "get_key_balance", args{PublicKey: key.ToBase58String()}, types.Uint128{})
+	if expected == "" {
+		if err != nil || result.Failure == nil || result.Failure.Error() != sim.ErrContractPanicPrefix+ErrDropNotFound+key.ToBase58String() {
+			t.Errorf("expected no drop, got %v %v", err, result.Failure)
+		}
+		return
+	}
+	if err != nil || result.Failed() || result.Unmarshal(&balance) != nil || balance != expected {
+		t.Errorf("expected a balance of %s, got %s: %v", expected, balance, err)
+	}
+}
+
+func TestSendAndClaim(t *testing.T) {
+	chain := setupChain(t)
+	key := sim.NewKey(1)
+
+	send(t, chain, key)
+	expectBalance(t, chain, key, dropAmount)
+	claimKey, ok := chain.Account(linkdropID).Keys[string(key.Bytes())]
+	if !ok || claimKey.FullAccess || claimKey.ReceiverID != linkdropID || len(claimKey.MethodNames) != 2 ||
+		claimKey.MethodNames[0] != ClaimMethod || claimKey.MethodNames[1] != CreateAccountAndClaimMethod {
+		t.Fatalf("unexpected claim key %+v", claimKey)
+	}
+	if _, err := chain.CallWithKey(linkdropID, key.Bytes(), linkdropID, "send", args{}, types.Uint128{}); err == nil {
+		t.Errorf("expected the claim key to be restricted to the claim methods")
+	}
+
+	result, _ := chain.Call(aliceID, linkdropID, ClaimMethod, args{AccountID: aliceID}, types.Uint128{})
+	if result.Failure == nil || result.Failure.Error() != sim.ErrContractPanicPrefix+ErrNotSelf {
+		t.Errorf("expected a claim from another account to fail, got %v", result.Failure)
+	}
+
+	result = claim(t, chain, key, ClaimMethod, args{AccountID: bobID})
+	if result.Failed() || len(result.ReceiptFailures()) != 0 {
+		t.Fatalf("failed to claim: %v %v", result.Failure, result.ReceiptFailures())
+	}
+	amount, _ := types.U128FromString(dropAmount)
+	received, _ := sim.NEAR(10).Add(amount)
+	if balance := chain.Account(bobID).Balance; balance.Cmp(received) != 0 {
+		t.Errorf("expected bob.near to receive the drop, got %s", balance.String())
+	}
+	if _, ok := chain.Account(linkdropID).Keys[string(key.Bytes())]; ok {
+		t.Errorf("expected the claim key to be deleted")
+	}
+	expectBalance(t, chain, key, "")
+}
+
+func TestFailedClaimRestoresDrop(t *testing.T) {
+	chain := setupChain(t)
+	key := sim.NewKey(2)
+	send(t, chain, key)
+
+	result := claim(t, chain, key, ClaimMethod, args{AccountID: "ghost.near"})
+	if len(result.ReceiptFailures()) != 1 {
+		t.Fatalf("expected the transfer to the missing account to fail, got %v", result.ReceiptFailures())
+	}
+	expectBalance(t, chain, key, dropAmount)
+
+	owner := sim.NewKey(3)
+	result = claim(t, chain, key, CreateAccountAndClaimMethod, args{NewAccountID: bobID, NewPublicKey: owner.ToBase58String()})
+	if len(result.ReceiptFailures()) != 1 {
+		t.Fatalf("expected creating an existing account to fail, got %v", result.ReceiptFailures())
+	}
+	expectBalance(t, chain, key, dropAmount)
+	if _, ok := chain.Account(linkdropID).Keys[string(key.Bytes())]; !ok {
+		t.Errorf("expected the claim key to stay after failed claims")
+	}
+}
+
+func TestCreateAccountAndClaim(t *testing.T) {
+	chain := setupChain(t)
+	key, owner := sim.NewKey(4), sim.NewKey(5)
+	send(t, chain, key)
+
+	result := claim(t, chain, key, CreateAccountAndClaimMethod, args{NewAccountID: "carol.near", NewPublicKey: owner.ToBase58String()})
+	if result.Failed() || len(result.ReceiptFailures()) != 0 {
+		t.Fatalf("failed to create the account: %v %v", result.Failure, result.ReceiptFailures())
+	}
+	carol := chain.Account("carol.near")
+	if carol == nil || carol.Balance.String() != dropAmount {
+		t.Fatalf("expected carol.near to be created with the drop, got %+v", carol)
+	}
+	if fullKey, ok := carol.Keys[string(owner.Bytes())]; !ok || !fullKey.FullAccess {
+		t.Errorf("expected carol.near to get a full access key, got %+v", carol.Keys)
+	}
+	if _, ok := chain.Account(linkdropID).Keys[string(key.Bytes())]; ok {
+		t.Errorf("expected the claim key to be deleted")
+	}
+	expectBalance(t, chain, key, "")
+}
+
+func TestSendValidation(t *testing.T) {
+	chain := setupChain(t)
+	key := sim.NewKey(6)
+
+	result, _ := chain.Call(aliceID, linkdropID, "send", args{PublicKey: key.ToBase58String()}, DefaultAllowance)
+	if result.Failure == nil || result.Failure.Error() != sim.ErrContractPanicPrefix+ErrDepositTooLow+DefaultAllowance.String() {
+		t.Errorf("expected a deposit not covering the allowance to fail, got %v", result.Failure)
+	}
+
+	send(t, chain, key)
+	send(t, chain, key)
+	expectBalance(t, chain, key, "1980000000000000000000000")
+
+	result, _ = chain.Call(bobID, linkdropID, "send", args{PublicKey: key.ToBase58String()}, sim.NEAR(1))
+	if result.Failure == nil || result.Failure.Error() != sim.ErrContractPanicPrefix+ErrDifferentSender+key.ToBase58String() {
+		t.Errorf("expected another sender to be rejected, got %v", result.Failure)
+	}
+}
@@ -0,0 +1,135 @@
+package sim
+
+import (
+	"encoding/json"
+
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+// The functions below build the entry points the CLI generates for a contract with state type S, so the
+// contract can be deployed on the simulator from its Go methods:
+//
+//	chain.Deploy("app.near", sim.Methods{
+//		"init": sim.Init(func(c *Contract) error {
+//			c.Init()
+//			return nil
+//		}),
+//		"add": sim.Method(func(c *Contract) (interface{}, error) {
+//			var args struct {
+//				Amount string `json:"amount"`
+//			}
+//			sim.Input(&args)
+//			return nil, c.Add(args.Amount)
+//		}),
+//	})
+//
+// Like the generated code, they keep the state as JSON under env.StateKey, and every method but the init
+// method panics until the contract is initialized.
+
+const (
+	ErrStateNotInitialized     = "(SIM_ERROR): the contract is not initialized"
+	ErrStateAlreadyInitialized = "(SIM_ERROR): the contract is already initialized"
+)
+
+// Input decodes the JSON arguments of the call into v, leaving v as it is when there are none.
+func Input(v interface{}) {
+	data := RawInput()
+	if len(data) == 0 {
+		return
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		env.PanicStr(err.Error())
+	}
+}
+
+// RawInput returns the arguments of the call as they were sent.
+func RawInput() []byte {
+	data, _, err := env.ContractInput(types.ContractInputOptions{IsRawBytes: true})
+	if err != nil {
+		env.PanicStr(err.Error())
+	}
+	return data
+}
+
+func loadState[S any]() *S {
+	data, err := env.StateRead()
+	if err != nil {
+		env.PanicStr(ErrStateNotInitialized)
+	}
+	state := new(S)
+	if err := json.Unmarshal(data, state); err != nil {
+		env.PanicStr(err.Error())
+	}
+	return state
+}
+
+func saveState[S any](state *S) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		env.PanicStr(err.Error())
+	}
+	if err := env.StateWrite(data); err != nil {
+		env.PanicStr(err.Error())
+	}
+}
+
+// returnResult panics with err, or returns value unless it's nil.
+func returnResult(value interface{}, err error) {
+	if err != nil {
+		env.PanicStr(err.Error())
+	}
+	if value == nil {
+		return
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		env.PanicStr(err.Error())
+	}
+	env.ContractValueReturn(data)
+}
+
+// Init is the init entry point: fn initializes a new state, which is saved. It panics when the contract is
+// already initialized.
+func Init[S any](fn func(state *S) error) func() {
+	return func() {
+		if env.StateExists() {
+			env.PanicStr(ErrStateAlreadyInitialized)
+		}
+		state := new(S)
+		returnResult(nil, fn(state))
+		saveState(state)
+	}
+}
+
+// Method is a mutating entry point: it loads the state, runs fn, returns its value and saves the state.
+func Method[S any](fn func(state *S) (interface{}, error)) func() {
+	return func() {
+		state := loadState[S]()
+		returnResult(fn(state))
+		saveState(state)
+	}
+}
+
+// View is a view entry point: it loads the state and returns the value of fn.
+func View[S any](fn func(state *S) (interface{}, error)) func() {
+	return func() {
+		returnResult(fn(loadState[S]()))
+	}
+}
+
+// Callback is a promise callback entry point: like Method, with the result of the promise it's attached to.
+// It panics when it isn't called by the contract itself as a callback.
+func Callback[S any](fn func(state *S, result promise.PromiseResult) (interface{}, error)) func() {
+	return Method(func(state *S) (interface{}, error) {
+		if err := promise.CallbackGuard(); err != nil {
+			return nil, err
+		}
+		result, err := promise.GetPromiseResultSafe(0)
+		if err != nil {
+			return nil, err
+		}
+		return fn(state, result)
+	})
+}
@@ -0,0 +1,404 @@
+package sim
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/system"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+// Action is a single action of a receipt. Only the fields used by Kind are set.
+type Action struct {
+	Kind promise.BatchAction
+
+	// DeployContractAction
+	Code []byte
+
+	// FunctionCallAction
+	MethodName string
+	Args       []byte
+	Gas        uint64
+
+	// FunctionCallAction, TransferAction and StakeAction
+	Deposit types.Uint128
+
+	// StakeAction, AddKeyFullAccessAction, AddKeyFunctionCallAction and DeleteKeyAction
+	PublicKey []byte
+
+	// AddKeyFullAccessAction and AddKeyFunctionCallAction
+	Nonce uint64
+
+	// AddKeyFunctionCallAction
+	Allowance   types.Uint128
+	ReceiverID  string
+	MethodNames []string
+
+	// DeleteAccountAction
+	Beneficiary string
+}
+
+// promiseData is the result of a receipt delivered to the receipts that depend on it.
+type promiseData struct {
+	success bool
+	data    []byte
+}
+
+// Receipt is a unit of execution routed to a single receiver account.
+type Receipt struct {
+	ID          uint64
+	Predecessor string
+	Receiver    string
+	Signer      string
+	SignerPk    []byte
+	Actions     []Action
+
+	// inputs are the receipts whose results this receipt waits for, in promise result order.
+	inputs []uint64
+	data   map[uint64]promiseData
+	// outputs are the receipts waiting for the result of this receipt.
+	outputs []*Receipt
+	// sink receives the final result of the transaction when this receipt produces it.
+	sink *Result
+	tx   *Result
+}
+
+func (r *Receipt) ready() bool {
+	return len(r.data) == len(r.inputs)
+}
+
+func (r *Receipt) deliver(from uint64, data promiseData) {
+	r.data[from] = data
+}
+
+// ExecutionOutcome describes the execution of a single receipt.
+type ExecutionOutcome struct {
+	ReceiptID   uint64
+	BlockHeight uint64
+	Predecessor string
+	Receiver    string
+	Logs        []string
+	Value       []byte
+	Failure     error
+	// ReceiptIDs are the receipts created by this execution.
+	ReceiptIDs []uint64
+}
+
+// Result is the outcome of a transaction or a view call.
+type Result struct {
+	// Outcomes of every receipt executed on behalf of the transaction, in execution order.
+	Outcomes []ExecutionOutcome
+	// Value is the value returned by the transaction once all returned promises were resolved.
+	Value []byte
+	// Failure is set when the receipt that produces the transaction result failed.
+	Failure error
+}
+
+// Failed reports whether the transaction result is a failure.
+func (r *Result) Failed() bool {
+	return r.Failure != nil
+}
+
+// Logs returns the logs of every receipt executed on behalf of the transaction.
+func (r *Result) Logs() []string {
+	var logs []string
+	for _, outcome := range r.Outcomes {
+		logs = append(logs, outcome.Logs...)
+	}
+	return logs
+}
+
+// Unmarshal decodes the JSON value returned by the transaction into v.
+func (r *Result) Unmarshal(v interface{}) error {
+	if r.Failure != nil {
+		return r.Failure
+	}
+	return json.Unmarshal(r.Value, v)
+}
+
+// ReceiptFailures returns the failures of every receipt, including the ones that don't affect the result.
+func (r *Result) ReceiptFailures() []error {
+	var failures []error
+	for _, outcome := range r.Outcomes {
+		if outcome.Failure != nil {
+			failures = append(failures, outcome.Failure)
+		}
+	}
+	return failures
+}
+
+// contractPanic is raised by the runtime to abort the execution, the same way a wasm trap does.
+type contractPanic struct {
+	message string
+}
+
+func (c *Chain) newReceipt(predecessor, receiver, signer string, actions []Action, tx *Result) *Receipt {
+	receipt := &Receipt{
+		ID:          c.nextReceiptID,
+		Predecessor: predecessor,
+		Receiver:    receiver,
+		Signer:      signer,
+		SignerPk:    c.signerPk(signer),
+		Actions:     actions,
+		data:        make(map[uint64]promiseData),
+		tx:          tx,
+	}
+	c.nextReceiptID++
+	return receipt
+}
+
+// signerPk returns the first full access key of the signer, or a key derived from the account ID.
+func (c *Chain) signerPk(signer string) []byte {
+	if account, exists := c.accounts[signer]; exists {
+		for _, key := range account.Keys {
+			if key.FullAccess {
+				return key.PublicKey
+			}
+		}
+	}
+	hash := sha256.Sum256([]byte(signer))
+	return append([]byte{byte(types.ED25519)}, hash[:]...)
+}
+
+func totalDeposit(actions []Action) (types.Uint128, error) {
+	total := types.Uint128{Hi: 0, Lo: 0}
+	for _, action := range actions {
+		switch action.Kind {
+		case promise.FunctionCallAction, promise.TransferAction:
+			var err error
+			total, err = total.Add(action.Deposit)
+			if err != nil {
+				return types.Uint128{Hi: 0, Lo: 0}, err
+			}
+		}
+	}
+	return total, nil
+}
+
+// executeReceipt applies the actions of the receipt to a copy of the receiver account.
+// The copy replaces the account only if every action succeeded, otherwise deposits are refunded
+// to the predecessor and the receiver state is left untouched.
+func (c *Chain) executeReceipt(receipt *Receipt) {
+	outcome := ExecutionOutcome{
+		ReceiptID:   receipt.ID,
+		BlockHeight: c.Height,
+		Predecessor: receipt.Predecessor,
+		Receiver:    receipt.Receiver,
+	}
+
+	var working *Account
+	if existing, exists := c.accounts[receipt.Receiver]; exists {
+		working = existing.clone()
+	}
+
+	var drafts []*promiseDraft
+	returned := -1
+	deleted := false
+	var err error
+
+	for _, action := range receipt.Actions {
+		switch action.Kind {
+		case promise.CreateAccountAction:
+			if working != nil {
+				err = errors.New(ErrAccountAlreadyExists + receipt.Receiver)
+				break
+			}
+			working = newAccount(receipt.Receiver, types.Uint128{Hi: 0, Lo: 0})
+		default:
+			if working == nil {
+				err = errors.New(ErrAccountDoesNotExist + receipt.Receiver)
+				break
+			}
+			switch action.Kind {
+			case promise.DeployContractAction:
+				working.Code = action.Code
+				working.Contract, err = c.contractForCode(action.Code)
+			case promise.FunctionCallAction:
+				rt := c.newRuntime(working, receipt, action, false)
+				outcome.Value, err = c.runFunctionCall(rt, working, action.MethodName)
+				outcome.Logs = append(outcome.Logs, rt.logs...)
+				if err == nil {
+					working.Balance = rt.AccountBalanceSys
+					working.Locked = rt.AccountLockedBalanceSys
+					working.StorageUsage = rt.StorageUsageSys
+					// Indices of the promises created by this call are shifted by the promises of previous calls.
+					offset := len(drafts)
+					for _, draft := range rt.promises {
+						draft.shift(offset)
+						drafts = append(drafts, draft)
+					}
+					if rt.returned >= 0 {
+						returned = rt.returned + offset
+					}
+				}
+			case promise.TransferAction:
+				working.Balance, err = working.Balance.Add(action.Deposit)
+			case promise.StakeAction:
+				working.Balance, err = working.Balance.Add(working.Locked)
+				if err == nil {
+					working.Balance, err = working.Balance.Sub(action.Deposit)
+					working.Locked = action.Deposit
+				}
+			case promise.AddKeyFullAccessAction:
+				working.Keys[string(action.PublicKey)] = AccessKey{PublicKey: action.PublicKey, Nonce: action.Nonce, FullAccess: true}
+			case promise.AddKeyFunctionCallAction:
+				working.Keys[string(action.PublicKey)] = AccessKey{
+					PublicKey:   action.PublicKey,
+					Nonce:       action.Nonce,
+					Allowance:   action.Allowance,
+					ReceiverID:  action.ReceiverID,
+					MethodNames: action.MethodNames,
+				}
+			case promise.DeleteKeyAction:
+				if _, exists := working.Keys[string(action.PublicKey)]; !exists {
+					err = errors.New(ErrKeyDoesNotExist)
+					break
+				}
+				delete(working.Keys, string(action.PublicKey))
+			case promise.DeleteAccountAction:
+				refund := Action{Kind: promise.TransferAction, Deposit: working.Balance}
+				c.pending = append(c.pending, c.newReceipt(receipt.Receiver, action.Beneficiary, receipt.Signer, []Action{refund}, receipt.tx))
+				deleted = true
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	if err == nil && !deleted {
+		check := &system.MockSystem{StorageUsageSys: working.StorageUsage, AccountBalanceSys: working.Balance, AccountLockedBalanceSys: working.Locked}
+		err = check.StorageStakingCheck()
+	}
+
+	outcome.Failure = err
+	if err != nil {
+		outcome.Value = nil
+		c.refund(receipt)
+	} else {
+		if deleted {
+			delete(c.accounts, receipt.Receiver)
+		} else {
+			c.accounts[receipt.Receiver] = working
+		}
+		outcome.ReceiptIDs = c.scheduleDrafts(receipt, drafts)
+	}
+
+	if err == nil && returned >= 0 {
+		c.forwardOutputs(receipt, drafts[returned].receipt)
+	} else {
+		c.deliverOutputs(receipt, promiseData{success: err == nil, data: outcome.Value}, err)
+	}
+
+	if receipt.tx != nil {
+		receipt.tx.Outcomes = append(receipt.tx.Outcomes, outcome)
+	}
+}
+
+// runFunctionCall executes method on the account contract, turning runtime aborts into errors.
+func (c *Chain) runFunctionCall(rt *Runtime, account *Account, method string) (value []byte, err error) {
+	if account.Contract == nil {
+		return nil, errors.New(ErrContractNotDeployed + account.ID)
+	}
+
+	restore := bindEnv(rt)
+	defer restore()
+
+	defer func() {
+		if r := recover(); r != nil {
+			value = nil
+			switch p := r.(type) {
+			case contractPanic:
+				err = errors.New(ErrContractPanicPrefix + p.message)
+			case error:
+				err = p
+			default:
+				err = errors.New(fmt.Sprint(p))
+			}
+		}
+	}()
+
+	if err := account.Contract.Execute(rt, method); err != nil {
+		return nil, err
+	}
+	return rt.value, nil
+}
+
+// refund returns the deposits of a failed receipt to its predecessor.
+func (c *Chain) refund(receipt *Receipt) {
+	if receipt.Predecessor == SystemAccountID {
+		return
+	}
+	amount, err := totalDeposit(receipt.Actions)
+	if err != nil || amount.Cmp(types.Uint128{Hi: 0, Lo: 0}) == 0 {
+		return
+	}
+	refund := Action{Kind: promise.TransferAction, Deposit: amount}
+	c.pending = append(c.pending, c.newReceipt(SystemAccountID, receipt.Predecessor, receipt.Predecessor, []Action{refund}, receipt.tx))
+}
+
+// scheduleDrafts turns the promises created during the execution into receipts for the next blocks.
+func (c *Chain) scheduleDrafts(parent *Receipt, drafts []*promiseDraft) []uint64 {
+	var ids []uint64
+	for _, draft := range drafts {
+		if draft.joint != nil {
+			continue
+		}
+		draft.receipt = c.newReceipt(parent.Receiver, draft.receiver, parent.Signer, draft.actions, parent.tx)
+		ids = append(ids, draft.receipt.ID)
+	}
+
+	for _, draft := range drafts {
+		if draft.joint != nil {
+			continue
+		}
+		for _, dependency := range expandDependencies(drafts, draft.after) {
+			input := drafts[dependency].receipt
+			draft.receipt.inputs = append(draft.receipt.inputs, input.ID)
+			input.outputs = append(input.outputs, draft.receipt)
+		}
+		c.pending = append(c.pending, draft.receipt)
+	}
+	return ids
+}
+
+func expandDependencies(drafts []*promiseDraft, after int) []int {
+	if after < 0 {
+		return nil
+	}
+	if drafts[after].joint != nil {
+		return drafts[after].joint
+	}
+	return []int{after}
+}
+
+// forwardOutputs hands the receivers of the receipt result over to the promise it returned. The receivers
+// now wait for the returned receipt, so its ID replaces the one of the receipt in their inputs.
+func (c *Chain) forwardOutputs(from, to *Receipt) {
+	for _, output := range from.outputs {
+		for i, input := range output.inputs {
+			if input == from.ID {
+				output.inputs[i] = to.ID
+			}
+		}
+	}
+	to.outputs = append(to.outputs, from.outputs...)
+	if from.sink != nil {
+		to.sink = from.sink
+	}
+	from.outputs = nil
+	from.sink = nil
+}
+
+func (c *Chain) deliverOutputs(receipt *Receipt, data promiseData, err error) {
+	for _, output := range receipt.outputs {
+		output.deliver(receipt.ID, data)
+	}
+	if receipt.sink != nil {
+		receipt.sink.Value = data.data
+		receipt.sink.Failure = err
+	}
+}
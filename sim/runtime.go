@@ -0,0 +1,403 @@
+package sim
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"strings"
+	"unicode/utf16"
+	"unsafe"
+
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/system"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+// promiseDraft is a promise created during a function call. It becomes a receipt once the call succeeds.
+type promiseDraft struct {
+	receiver string
+	actions  []Action
+	// after is the index of the promise this one waits for, or -1.
+	after int
+	// joint holds the combined promise indices when the draft was created with PromiseAnd.
+	joint   []int
+	receipt *Receipt
+}
+
+func (d *promiseDraft) shift(offset int) {
+	if d.after >= 0 {
+		d.after += offset
+	}
+	for i := range d.joint {
+		d.joint[i] += offset
+	}
+}
+
+// Runtime is the host environment of a single function call executed by the simulator.
+//
+// It extends system.MockSystem with the state of the receiver account and the receipt being executed,
+// real aborts on panics, collected logs and return values, and promises that are turned into receipts
+// once the call succeeds.
+type Runtime struct {
+	*system.MockSystem
+
+	chain   *Chain
+	receipt *Receipt
+	view    bool
+
+	logs     []string
+	value    []byte
+	results  []promiseData
+	promises []*promiseDraft
+	returned int
+}
+
+func (c *Chain) newRuntime(account *Account, receipt *Receipt, action Action, view bool) *Runtime {
+	mock := system.NewMockSystem()
+	mock.Storage = account.Storage
+	mock.CurrentAccountIdSys = account.ID
+	mock.SignerAccountIdSys = receipt.Signer
+	mock.SignerAccountPkSys = receipt.SignerPk
+	mock.PredecessorAccountIdSys = receipt.Predecessor
+	mock.ContractInput = action.Args
+	mock.BlockIndexSys = c.Height
+	mock.BlockTimestampSys = c.Timestamp
+	mock.EpochHeightSys = c.EpochHeight
+	mock.StorageUsageSys = account.StorageUsage
+	mock.AccountBalanceSys = account.Balance
+	mock.AccountLockedBalanceSys = account.Locked
+	mock.PrepaidGasSys = action.Gas
+	mock.UsedGasSys = 0
+
+	rt := &Runtime{
+		MockSystem: mock,
+		chain:      c,
+		receipt:    receipt,
+		view:       view,
+		returned:   -1,
+	}
+
+	if !view {
+		rt.AttachDeposit(action.Deposit)
+		for _, input := range receipt.inputs {
+			rt.results = append(rt.results, receipt.data[input])
+		}
+	}
+	return rt
+}
+
+// Logs returns the messages logged so far by the function call.
+func (rt *Runtime) Logs() []string {
+	return rt.logs
+}
+
+func (rt *Runtime) abort(message string) {
+	panic(contractPanic{message: message})
+}
+
+func (rt *Runtime) prohibitedInView(method string) {
+	if rt.view {
+		rt.abort(ErrProhibitedInView + method)
+	}
+}
+
+func readBytes(length, ptr uint64) []byte {
+	if length == 0 {
+		return []byte{}
+	}
+	data := make([]byte, length)
+	copy(data, unsafe.Slice((*byte)(unsafe.Pointer(uintptr(ptr))), length))
+	return data
+}
+
+func readUint128(ptr uint64) types.Uint128 {
+	value, _ := types.LoadUint128LE(readBytes(16, ptr))
+	return value
+}
+
+// Storage API
+
+func (rt *Runtime) StorageWrite(keyLen, keyPtr, valueLen, valuePtr, registerId uint64) uint64 {
+	rt.prohibitedInView("storage_write")
+
+	key := string(readBytes(keyLen, keyPtr))
+	old, exists := rt.Storage[key]
+	rt.MockSystem.StorageWrite(keyLen, keyPtr, valueLen, valuePtr, 0)
+	if !exists {
+		return 0
+	}
+	rt.writeRegister(registerId, old)
+	return 1
+}
+
+func (rt *Runtime) StorageRemove(keyLen, keyPtr, registerId uint64) uint64 {
+	rt.prohibitedInView("storage_remove")
+	return rt.MockSystem.StorageRemove(keyLen, keyPtr, registerId)
+}
+
+func (rt *Runtime) writeRegister(registerId uint64, data []byte) {
+	rt.Registers[registerId] = append([]byte{}, data...)
+}
+
+// Context API
+
+func (rt *Runtime) SignerAccountId(registerId uint64) {
+	rt.prohibitedInView("signer_account_id")
+	rt.MockSystem.SignerAccountId(registerId)
+}
+
+func (rt *Runtime) SignerAccountPk(registerId uint64) {
+	rt.prohibitedInView("signer_account_pk")
+	rt.writeRegister(registerId, rt.SignerAccountPkSys)
+}
+
+func (rt *Runtime) PredecessorAccountId(registerId uint64) {
+	rt.prohibitedInView("predecessor_account_id")
+	rt.MockSystem.PredecessorAccountId(registerId)
+}
+
+// Economics API
+
+func (rt *Runtime) AttachedDeposit(balancePtr uint64) {
+	rt.prohibitedInView("attached_deposit")
+	rt.MockSystem.AttachedDeposit(balancePtr)
+}
+
+func (rt *Runtime) PrepaidGas() uint64 {
+	rt.prohibitedInView("prepaid_gas")
+	return rt.MockSystem.PrepaidGas()
+}
+
+func (rt *Runtime) UsedGas() uint64 {
+	rt.prohibitedInView("used_gas")
+	return rt.MockSystem.UsedGas()
+}
+
+// Math API
+
+func (rt *Runtime) Sha256(valueLen, valuePtr, registerId uint64) {
+	hash := sha256.Sum256(readBytes(valueLen, valuePtr))
+	rt.writeRegister(registerId, hash[:])
+}
+
+func (rt *Runtime) Ed25519Verify(sigLen, sigPtr, msgLen, msgPtr, pubKeyLen, pubKeyPtr uint64) uint64 {
+	if sigLen != ed25519.SignatureSize || pubKeyLen != ed25519.PublicKeySize {
+		rt.abort(ErrInvalidEd25519Input)
+	}
+	signature := readBytes(sigLen, sigPtr)
+	publicKey := readBytes(pubKeyLen, pubKeyPtr)
+	return types.BoolToUnit(ed25519.Verify(publicKey, readBytes(msgLen, msgPtr), signature))
+}
+
+func (rt *Runtime) RandomSeed(registerId uint64) {
+	seed := sha256.Sum256([]byte(rt.receipt.Receiver + types.Uint64ToString(rt.BlockIndexSys)))
+	rt.writeRegister(registerId, seed[:])
+}
+
+// Miscellaneous API
+
+func (rt *Runtime) ValueReturn(valueLen, valuePtr uint64) {
+	rt.value = readBytes(valueLen, valuePtr)
+}
+
+func (rt *Runtime) PanicUtf8(len, ptr uint64) {
+	rt.abort(string(readBytes(len, ptr)))
+}
+
+func (rt *Runtime) LogUtf8(len, ptr uint64) {
+	rt.logs = append(rt.logs, string(readBytes(len, ptr)))
+}
+
+func (rt *Runtime) LogUtf16(len, ptr uint64) {
+	data := readBytes(len, ptr)
+	units := make([]uint16, len/2)
+	for i := range units {
+		units[i] = uint16(data[2*i]) | uint16(data[2*i+1])<<8
+	}
+	rt.logs = append(rt.logs, string(utf16.Decode(units)))
+}
+
+// Promises API
+
+func (rt *Runtime) newPromise(receiver string, after int) uint64 {
+	rt.prohibitedInView("promise_create")
+	rt.promises = append(rt.promises, &promiseDraft{receiver: receiver, after: after})
+	return uint64(len(rt.promises) - 1)
+}
+
+func (rt *Runtime) promise(promiseIndex uint64) *promiseDraft {
+	if promiseIndex >= uint64(len(rt.promises)) {
+		rt.abort(ErrInvalidPromiseIndex)
+	}
+	return rt.promises[promiseIndex]
+}
+
+// batch returns the promise that actions are appended to. Joint promises can't carry actions.
+func (rt *Runtime) batch(promiseIndex uint64) *promiseDraft {
+	draft := rt.promise(promiseIndex)
+	if draft.joint != nil {
+		rt.abort(ErrInvalidPromiseIndex)
+	}
+	return draft
+}
+
+// debit takes the amount attached to an outgoing action from the current account balance.
+func (rt *Runtime) debit(amount types.Uint128) {
+	balance, err := rt.AccountBalanceSys.Sub(amount)
+	if err != nil {
+		rt.abort(system.ErrBalanceExceeded)
+	}
+	rt.AccountBalanceSys = balance
+}
+
+func (rt *Runtime) addFunctionCall(draft *promiseDraft, functionNameLen, functionNamePtr, argumentsLen, argumentsPtr, amountPtr, gas uint64) {
+	amount := readUint128(amountPtr)
+	rt.debit(amount)
+	draft.actions = append(draft.actions, Action{
+		Kind:       promise.FunctionCallAction,
+		MethodName: string(readBytes(functionNameLen, functionNamePtr)),
+		Args:       readBytes(argumentsLen, argumentsPtr),
+		Deposit:    amount,
+		Gas:        gas,
+	})
+}
+
+func (rt *Runtime) PromiseCreate(accountIdLen, accountIdPtr, functionNameLen, functionNamePtr, argumentsLen, argumentsPtr, amountPtr, gas uint64) uint64 {
+	index := rt.newPromise(string(readBytes(accountIdLen, accountIdPtr)), -1)
+	rt.addFunctionCall(rt.promises[index], functionNameLen, functionNamePtr, argumentsLen, argumentsPtr, amountPtr, gas)
+	return index
+}
+
+func (rt *Runtime) PromiseThen(promiseIndex, accountIdLen, accountIdPtr, functionNameLen, functionNamePtr, argumentsLen, argumentsPtr, amountPtr, gas uint64) uint64 {
+	rt.promise(promiseIndex)
+	index := rt.newPromise(string(readBytes(accountIdLen, accountIdPtr)), int(promiseIndex))
+	rt.addFunctionCall(rt.promises[index], functionNameLen, functionNamePtr, argumentsLen, argumentsPtr, amountPtr, gas)
+	return index
+}
+
+func (rt *Runtime) PromiseAnd(promiseIdxPtr, promiseIdxCount uint64) uint64 {
+	rt.prohibitedInView("promise_and")
+	data := readBytes(promiseIdxCount*8, promiseIdxPtr)
+
+	var joint []int
+	for i := uint64(0); i < promiseIdxCount; i++ {
+		var index uint64
+		for b := uint64(0); b < 8; b++ {
+			index |= uint64(data[i*8+b]) << (8 * b)
+		}
+		rt.promise(index)
+		joint = append(joint, expandDependencies(rt.promises, int(index))...)
+	}
+
+	rt.promises = append(rt.promises, &promiseDraft{after: -1, joint: joint})
+	return uint64(len(rt.promises) - 1)
+}
+
+func (rt *Runtime) PromiseBatchCreate(accountIdLen, accountIdPtr uint64) uint64 {
+	return rt.newPromise(string(readBytes(accountIdLen, accountIdPtr)), -1)
+}
+
+func (rt *Runtime) PromiseBatchThen(promiseIndex, accountIdLen, accountIdPtr uint64) uint64 {
+	rt.promise(promiseIndex)
+	return rt.newPromise(string(readBytes(accountIdLen, accountIdPtr)), int(promiseIndex))
+}
+
+func (rt *Runtime) PromiseBatchActionCreateAccount(promiseIndex uint64) {
+	draft := rt.batch(promiseIndex)
+	draft.actions = append(draft.actions, Action{Kind: promise.CreateAccountAction})
+}
+
+func (rt *Runtime) PromiseBatchActionDeployContract(promiseIndex, codeLen, codePtr uint64) {
+	draft := rt.batch(promiseIndex)
+	draft.actions = append(draft.actions, Action{Kind: promise.DeployContractAction, Code: readBytes(codeLen, codePtr)})
+}
+
+func (rt *Runtime) PromiseBatchActionFunctionCall(promiseIndex, functionNameLen, functionNamePtr, argumentsLen, argumentsPtr, amountPtr, gas uint64) {
+	rt.addFunctionCall(rt.batch(promiseIndex), functionNameLen, functionNamePtr, argumentsLen, argumentsPtr, amountPtr, gas)
+}
+
+func (rt *Runtime) PromiseBatchActionFunctionCallWeight(promiseIndex, functionNameLen, functionNamePtr, argumentsLen, argumentsPtr, amountPtr, gas, weight uint64) {
+	rt.addFunctionCall(rt.batch(promiseIndex), functionNameLen, functionNamePtr, argumentsLen, argumentsPtr, amountPtr, gas)
+}
+
+func (rt *Runtime) PromiseBatchActionTransfer(promiseIndex, amountPtr uint64) {
+	draft := rt.batch(promiseIndex)
+	amount := readUint128(amountPtr)
+	rt.debit(amount)
+	draft.actions = append(draft.actions, Action{Kind: promise.TransferAction, Deposit: amount})
+}
+
+func (rt *Runtime) PromiseBatchActionStake(promiseIndex, amountPtr, publicKeyLen, publicKeyPtr uint64) {
+	draft := rt.batch(promiseIndex)
+	draft.actions = append(draft.actions, Action{Kind: promise.StakeAction, Deposit: readUint128(amountPtr), PublicKey: readBytes(publicKeyLen, publicKeyPtr)})
+}
+
+func (rt *Runtime) PromiseBatchActionAddKeyWithFullAccess(promiseIndex, publicKeyLen, publicKeyPtr, nonce uint64) {
+	draft := rt.batch(promiseIndex)
+	draft.actions = append(draft.actions, Action{Kind: promise.AddKeyFullAccessAction, PublicKey: readBytes(publicKeyLen, publicKeyPtr), Nonce: nonce})
+}
+
+func (rt *Runtime) PromiseBatchActionAddKeyWithFunctionCall(promiseIndex, publicKeyLen, publicKeyPtr, nonce, allowancePtr, receiverIdLen, receiverIdPtr, functionNamesLen, functionNamesPtr uint64) {
+	draft := rt.batch(promiseIndex)
+
+	var methodNames []string
+	if names := string(readBytes(functionNamesLen, functionNamesPtr)); names != "" {
+		methodNames = strings.Split(names, ",")
+	}
+
+	draft.actions = append(draft.actions, Action{
+		Kind:        promise.AddKeyFunctionCallAction,
+		PublicKey:   readBytes(publicKeyLen, publicKeyPtr),
+		Nonce:       nonce,
+		Allowance:   readUint128(allowancePtr),
+		ReceiverID:  string(readBytes(receiverIdLen, receiverIdPtr)),
+		MethodNames: methodNames,
+	})
+}
+
+func (rt *Runtime) PromiseBatchActionDeleteKey(promiseIndex, publicKeyLen, publicKeyPtr uint64) {
+	draft := rt.batch(promiseIndex)
+	draft.actions = append(draft.actions, Action{Kind: promise.DeleteKeyAction, PublicKey: readBytes(publicKeyLen, publicKeyPtr)})
+}
+
+func (rt *Runtime) PromiseBatchActionDeleteAccount(promiseIndex, beneficiaryIdLen, beneficiaryIdPtr uint64) {
+	draft := rt.batch(promiseIndex)
+	draft.actions = append(draft.actions, Action{Kind: promise.DeleteAccountAction, Beneficiary: string(readBytes(beneficiaryIdLen, beneficiaryIdPtr))})
+}
+
+func (rt *Runtime) PromiseYieldCreate(functionNameLen, functionNamePtr, argumentsLen, argumentsPtr, gas, gasWeight, registerId uint64) uint64 {
+	rt.abort(ErrNotSupported + "promise_yield_create")
+	return 0
+}
+
+func (rt *Runtime) PromiseYieldResume(dataIdLen, dataIdPtr, payloadLen, payloadPtr uint64) uint32 {
+	rt.abort(ErrNotSupported + "promise_yield_resume")
+	return 0
+}
+
+func (rt *Runtime) PromiseResultsCount() uint64 {
+	rt.prohibitedInView("promise_results_count")
+	return uint64(len(rt.results))
+}
+
+func (rt *Runtime) PromiseResult(resultIdx uint64, registerId uint64) uint64 {
+	rt.prohibitedInView("promise_result")
+	if resultIdx >= uint64(len(rt.results)) {
+		rt.abort(ErrInvalidPromiseIndex)
+	}
+
+	result := rt.results[resultIdx]
+	if !result.success {
+		delete(rt.Registers, registerId)
+		return 2
+	}
+	rt.writeRegister(registerId, result.data)
+	return 1
+}
+
+func (rt *Runtime) PromiseReturn(promiseId uint64) {
+	rt.prohibitedInView("promise_return")
+	if rt.promise(promiseId).joint != nil {
+		rt.abort(ErrCannotReturnJointPromise)
+	}
+	rt.returned = int(promiseId)
+}
@@ -0,0 +1,387 @@
+// Package sim provides an in-process simulated blockchain for unit tests.
+//
+// Unlike system.MockSystem, which models a single contract with a single storage map, the simulator keeps
+// many accounts, each with its own storage, balance, access keys and Go contract implementation.
+// Receipts produced through the promise API are routed between accounts in block order, and the results
+// of finished receipts are delivered to the callbacks that depend on them, so cross-contract flows such as
+// `ft_transfer_call` -> `ft_on_transfer` -> `ft_resolve_transfer` can be tested without a network.
+package sim
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/system"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+const (
+	// SystemAccountID is the predecessor of the refund receipts created by the simulator.
+	SystemAccountID = "system"
+
+	// DefaultBlockTime is the time between two simulated blocks in nanoseconds.
+	DefaultBlockTime uint64 = 1_000_000_000
+
+	// DefaultGas is the amount of gas attached to transactions sent with Call.
+	DefaultGas uint64 = 300 * types.ONE_TERA_GAS
+
+	// MaxBlocksPerTransaction bounds the number of blocks a transaction may take to settle.
+	MaxBlocksPerTransaction = 1000
+)
+
+const (
+	ErrAccountAlreadyExists     = "(SIM_ERROR): account already exists: "
+	ErrAccountDoesNotExist      = "(SIM_ERROR): account does not exist: "
+	ErrContractNotDeployed      = "(SIM_ERROR): contract is not deployed on account: "
+	ErrMethodNotFound           = "(SIM_ERROR): method not found: "
+	ErrInsufficientBalance      = "(SIM_ERROR): signer balance can't cover the attached deposit"
+	ErrTransactionDidNotSettle  = "(SIM_ERROR): transaction did not settle within the block limit"
+	ErrProhibitedInView         = "(SIM_ERROR): method is not allowed in view calls: "
+	ErrKeyDoesNotExist          = "(SIM_ERROR): access key does not exist"
+	ErrKeyNotAllowed            = "(SIM_ERROR): the function call access key doesn't allow the call: "
+	ErrCannotReturnJointPromise = "(SIM_ERROR): joint promise can't be returned"
+	ErrInvalidPromiseIndex      = "(SIM_ERROR): invalid promise index"
+	ErrNotSupported             = "(SIM_ERROR): host function is not supported by the simulator: "
+	ErrInvalidEd25519Input      = "(SIM_ERROR): invalid ed25519 signature or public key length"
+	ErrContractPanicPrefix      = "Smart contract panicked: "
+)
+
+// Contract is a contract implementation deployed on a simulated account.
+//
+// Execute runs the exported method with rt as the host environment. The env package is already
+// bound to rt when Execute is called, so Go contracts can use env, collections and promise as usual.
+type Contract interface {
+	Execute(rt *Runtime, method string) error
+}
+
+// Methods is a Contract made of plain Go functions, keyed by the exported method name.
+// Every function plays the role of a `//go:export` entry point of the compiled contract.
+type Methods map[string]func()
+
+func (m Methods) Execute(rt *Runtime, method string) error {
+	fn, ok := m[method]
+	if !ok {
+		return errors.New(ErrMethodNotFound + method)
+	}
+	fn()
+	return nil
+}
+
+// AccessKey is an access key added to a simulated account.
+type AccessKey struct {
+	PublicKey   []byte
+	Nonce       uint64
+	FullAccess  bool
+	Allowance   types.Uint128
+	ReceiverID  string
+	MethodNames []string
+}
+
+// Account is a simulated account with its own storage, balance, keys and contract.
+type Account struct {
+	ID           string
+	Balance      types.Uint128
+	Locked       types.Uint128
+	Storage      map[string][]byte
+	StorageUsage uint64
+	Code         []byte
+	Contract     Contract
+	Keys         map[string]AccessKey
+}
+
+func newAccount(accountID string, balance types.Uint128) *Account {
+	return &Account{
+		ID:      accountID,
+		Balance: balance,
+		Storage: make(map[string][]byte),
+		Keys:    make(map[string]AccessKey),
+	}
+}
+
+func (a *Account) clone() *Account {
+	cloned := *a
+	cloned.Storage = make(map[string][]byte, len(a.Storage))
+	for key, value := range a.Storage {
+		cloned.Storage[key] = value
+	}
+	cloned.Keys = make(map[string]AccessKey, len(a.Keys))
+	for key, value := range a.Keys {
+		cloned.Keys[key] = value
+	}
+	return &cloned
+}
+
+// Chain is the simulated blockchain.
+type Chain struct {
+	Height      uint64
+	Timestamp   uint64
+	EpochHeight uint64
+	BlockTime   uint64
+
+	// CodeLoader builds the implementation of code deployed through a DeployContract action when the code
+	// wasn't registered with RegisterCode. Without a loader such accounts get the code but no implementation.
+	CodeLoader func(code []byte) (Contract, error)
+
+	accounts      map[string]*Account
+	codes         map[string]Contract
+	pending       []*Receipt
+	nextReceiptID uint64
+}
+
+// New creates an empty simulated blockchain at block height 1.
+func New() *Chain {
+	return &Chain{
+		Height:        1,
+		Timestamp:     uint64(1739394085901002712),
+		EpochHeight:   1,
+		BlockTime:     DefaultBlockTime,
+		accounts:      make(map[string]*Account),
+		codes:         make(map[string]Contract),
+		nextReceiptID: 1,
+	}
+}
+
+// NEAR converts an amount of whole NEAR tokens into yoctoNEAR.
+func NEAR(amount uint64) types.Uint128 {
+	oneNear, _ := types.U128FromString("1000000000000000000000000")
+	result, err := oneNear.Mul(types.U64ToUint128(amount))
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// CreateAccount adds a new account with the given balance to the chain.
+func (c *Chain) CreateAccount(accountID string, balance types.Uint128) (*Account, error) {
+	if _, exists := c.accounts[accountID]; exists {
+		return nil, errors.New(ErrAccountAlreadyExists + accountID)
+	}
+	account := newAccount(accountID, balance)
+	c.accounts[accountID] = account
+	return account, nil
+}
+
+// Account returns the current state of the account, or nil if it doesn't exist.
+func (c *Chain) Account(accountID string) *Account {
+	return c.accounts[accountID]
+}
+
+// Deploy binds a Go contract implementation to an existing account.
+func (c *Chain) Deploy(accountID string, contract Contract) error {
+	account, exists := c.accounts[accountID]
+	if !exists {
+		return errors.New(ErrAccountDoesNotExist + accountID)
+	}
+	account.Contract = contract
+	return nil
+}
+
+// RegisterCode associates code bytes with a Go contract implementation.
+// DeployContract actions that carry the same bytes deploy this implementation on the receiver account.
+func (c *Chain) RegisterCode(code []byte, contract Contract) {
+	c.codes[codeKey(code)] = contract
+}
+
+func (c *Chain) contractForCode(code []byte) (Contract, error) {
+	if contract, exists := c.codes[codeKey(code)]; exists {
+		return contract, nil
+	}
+	if c.CodeLoader == nil {
+		return nil, nil
+	}
+
+	contract, err := c.CodeLoader(code)
+	if err != nil {
+		return nil, err
+	}
+	c.RegisterCode(code, contract)
+	return contract, nil
+}
+
+func codeKey(code []byte) string {
+	hash := sha256.Sum256(code)
+	return string(hash[:])
+}
+
+// Call sends a transaction from signer to receiver that calls method with args and the attached deposit,
+// then produces blocks until every receipt spawned by the transaction has been executed.
+//
+// args is used as is when it is a []byte, otherwise it is encoded to JSON.
+func (c *Chain) Call(signerID, receiverID, method string, args interface{}, deposit types.Uint128) (*Result, error) {
+	return c.CallWithGas(signerID, receiverID, method, args, deposit, DefaultGas)
+}
+
+// CallWithGas is Call with an explicit amount of prepaid gas.
+func (c *Chain) CallWithGas(signerID, receiverID, method string, args interface{}, deposit types.Uint128, gas uint64) (*Result, error) {
+	argsBytes, err := encodeArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	action := Action{Kind: promise.FunctionCallAction, MethodName: method, Args: argsBytes, Deposit: deposit, Gas: gas}
+	return c.Transact(signerID, receiverID, []Action{action})
+}
+
+// CallWithKey is Call signed with the access key publicKey of the signer, which the contract reads as the
+// signer public key. A function call key only allows calls without deposit to its receiver and methods.
+func (c *Chain) CallWithKey(signerID string, publicKey []byte, receiverID, method string, args interface{}, deposit types.Uint128) (*Result, error) {
+	signer, exists := c.accounts[signerID]
+	if !exists {
+		return nil, errors.New(ErrAccountDoesNotExist + signerID)
+	}
+	key, exists := signer.Keys[string(publicKey)]
+	if !exists {
+		return nil, errors.New(ErrKeyDoesNotExist)
+	}
+	if !key.FullAccess {
+		if deposit.Cmp(types.Uint128{Hi: 0, Lo: 0}) != 0 {
+			return nil, errors.New(ErrKeyNotAllowed + "deposit")
+		}
+		if key.ReceiverID != receiverID {
+			return nil, errors.New(ErrKeyNotAllowed + receiverID)
+		}
+		if len(key.MethodNames) > 0 && !contains(key.MethodNames, method) {
+			return nil, errors.New(ErrKeyNotAllowed + method)
+		}
+	}
+
+	argsBytes, err := encodeArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	action := Action{Kind: promise.FunctionCallAction, MethodName: method, Args: argsBytes, Deposit: deposit, Gas: DefaultGas}
+	return c.transact(signerID, publicKey, receiverID, []Action{action})
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Transact sends a transaction with an arbitrary list of actions and waits until it settles.
+func (c *Chain) Transact(signerID, receiverID string, actions []Action) (*Result, error) {
+	return c.transact(signerID, nil, receiverID, actions)
+}
+
+// transact sends the transaction signed with signerPk, or with the default key of the signer when nil.
+func (c *Chain) transact(signerID string, signerPk []byte, receiverID string, actions []Action) (*Result, error) {
+	signer, exists := c.accounts[signerID]
+	if !exists {
+		return nil, errors.New(ErrAccountDoesNotExist + signerID)
+	}
+
+	total, err := totalDeposit(actions)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := signer.Balance.Sub(total)
+	if err != nil {
+		return nil, errors.New(ErrInsufficientBalance)
+	}
+	signer.Balance = balance
+
+	result := &Result{}
+	receipt := c.newReceipt(signerID, receiverID, signerID, actions, result)
+	if signerPk != nil {
+		receipt.SignerPk = signerPk
+	}
+	receipt.sink = result
+	c.pending = append(c.pending, receipt)
+
+	for blocks := 0; c.hasPendingFor(result); blocks++ {
+		if blocks >= MaxBlocksPerTransaction {
+			return result, errors.New(ErrTransactionDidNotSettle)
+		}
+		c.ProduceBlock()
+	}
+
+	return result, nil
+}
+
+// View calls a read-only method. State changes, promises and the predecessor context are not available,
+// and nothing the method does is persisted.
+func (c *Chain) View(accountID, method string, args interface{}) (*Result, error) {
+	argsBytes, err := encodeArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	account, exists := c.accounts[accountID]
+	if !exists {
+		return nil, errors.New(ErrAccountDoesNotExist + accountID)
+	}
+
+	receipt := &Receipt{Receiver: accountID}
+	action := Action{Kind: promise.FunctionCallAction, MethodName: method, Args: argsBytes}
+	rt := c.newRuntime(account.clone(), receipt, action, true)
+	outcome := ExecutionOutcome{Receiver: accountID, BlockHeight: c.Height}
+	outcome.Value, outcome.Failure = c.runFunctionCall(rt, account, method)
+	outcome.Logs = rt.logs
+
+	result := &Result{Outcomes: []ExecutionOutcome{outcome}, Value: outcome.Value, Failure: outcome.Failure}
+	return result, nil
+}
+
+// ProduceBlock advances the chain by one block and executes every receipt that is ready at its start.
+// Receipts created or unblocked while executing the block wait for the next one.
+func (c *Chain) ProduceBlock() {
+	c.Height++
+	c.Timestamp += c.BlockTime
+
+	var ready, waiting []*Receipt
+	for _, receipt := range c.pending {
+		if receipt.ready() {
+			ready = append(ready, receipt)
+		} else {
+			waiting = append(waiting, receipt)
+		}
+	}
+	c.pending = waiting
+
+	for _, receipt := range ready {
+		c.executeReceipt(receipt)
+	}
+}
+
+// FastForward produces the given number of blocks.
+func (c *Chain) FastForward(blocks uint64) {
+	for i := uint64(0); i < blocks; i++ {
+		c.ProduceBlock()
+	}
+}
+
+func (c *Chain) hasPendingFor(result *Result) bool {
+	for _, receipt := range c.pending {
+		if receipt.tx == result {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeArgs(args interface{}) ([]byte, error) {
+	switch v := args.(type) {
+	case nil:
+		return []byte{}, nil
+	case []byte:
+		return v, nil
+	default:
+		return json.Marshal(v)
+	}
+}
+
+// bindEnv points the env package at rt and returns a function restoring the previous environment.
+func bindEnv(rt system.System) func() {
+	previous := env.NearBlockchainImports
+	env.SetEnv(rt)
+	return func() {
+		env.SetEnv(previous)
+	}
+}
@@ -0,0 +1,456 @@
+package sim
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/contract"
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+const (
+	tokenID    = "token.near"
+	aliceID    = "alice.near"
+	receiverID = "receiver.near"
+)
+
+type transferCallArgs struct {
+	ReceiverID string `json:"receiver_id"`
+	Amount     string `json:"amount"`
+	Msg        string `json:"msg"`
+}
+
+type onTransferArgs struct {
+	SenderID string `json:"sender_id"`
+	Amount   string `json:"amount"`
+	Msg      string `json:"msg"`
+}
+
+type resolveArgs struct {
+	SenderID   string `json:"sender_id"`
+	ReceiverID string `json:"receiver_id"`
+	Amount     string `json:"amount"`
+}
+
+func readInput(v interface{}) {
+	input, err := contract.GetRawBytesInput()
+	if err != nil {
+		env.PanicStr(err.Error())
+	}
+	if err := json.Unmarshal(input.Data, v); err != nil {
+		env.PanicStr(err.Error())
+	}
+}
+
+func balanceOf(accountID string) uint64 {
+	data, err := env.StorageRead([]byte("b:" + accountID))
+	if err != nil {
+		return 0
+	}
+	balance, _ := strconv.ParseUint(string(data), 10, 64)
+	return balance
+}
+
+func setBalance(accountID string, balance uint64) {
+	env.StorageWrite([]byte("b:"+accountID), []byte(strconv.FormatUint(balance, 10)))
+}
+
+func moveTokens(from, to string, amount uint64) {
+	if balanceOf(from) < amount {
+		env.PanicStr("not enough balance")
+	}
+	setBalance(from, balanceOf(from)-amount)
+	setBalance(to, balanceOf(to)+amount)
+}
+
+// tokenContract is a minimal fungible token that follows the ft_transfer_call flow of NEP-141.
+func tokenContract() Methods {
+	return Methods{
+		"mint": func() {
+			var args struct {
+				AccountID string `json:"account_id"`
+				Amount    uint64 `json:"amount"`
+			}
+			readInput(&args)
+			setBalance(args.AccountID, balanceOf(args.AccountID)+args.Amount)
+		},
+		"ft_balance_of": func() {
+			var args struct {
+				AccountID string `json:"account_id"`
+			}
+			readInput(&args)
+			contract.ReturnValue(strconv.FormatUint(balanceOf(args.AccountID), 10))
+		},
+		"ft_transfer_call": func() {
+			var args transferCallArgs
+			readInput(&args)
+			sender, _ := env.GetPredecessorAccountID()
+			amount, _ := strconv.ParseUint(args.Amount, 10, 64)
+			moveTokens(sender, args.ReceiverID, amount)
+
+			promise.NewCrossContract(args.ReceiverID).
+				Call("ft_on_transfer", onTransferArgs{SenderID: sender, Amount: args.Amount, Msg: args.Msg}).
+				Then("ft_resolve_transfer", resolveArgs{SenderID: sender, ReceiverID: args.ReceiverID, Amount: args.Amount}).
+				Value()
+		},
+		"ft_resolve_transfer": func() {
+			var args resolveArgs
+			readInput(&args)
+			amount, _ := strconv.ParseUint(args.Amount, 10, 64)
+
+			contract.HandlePromiseResult(func(result *promise.PromiseResult) error {
+				unused := amount
+				if result.Success {
+					var value string
+					if err := json.Unmarshal(result.Data, &value); err == nil {
+						unused, _ = strconv.ParseUint(value, 10, 64)
+					}
+				}
+				if unused > amount {
+					unused = amount
+				}
+				if unused > 0 {
+					moveTokens(args.ReceiverID, args.SenderID, unused)
+					env.LogString("refund " + strconv.FormatUint(unused, 10))
+				}
+				return contract.ReturnValue(strconv.FormatUint(amount-unused, 10))
+			})
+		},
+	}
+}
+
+// receiverContract keeps the amount given in msg and returns the rest as unused, or panics when msg is "fail".
+func receiverContract() Methods {
+	return Methods{
+		"ft_on_transfer": func() {
+			var args onTransferArgs
+			readInput(&args)
+			if args.Msg == "fail" {
+				env.PanicStr("receiver failed")
+			}
+			amount, _ := strconv.ParseUint(args.Amount, 10, 64)
+			keep, _ := strconv.ParseUint(args.Msg, 10, 64)
+			contract.ReturnValue(strconv.FormatUint(amount-keep, 10))
+		},
+		"deposit": func() {
+			setBalance("deposits", balanceOf("deposits")+1)
+		},
+		"transfer_back": func() {
+			predecessor, _ := env.GetPredecessorAccountID()
+			promise.CreateBatch(predecessor).Transfer(NEAR(1))
+		},
+	}
+}
+
+func setupChain(t *testing.T) *Chain {
+	t.Helper()
+	contracts := map[string]Contract{tokenID: tokenContract(), receiverID: receiverContract()}
+	chain := Setup(t, NEAR(100), contracts, tokenID, aliceID, receiverID)
+
+	result, err := chain.Call(aliceID, tokenID, "mint", map[string]interface{}{"account_id": aliceID, "amount": 1000}, types.Uint128{})
+	if err != nil || result.Failed() {
+		t.Fatalf("failed to mint: %v %v", err, result.Failure)
+	}
+	return chain
+}
+
+func viewBalance(t *testing.T, chain *Chain, accountID string) string {
+	t.Helper()
+	result, err := chain.View(tokenID, "ft_balance_of", map[string]string{"account_id": accountID})
+	if err != nil {
+		t.Fatalf("view failed: %v", err)
+	}
+	var balance string
+	if err := result.Unmarshal(&balance); err != nil {
+		t.Fatalf("failed to decode balance: %v", err)
+	}
+	return balance
+}
+
+func TestTransferCallResolvesRefund(t *testing.T) {
+	chain := setupChain(t)
+
+	result, err := chain.Call(aliceID, tokenID, "ft_transfer_call", transferCallArgs{ReceiverID: receiverID, Amount: "100", Msg: "30"}, types.Uint128{Hi: 0, Lo: 1})
+	if err != nil {
+		t.Fatalf("transaction failed: %v", err)
+	}
+
+	var used string
+	if err := result.Unmarshal(&used); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if used != "30" {
+		t.Errorf("expected used amount 30, got %s", used)
+	}
+
+	if len(result.Outcomes) != 3 {
+		t.Fatalf("expected 3 receipt outcomes, got %d", len(result.Outcomes))
+	}
+	if result.Outcomes[1].Receiver != receiverID || result.Outcomes[1].Predecessor != tokenID {
+		t.Errorf("unexpected ft_on_transfer outcome: %+v", result.Outcomes[1])
+	}
+	for i := 1; i < len(result.Outcomes); i++ {
+		if result.Outcomes[i].BlockHeight <= result.Outcomes[i-1].BlockHeight {
+			t.Errorf("receipt %d executed in block %d, not after block %d", i, result.Outcomes[i].BlockHeight, result.Outcomes[i-1].BlockHeight)
+		}
+	}
+
+	if logs := result.Logs(); len(logs) != 1 || logs[0] != "refund 70" {
+		t.Errorf("unexpected logs: %v", logs)
+	}
+	if balance := viewBalance(t, chain, aliceID); balance != "970" {
+		t.Errorf("expected sender balance 970, got %s", balance)
+	}
+	if balance := viewBalance(t, chain, receiverID); balance != "30" {
+		t.Errorf("expected receiver balance 30, got %s", balance)
+	}
+}
+
+func TestTransferCallReceiverFailure(t *testing.T) {
+	chain := setupChain(t)
+
+	result, err := chain.Call(aliceID, tokenID, "ft_transfer_call", transferCallArgs{ReceiverID: receiverID, Amount: "100", Msg: "fail"}, types.Uint128{Hi: 0, Lo: 1})
+	if err != nil {
+		t.Fatalf("transaction failed: %v", err)
+	}
+	if result.Failed() {
+		t.Fatalf("expected the callback to recover, got %v", result.Failure)
+	}
+
+	failures := result.ReceiptFailures()
+	if len(failures) != 1 || !strings.Contains(failures[0].Error(), "receiver failed") {
+		t.Errorf("expected the receiver failure to be recorded, got %v", failures)
+	}
+	if balance := viewBalance(t, chain, aliceID); balance != "1000" {
+		t.Errorf("expected full refund, got %s", balance)
+	}
+}
+
+// relayContract answers ft_on_transfer with the promise of the same call on receiver.near.
+func relayContract() Methods {
+	return Methods{
+		"ft_on_transfer": func() {
+			var args onTransferArgs
+			readInput(&args)
+			promise.NewCrossContract(receiverID).Call("ft_on_transfer", args).Value()
+		},
+	}
+}
+
+func TestTransferCallThroughReturnedPromise(t *testing.T) {
+	chain := setupChain(t)
+	relayID := "relay.near"
+	if _, err := chain.CreateAccount(relayID, NEAR(100)); err != nil {
+		t.Fatalf("failed to create %s: %v", relayID, err)
+	}
+	if err := chain.Deploy(relayID, relayContract()); err != nil {
+		t.Fatalf("failed to deploy the relay: %v", err)
+	}
+
+	result, err := chain.Call(aliceID, tokenID, "ft_transfer_call", transferCallArgs{ReceiverID: relayID, Amount: "100", Msg: "30"}, types.Uint128{Hi: 0, Lo: 1})
+	if err != nil || result.Failed() || len(result.ReceiptFailures()) != 0 {
+		t.Fatalf("transaction failed: %v %v %v", err, result.Failure, result.ReceiptFailures())
+	}
+	var used string
+	if err := result.Unmarshal(&used); err != nil || used != "30" {
+		t.Errorf("expected the callback to get the result of the returned promise, got %s: %v", used, err)
+	}
+	if balance := viewBalance(t, chain, aliceID); balance != "970" {
+		t.Errorf("expected sender balance 970, got %s", balance)
+	}
+}
+
+func TestFailedReceiptRevertsStateAndRefundsDeposit(t *testing.T) {
+	chain := setupChain(t)
+	before := chain.Account(aliceID).Balance
+
+	result, err := chain.Call(aliceID, tokenID, "ft_transfer_call", transferCallArgs{ReceiverID: receiverID, Amount: "5000"}, NEAR(1))
+	if err != nil {
+		t.Fatalf("transaction failed: %v", err)
+	}
+	if !result.Failed() || !strings.HasPrefix(result.Failure.Error(), ErrContractPanicPrefix) {
+		t.Fatalf("expected contract panic, got %v", result.Failure)
+	}
+
+	if balance := chain.Account(aliceID).Balance; balance.Cmp(before) != 0 {
+		t.Errorf("expected deposit to be refunded, balance %s, want %s", balance.String(), before.String())
+	}
+	if balance := viewBalance(t, chain, aliceID); balance != "1000" {
+		t.Errorf("expected state to be reverted, got %s", balance)
+	}
+}
+
+func TestTransfersBetweenAccounts(t *testing.T) {
+	chain := setupChain(t)
+
+	result, err := chain.Call(aliceID, receiverID, "transfer_back", nil, NEAR(2))
+	if err != nil || result.Failed() {
+		t.Fatalf("transaction failed: %v %v", err, result.Failure)
+	}
+
+	if balance := chain.Account(aliceID).Balance; balance.Cmp(NEAR(99)) != 0 {
+		t.Errorf("expected alice balance 99 NEAR, got %s", balance.String())
+	}
+	if balance := chain.Account(receiverID).Balance; balance.Cmp(NEAR(101)) != 0 {
+		t.Errorf("expected receiver balance 101 NEAR, got %s", balance.String())
+	}
+}
+
+func TestInsufficientBalance(t *testing.T) {
+	chain := setupChain(t)
+
+	if _, err := chain.Call(aliceID, receiverID, "deposit", nil, NEAR(1000)); err == nil || err.Error() != ErrInsufficientBalance {
+		t.Errorf("expected %q, got %v", ErrInsufficientBalance, err)
+	}
+}
+
+func TestMethodNotFound(t *testing.T) {
+	chain := setupChain(t)
+
+	result, err := chain.Call(aliceID, receiverID, "missing", nil, types.Uint128{})
+	if err != nil {
+		t.Fatalf("transaction failed: %v", err)
+	}
+	if !result.Failed() || result.Failure.Error() != ErrMethodNotFound+"missing" {
+		t.Errorf("expected method not found, got %v", result.Failure)
+	}
+}
+
+func TestViewProhibitsStateChanges(t *testing.T) {
+	chain := setupChain(t)
+
+	result, err := chain.View(receiverID, "deposit", nil)
+	if err != nil {
+		t.Fatalf("view failed: %v", err)
+	}
+	if !result.Failed() || !strings.Contains(result.Failure.Error(), ErrProhibitedInView) {
+		t.Errorf("expected view to reject storage writes, got %v", result.Failure)
+	}
+	if _, exists := chain.Account(receiverID).Storage["b:deposits"]; exists {
+		t.Errorf("view call must not persist state")
+	}
+}
+
+func TestStorageStakingFailure(t *testing.T) {
+	chain := New()
+	chain.CreateAccount(aliceID, NEAR(10))
+	chain.CreateAccount(receiverID, types.Uint128{})
+	chain.Deploy(receiverID, receiverContract())
+
+	result, err := chain.Call(aliceID, receiverID, "deposit", nil, types.Uint128{})
+	if err != nil {
+		t.Fatalf("transaction failed: %v", err)
+	}
+	if !result.Failed() || !strings.Contains(result.Failure.Error(), "BALANCE_ERROR") {
+		t.Errorf("expected storage staking failure, got %v", result.Failure)
+	}
+}
+
+func TestFastForward(t *testing.T) {
+	chain := New()
+	height, timestamp := chain.Height, chain.Timestamp
+
+	chain.FastForward(10)
+
+	if chain.Height != height+10 {
+		t.Errorf("expected height %d, got %d", height+10, chain.Height)
+	}
+	if chain.Timestamp != timestamp+10*DefaultBlockTime {
+		t.Errorf("expected timestamp %d, got %d", timestamp+10*DefaultBlockTime, chain.Timestamp)
+	}
+}
+
+func TestCallWithKey(t *testing.T) {
+	chain := New()
+	chain.CreateAccount(aliceID, NEAR(10))
+	chain.CreateAccount(receiverID, NEAR(10))
+	chain.Deploy(receiverID, Methods{
+		"whoami": func() {
+			pk, _ := env.GetSignerAccountPK()
+			contract.ReturnValue(pk)
+		},
+	})
+
+	fullKey := []byte{byte(types.ED25519), 1}
+	callKey := []byte{byte(types.ED25519), 2}
+	alice := chain.Account(aliceID)
+	alice.Keys[string(fullKey)] = AccessKey{PublicKey: fullKey, FullAccess: true}
+	alice.Keys[string(callKey)] = AccessKey{PublicKey: callKey, ReceiverID: receiverID, MethodNames: []string{"whoami"}}
+
+	result, err := chain.CallWithKey(aliceID, callKey, receiverID, "whoami", nil, types.Uint128{})
+	if err != nil || result.Failed() || string(result.Value) != string(callKey) {
+		t.Errorf("expected the call to be signed with the function call key, got %v %v %v", result, err, result.Value)
+	}
+	if _, err := chain.CallWithKey(aliceID, callKey, receiverID, "other", nil, types.Uint128{}); err == nil || err.Error() != ErrKeyNotAllowed+"other" {
+		t.Errorf("expected the method to be rejected, got %v", err)
+	}
+	if _, err := chain.CallWithKey(aliceID, callKey, aliceID, "whoami", nil, types.Uint128{}); err == nil || err.Error() != ErrKeyNotAllowed+aliceID {
+		t.Errorf("expected the receiver to be rejected, got %v", err)
+	}
+	if _, err := chain.CallWithKey(aliceID, callKey, receiverID, "whoami", nil, NEAR(1)); err == nil || err.Error() != ErrKeyNotAllowed+"deposit" {
+		t.Errorf("expected the deposit to be rejected, got %v", err)
+	}
+	if _, err := chain.CallWithKey(aliceID, []byte{9}, receiverID, "whoami", nil, types.Uint128{}); err == nil || err.Error() != ErrKeyDoesNotExist {
+		t.Errorf("expected an unknown key to be rejected, got %v", err)
+	}
+	if result, err := chain.CallWithKey(aliceID, fullKey, receiverID, "whoami", nil, NEAR(1)); err != nil || result.Failed() {
+		t.Errorf("expected a full access key to allow anything, got %v %v", err, result.Failure)
+	}
+}
+
+type counter struct {
+	Count int `json:"count"`
+}
+
+func counterContract() Methods {
+	return Methods{
+		"init": Init(func(c *counter) error {
+			var args counter
+			Input(&args)
+			c.Count = args.Count
+			return nil
+		}),
+		"add": Method(func(c *counter) (interface{}, error) {
+			c.Count++
+			return c.Count, nil
+		}),
+		"get": View(func(c *counter) (interface{}, error) {
+			return c.Count, nil
+		}),
+	}
+}
+
+func TestEntryPoints(t *testing.T) {
+	chain := Setup(t, NEAR(10), map[string]Contract{aliceID: counterContract()}, aliceID)
+
+	result, err := chain.Call(aliceID, aliceID, "add", nil, types.Uint128{})
+	if err != nil || result.Failure == nil || result.Failure.Error() != ErrContractPanicPrefix+ErrStateNotInitialized {
+		t.Fatalf("expected a call before init to panic, got %v %v", err, result.Failure)
+	}
+	view, err := chain.View(aliceID, "get", nil)
+	if err != nil || view.Failure == nil || view.Failure.Error() != ErrContractPanicPrefix+ErrStateNotInitialized {
+		t.Fatalf("expected a view before init to panic, got %v %v", err, view.Failure)
+	}
+
+	if result, err := chain.Call(aliceID, aliceID, "init", counter{Count: 5}, types.Uint128{}); err != nil || result.Failed() {
+		t.Fatalf("init failed: %v %v", err, result.Failure)
+	}
+	result, _ = chain.Call(aliceID, aliceID, "init", nil, types.Uint128{})
+	if result.Failure == nil || result.Failure.Error() != ErrContractPanicPrefix+ErrStateAlreadyInitialized {
+		t.Errorf("expected a second init to panic, got %v", result.Failure)
+	}
+
+	var count int
+	result, err = chain.Call(aliceID, aliceID, "add", nil, types.Uint128{})
+	if err != nil || result.Failed() || result.Unmarshal(&count) != nil || count != 6 {
+		t.Errorf("expected add to return 6, got %d: %v %v", count, err, result.Failure)
+	}
+	view, err = chain.View(aliceID, "get", nil)
+	if err != nil || view.Unmarshal(&count) != nil || count != 6 {
+		t.Errorf("expected the count to be saved, got %d: %v", count, err)
+	}
+}
@@ -0,0 +1,54 @@
+package sim
+
+import (
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+// Setup creates a chain with accountIDs, each holding balance, and deploys contracts on the accounts they
+// are keyed by. It fails t when an account can't be created or a contract deployed.
+func Setup(t testing.TB, balance types.Uint128, contracts map[string]Contract, accountIDs ...string) *Chain {
+	t.Helper()
+	chain := New()
+	for _, accountID := range accountIDs {
+		if _, err := chain.CreateAccount(accountID, balance); err != nil {
+			t.Fatalf("failed to create %s: %v", accountID, err)
+		}
+	}
+	for accountID, contract := range contracts {
+		if err := chain.Deploy(accountID, contract); err != nil {
+			t.Fatalf("failed to deploy the contract of %s: %v", accountID, err)
+		}
+	}
+	return chain
+}
+
+// MustCall calls method on receiverID from signerID without a deposit. It fails t when the transaction
+// can't be sent; failures of the call itself are left in the result.
+func (c *Chain) MustCall(t testing.TB, signerID, receiverID, method string, args interface{}) *Result {
+	t.Helper()
+	result, err := c.Call(signerID, receiverID, method, args, types.Uint128{})
+	if err != nil {
+		t.Fatalf("%s failed: %v", method, err)
+	}
+	return result
+}
+
+// NewKey returns an ED25519 public key made of seed followed by zeros, for tests needing distinct keys.
+func NewKey(seed byte) types.PublicKey {
+	data := make([]byte, 32)
+	data[0] = seed
+	return types.PublicKey{Curve: types.ED25519, Data: data}
+}
+
+// ParseKey parses a public key argument inside a contract method, panicking when it's invalid.
+func ParseKey(s string) types.PublicKey {
+	publicKey, err := types.PublicKeyFromString(s)
+	if err != nil {
+		env.PanicStr(err.Error())
+		return types.PublicKey{}
+	}
+	return *publicKey
+}
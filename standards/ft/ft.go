@@ -0,0 +1,386 @@
+// Package ft implements the core of the NEP-141 fungible token standard.
+//
+// FungibleToken keeps the balances in a LookupMap and implements ft_transfer, ft_transfer_call,
+// ft_resolve_transfer, ft_total_supply and ft_balance_of. A contract embeds it in its state and exposes the
+// methods through its own annotated wrappers:
+//
+//	// @contract:state
+//	type Contract struct {
+//		Token *ft.FungibleToken
+//	}
+//
+//	// @contract:payable min_deposit=0.000000000000000000000001NEAR
+//	func (c *Contract) FtTransfer(receiverId string, amount string, memo string) {
+//		value, _ := types.U128FromString(amount)
+//		if err := c.Token.FtTransfer(receiverId, value, memo); err != nil {
+//			env.PanicStr(err.Error())
+//		}
+//	}
+//
+// Holders register through NEP-145 storage management: NewStorageManagement creates a storage.Management
+// priced for one balance, and the token is the storage.Registrar passed to its storage_deposit and
+// storage_unregister.
+//
+// Balance changes emit the NEP-297 ft_mint, ft_transfer and ft_burn events of NEP-141.
+package ft
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+
+	"github.com/vlmoon99/near-sdk-go/collections"
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/events"
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/standards/storage"
+	"github.com/vlmoon99/near-sdk-go/system"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+const (
+	// GasForResolveTransfer is the gas reserved for the ft_resolve_transfer callback.
+	GasForResolveTransfer = 5 * types.ONE_TERA_GAS
+
+	// GasForFtTransferCall is the gas ft_transfer_call keeps for itself and the callback;
+	// the rest of the prepaid gas is attached to ft_on_transfer.
+	GasForFtTransferCall = 25*types.ONE_TERA_GAS + GasForResolveTransfer
+)
+
+const (
+	// OnTransferMethod is the receiver method called by ft_transfer_call.
+	OnTransferMethod = "ft_on_transfer"
+
+	// ResolveTransferMethod is the callback ft_transfer_call schedules on the token contract.
+	ResolveTransferMethod = "ft_resolve_transfer"
+)
+
+const (
+	ErrRequiresOneYocto     = "(FT_ERROR): requires attached deposit of exactly 1 yoctoNEAR"
+	ErrAccountNotRegistered = "(FT_ERROR): the account is not registered: "
+	ErrAccountRegistered    = "(FT_ERROR): the account is already registered: "
+	ErrSelfTransfer         = "(FT_ERROR): sender and receiver should be different"
+	ErrZeroAmount           = "(FT_ERROR): the amount should be a positive number"
+	ErrInsufficientBalance  = "(FT_ERROR): the account doesn't have enough balance"
+	ErrTotalSupplyOverflow  = "(FT_ERROR): total supply overflow"
+	ErrTotalSupplyUnderflow = "(FT_ERROR): total supply underflow"
+	ErrBalanceOverflow      = "(FT_ERROR): balance overflow"
+	ErrNotEnoughGas         = "(FT_ERROR): more gas is required for ft_transfer_call"
+	ErrNonZeroBalance       = "(FT_ERROR): can't unregister the account with a positive balance without force"
+	ErrPredecessorUnknown   = "(FT_ERROR): failed to get the predecessor account: "
+)
+
+var zero = types.Uint128{Hi: 0, Lo: 0}
+
+// FungibleToken is the NEP-141 state of a token: registered balances and the total supply.
+type FungibleToken struct {
+	Accounts    *collections.LookupMap[string, types.Uint128] `json:"accounts"`
+	TotalSupply types.Uint128                                 `json:"total_supply"`
+}
+
+// New creates an empty token whose balances are stored under prefix.
+func New(prefix string) *FungibleToken {
+	return &FungibleToken{
+		Accounts:    collections.NewLookupMap[string, types.Uint128](prefix),
+		TotalSupply: zero,
+	}
+}
+
+// AccountStorageUsage returns the worst case number of bytes a registered account occupies:
+// the longest account ID holding the largest balance.
+func (ft *FungibleToken) AccountStorageUsage() uint64 {
+	maxBalance, _ := json.Marshal(types.Uint128{Hi: math.MaxUint64, Lo: math.MaxUint64})
+	return uint64(len(ft.Accounts.Prefix)+1+storage.MaxAccountIDLength+len(maxBalance)) + system.StorageNumExtraBytesRecord
+}
+
+// NewStorageManagement creates the NEP-145 bookkeeping of the token under prefix. Registration costs the
+// storage of a balance and of its storage record; anything deposited above that is refunded, as holders
+// never need more storage.
+func (ft *FungibleToken) NewStorageManagement(prefix string) *storage.Management {
+	management := storage.New(prefix, zero)
+	minBalance, err := storage.CostOf(ft.AccountStorageUsage() + management.RecordStorageUsage())
+	if err != nil {
+		env.PanicStr(err.Error())
+	}
+	return storage.NewFixed(prefix, minBalance)
+}
+
+// OnStorageRegister implements storage.Registrar: storage_deposit registers the token holder.
+func (ft *FungibleToken) OnStorageRegister(accountID string) error {
+	return ft.InternalRegisterAccount(accountID)
+}
+
+// OnStorageUnregister implements storage.Registrar: storage_unregister needs a zero balance, unless force
+// burns the remaining tokens.
+func (ft *FungibleToken) OnStorageUnregister(accountID string, force bool) error {
+	_, err := ft.InternalUnregisterAccount(accountID, force)
+	return err
+}
+
+// TransferCallArgs are the arguments ft_transfer_call passes to ft_on_transfer.
+type TransferCallArgs struct {
+	SenderID string `json:"sender_id"`
+	Amount   string `json:"amount"`
+	Msg      string `json:"msg"`
+}
+
+// ResolveTransferArgs are the arguments of the ft_resolve_transfer callback.
+type ResolveTransferArgs struct {
+	SenderID   string `json:"sender_id"`
+	ReceiverID string `json:"receiver_id"`
+	Amount     string `json:"amount"`
+}
+
+// IsRegistered reports whether the account has a balance entry.
+func (ft *FungibleToken) IsRegistered(accountID string) bool {
+	exists, err := ft.Accounts.Contains(accountID)
+	return err == nil && exists
+}
+
+// InternalRegisterAccount creates a zero balance for the account.
+func (ft *FungibleToken) InternalRegisterAccount(accountID string) error {
+	if ft.IsRegistered(accountID) {
+		return errors.New(ErrAccountRegistered + accountID)
+	}
+	return ft.Accounts.Insert(accountID, zero)
+}
+
+// InternalUnregisterAccount removes the account and returns its balance. A positive balance is only removed
+// with force, in which case it is burned.
+func (ft *FungibleToken) InternalUnregisterAccount(accountID string, force bool) (types.Uint128, error) {
+	balance, err := ft.InternalUnwrapBalanceOf(accountID)
+	if err != nil {
+		return zero, err
+	}
+	if balance.Cmp(zero) > 0 {
+		if !force {
+			return zero, errors.New(ErrNonZeroBalance)
+		}
+		if ft.TotalSupply, err = ft.TotalSupply.Sub(balance); err != nil {
+			return zero, errors.New(ErrTotalSupplyUnderflow)
+		}
+		events.FtBurnEvent.Emit(events.FtBurn{OwnerID: accountID, Amount: balance.String(), Memo: "force unregister"})
+	}
+	return balance, ft.Accounts.Remove(accountID)
+}
+
+// InternalUnwrapBalanceOf returns the balance of a registered account.
+func (ft *FungibleToken) InternalUnwrapBalanceOf(accountID string) (types.Uint128, error) {
+	balance, err := ft.Accounts.Get(accountID)
+	if err != nil {
+		return zero, errors.New(ErrAccountNotRegistered + accountID)
+	}
+	return balance, nil
+}
+
+// InternalDeposit credits amount to a registered account and increases the total supply.
+func (ft *FungibleToken) InternalDeposit(accountID string, amount types.Uint128) error {
+	balance, err := ft.InternalUnwrapBalanceOf(accountID)
+	if err != nil {
+		return err
+	}
+	balance, err = balance.Add(amount)
+	if err != nil {
+		return errors.New(ErrBalanceOverflow)
+	}
+	totalSupply, err := ft.TotalSupply.Add(amount)
+	if err != nil {
+		return errors.New(ErrTotalSupplyOverflow)
+	}
+
+	ft.TotalSupply = totalSupply
+	return ft.Accounts.Insert(accountID, balance)
+}
+
+// InternalWithdraw debits amount from a registered account and decreases the total supply.
+func (ft *FungibleToken) InternalWithdraw(accountID string, amount types.Uint128) error {
+	balance, err := ft.InternalUnwrapBalanceOf(accountID)
+	if err != nil {
+		return err
+	}
+	balance, err = balance.Sub(amount)
+	if err != nil {
+		return errors.New(ErrInsufficientBalance)
+	}
+	totalSupply, err := ft.TotalSupply.Sub(amount)
+	if err != nil {
+		return errors.New(ErrTotalSupplyUnderflow)
+	}
+
+	ft.TotalSupply = totalSupply
+	return ft.Accounts.Insert(accountID, balance)
+}
+
+// InternalTransfer moves amount between two registered accounts and emits ft_transfer.
+func (ft *FungibleToken) InternalTransfer(senderID, receiverID string, amount types.Uint128, memo string) error {
+	if senderID == receiverID {
+		return errors.New(ErrSelfTransfer)
+	}
+	if amount.Cmp(zero) == 0 {
+		return errors.New(ErrZeroAmount)
+	}
+
+	senderBalance, err := ft.InternalUnwrapBalanceOf(senderID)
+	if err != nil {
+		return err
+	}
+	receiverBalance, err := ft.InternalUnwrapBalanceOf(receiverID)
+	if err != nil {
+		return err
+	}
+	if senderBalance, err = senderBalance.Sub(amount); err != nil {
+		return errors.New(ErrInsufficientBalance)
+	}
+	if receiverBalance, err = receiverBalance.Add(amount); err != nil {
+		return errors.New(ErrBalanceOverflow)
+	}
+
+	if err := ft.Accounts.Insert(senderID, senderBalance); err != nil {
+		return err
+	}
+	if err := ft.Accounts.Insert(receiverID, receiverBalance); err != nil {
+		return err
+	}
+
+	events.FtTransferEvent.Emit(events.FtTransfer{OldOwnerID: senderID, NewOwnerID: receiverID, Amount: amount.String(), Memo: memo})
+	return nil
+}
+
+// Mint creates amount new tokens on a registered account and emits ft_mint.
+func (ft *FungibleToken) Mint(accountID string, amount types.Uint128, memo string) error {
+	if err := ft.InternalDeposit(accountID, amount); err != nil {
+		return err
+	}
+	events.FtMintEvent.Emit(events.FtMint{OwnerID: accountID, Amount: amount.String(), Memo: memo})
+	return nil
+}
+
+// Burn destroys amount tokens of a registered account and emits ft_burn.
+func (ft *FungibleToken) Burn(accountID string, amount types.Uint128, memo string) error {
+	if err := ft.InternalWithdraw(accountID, amount); err != nil {
+		return err
+	}
+	events.FtBurnEvent.Emit(events.FtBurn{OwnerID: accountID, Amount: amount.String(), Memo: memo})
+	return nil
+}
+
+// FtTransfer implements ft_transfer: the predecessor sends amount to the receiver.
+// Exactly one yoctoNEAR must be attached.
+func (ft *FungibleToken) FtTransfer(receiverID string, amount types.Uint128, memo string) error {
+	if err := assertOneYocto(); err != nil {
+		return err
+	}
+	senderID, err := env.GetPredecessorAccountID()
+	if err != nil {
+		return errors.New(ErrPredecessorUnknown + err.Error())
+	}
+	return ft.InternalTransfer(senderID, receiverID, amount, memo)
+}
+
+// FtTransferCall implements ft_transfer_call: it transfers amount to the receiver, calls ft_on_transfer on it
+// and schedules ft_resolve_transfer on this contract. Exactly one yoctoNEAR must be attached.
+//
+// The wrapper returns the resulting promise with Value, so the caller gets the amount used by the receiver.
+func (ft *FungibleToken) FtTransferCall(receiverID string, amount types.Uint128, memo, msg string) (*promise.Promise, error) {
+	if err := assertOneYocto(); err != nil {
+		return nil, err
+	}
+	prepaidGas := env.GetPrepaidGas().Inner
+	if prepaidGas <= GasForFtTransferCall {
+		return nil, errors.New(ErrNotEnoughGas)
+	}
+	senderID, err := env.GetPredecessorAccountID()
+	if err != nil {
+		return nil, errors.New(ErrPredecessorUnknown + err.Error())
+	}
+	if err := ft.InternalTransfer(senderID, receiverID, amount, memo); err != nil {
+		return nil, err
+	}
+
+	return promise.NewCrossContract(receiverID).
+		Gas(prepaidGas-GasForFtTransferCall).
+		Call(OnTransferMethod, TransferCallArgs{SenderID: senderID, Amount: amount.String(), Msg: msg}).
+		Gas(GasForResolveTransfer).
+		Then(ResolveTransferMethod, ResolveTransferArgs{SenderID: senderID, ReceiverID: receiverID, Amount: amount.String()}), nil
+}
+
+// FtResolveTransfer implements ft_resolve_transfer. The result is the outcome of ft_on_transfer, which returns
+// the amount the receiver didn't use. That amount, capped by the receiver balance, is refunded to the sender,
+// or burned when the sender unregistered in the meantime. A failed ft_on_transfer refunds everything.
+//
+// It returns the amount that ended up on the receiver. The wrapper must be a private promise callback.
+func (ft *FungibleToken) FtResolveTransfer(senderID, receiverID string, amount types.Uint128, result promise.PromiseResult) (types.Uint128, error) {
+	unused := amount
+	if result.Success {
+		if value, err := parseJSONAmount(result.Data); err == nil && value.Cmp(amount) < 0 {
+			unused = value
+		}
+	}
+	if unused.Cmp(zero) == 0 {
+		return amount, nil
+	}
+
+	receiverBalance, err := ft.InternalUnwrapBalanceOf(receiverID)
+	if err != nil || receiverBalance.Cmp(zero) == 0 {
+		return amount, nil
+	}
+	refund := unused
+	if receiverBalance.Cmp(refund) < 0 {
+		refund = receiverBalance
+	}
+	receiverBalance, _ = receiverBalance.Sub(refund)
+	if err := ft.Accounts.Insert(receiverID, receiverBalance); err != nil {
+		return zero, err
+	}
+	used, _ := amount.Sub(refund)
+
+	if senderBalance, err := ft.InternalUnwrapBalanceOf(senderID); err == nil {
+		if senderBalance, err = senderBalance.Add(refund); err != nil {
+			return zero, errors.New(ErrBalanceOverflow)
+		}
+		if err := ft.Accounts.Insert(senderID, senderBalance); err != nil {
+			return zero, err
+		}
+		events.FtTransferEvent.Emit(events.FtTransfer{OldOwnerID: receiverID, NewOwnerID: senderID, Amount: refund.String(), Memo: "refund"})
+		return used, nil
+	}
+
+	// The sender unregistered after the transfer, so the refund has no owner left.
+	if ft.TotalSupply, err = ft.TotalSupply.Sub(refund); err != nil {
+		return zero, errors.New(ErrTotalSupplyUnderflow)
+	}
+	events.FtBurnEvent.Emit(events.FtBurn{OwnerID: receiverID, Amount: refund.String(), Memo: "refund"})
+	return amount, nil
+}
+
+// FtTotalSupply implements ft_total_supply, returning the decimal string NEP-141 expects.
+func (ft *FungibleToken) FtTotalSupply() string {
+	return ft.TotalSupply.String()
+}
+
+// FtBalanceOf implements ft_balance_of, returning the decimal string NEP-141 expects; unregistered accounts
+// have a zero balance.
+func (ft *FungibleToken) FtBalanceOf(accountID string) string {
+	balance, err := ft.Accounts.Get(accountID)
+	if err != nil {
+		return zero.String()
+	}
+	return balance.String()
+}
+
+func assertOneYocto() error {
+	deposit, err := env.GetAttachedDeposit()
+	if err != nil || deposit.Cmp(types.Uint128{Hi: 0, Lo: 1}) != 0 {
+		return errors.New(ErrRequiresOneYocto)
+	}
+	return nil
+}
+
+// parseJSONAmount decodes a NEP-141 amount, a decimal number in a JSON string.
+func parseJSONAmount(data []byte) (types.Uint128, error) {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return zero, err
+	}
+	return types.U128FromString(value)
+}
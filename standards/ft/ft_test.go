@@ -0,0 +1,255 @@
+package ft
+
+import (
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/events"
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/testutils"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+var oneYocto = types.Uint128{Hi: 0, Lo: 1}
+
+func amount(value uint64) types.Uint128 {
+	return types.U64ToUint128(value)
+}
+
+// setup mints 100 tokens to alice.near and registers bob.near.
+func setup(t *testing.T) (*FungibleToken, *testutils.Context) {
+	t.Helper()
+	ctx := testutils.NewContextBuilder().CurrentAccount("token.near").Build()
+
+	token := New("a")
+	for _, accountID := range []string{"alice.near", "bob.near"} {
+		if err := token.InternalRegisterAccount(accountID); err != nil {
+			t.Fatalf("failed to register %s: %v", accountID, err)
+		}
+	}
+	if err := token.Mint("alice.near", amount(100), ""); err != nil {
+		t.Fatalf("failed to mint: %v", err)
+	}
+	return token, ctx
+}
+
+// call builds the context of a call made by predecessor on top of the state left by the previous one.
+func call(previous *testutils.Context, predecessor string) *testutils.ContextBuilder {
+	return testutils.NewContextBuilder().
+		CurrentAccount("token.near").
+		Predecessor(predecessor).
+		State(previous.Storage)
+}
+
+func expectBalance(t *testing.T, token *FungibleToken, accountID string, expected uint64) {
+	t.Helper()
+	if balance := token.FtBalanceOf(accountID); balance != amount(expected).String() {
+		t.Errorf("expected %s to have %d, got %s", accountID, expected, balance)
+	}
+}
+
+func TestMintAndBurn(t *testing.T) {
+	token, ctx := setup(t)
+
+	expectBalance(t, token, "alice.near", 100)
+	if supply := token.FtTotalSupply(); supply != amount(100).String() {
+		t.Errorf("expected total supply 100, got %s", supply)
+	}
+	logged := events.ParseLogs(ctx.Logs())
+	if len(logged) != 1 || logged[0].Event != "ft_mint" || logged[0].Standard != events.NEP141Standard {
+		t.Fatalf("expected an ft_mint event, got %+v", logged)
+	}
+
+	if err := token.Burn("alice.near", amount(40), "burn"); err != nil {
+		t.Fatalf("failed to burn: %v", err)
+	}
+	expectBalance(t, token, "alice.near", 60)
+	if supply := token.FtTotalSupply(); supply != amount(60).String() {
+		t.Errorf("expected total supply 60, got %s", supply)
+	}
+	if err := token.Burn("alice.near", amount(61), ""); err == nil || err.Error() != ErrInsufficientBalance {
+		t.Errorf("expected %q, got %v", ErrInsufficientBalance, err)
+	}
+	if err := token.Mint("carol.near", amount(1), ""); err == nil || err.Error() != ErrAccountNotRegistered+"carol.near" {
+		t.Errorf("expected %q, got %v", ErrAccountNotRegistered+"carol.near", err)
+	}
+}
+
+func TestFtTransfer(t *testing.T) {
+	token, ctx := setup(t)
+
+	call(ctx, "alice.near").Build()
+	if err := token.FtTransfer("bob.near", amount(10), ""); err == nil || err.Error() != ErrRequiresOneYocto {
+		t.Errorf("expected %q, got %v", ErrRequiresOneYocto, err)
+	}
+
+	ctx = call(ctx, "alice.near").DepositYocto(oneYocto).Build()
+	if err := token.FtTransfer("bob.near", amount(30), "thanks"); err != nil {
+		t.Fatalf("transfer failed: %v", err)
+	}
+	expectBalance(t, token, "alice.near", 70)
+	expectBalance(t, token, "bob.near", 30)
+
+	logged := events.ParseLogs(ctx.Logs())
+	if len(logged) != 1 || logged[0].Event != "ft_transfer" {
+		t.Fatalf("expected an ft_transfer event, got %+v", logged)
+	}
+	transfers, err := events.FtTransferEvent.Decode(logged[0])
+	if err != nil || len(transfers) != 1 ||
+		transfers[0] != (events.FtTransfer{OldOwnerID: "alice.near", NewOwnerID: "bob.near", Amount: "30", Memo: "thanks"}) {
+		t.Errorf("unexpected event data %+v: %v", transfers, err)
+	}
+
+	tests := []struct {
+		receiver string
+		amount   uint64
+		err      string
+	}{
+		{"bob.near", 71, ErrInsufficientBalance},
+		{"carol.near", 1, ErrAccountNotRegistered + "carol.near"},
+		{"alice.near", 1, ErrSelfTransfer},
+		{"bob.near", 0, ErrZeroAmount},
+	}
+	for _, tt := range tests {
+		call(ctx, "alice.near").DepositYocto(oneYocto).Build()
+		if err := token.FtTransfer(tt.receiver, amount(tt.amount), ""); err == nil || err.Error() != tt.err {
+			t.Errorf("transfer of %d to %s: expected %q, got %v", tt.amount, tt.receiver, tt.err, err)
+		}
+	}
+}
+
+func TestFtTransferCall(t *testing.T) {
+	token, ctx := setup(t)
+
+	call(ctx, "alice.near").DepositYocto(oneYocto).Gas(GasForFtTransferCall).Build()
+	if _, err := token.FtTransferCall("bob.near", amount(10), "", "msg"); err == nil || err.Error() != ErrNotEnoughGas {
+		t.Errorf("expected %q, got %v", ErrNotEnoughGas, err)
+	}
+
+	ctx = call(ctx, "alice.near").DepositYocto(oneYocto).Gas(100 * types.ONE_TERA_GAS).Build()
+	if _, err := token.FtTransferCall("bob.near", amount(10), "", "msg"); err != nil {
+		t.Fatalf("transfer call failed: %v", err)
+	}
+	expectBalance(t, token, "bob.near", 10)
+	if len(ctx.Promises) != 2 {
+		t.Fatalf("expected ft_on_transfer and its callback, got %d promises", len(ctx.Promises))
+	}
+	onTransfer, resolve := ctx.Promises[0], ctx.Promises[1]
+	if onTransfer.AccountId != "bob.near" || onTransfer.FunctionName != OnTransferMethod ||
+		string(onTransfer.Arguments) != `{"sender_id":"alice.near","amount":"10","msg":"msg"}` {
+		t.Errorf("unexpected ft_on_transfer call %+v", onTransfer)
+	}
+	if resolve.AccountId != "token.near" || resolve.FunctionName != ResolveTransferMethod ||
+		string(resolve.Arguments) != `{"sender_id":"alice.near","receiver_id":"bob.near","amount":"10"}` {
+		t.Errorf("unexpected ft_resolve_transfer callback %+v", resolve)
+	}
+	if onTransfer.Gas != 100*types.ONE_TERA_GAS-GasForFtTransferCall || resolve.Gas != GasForResolveTransfer {
+		t.Errorf("unexpected gas split %d/%d", onTransfer.Gas, resolve.Gas)
+	}
+}
+
+func TestFtResolveTransfer(t *testing.T) {
+	tests := []struct {
+		name        string
+		result      promise.PromiseResult
+		used        uint64
+		aliceAfter  uint64
+		bobAfter    uint64
+		refundEvent bool
+	}{
+		{"all used", promise.NewPromiseResult(1, []byte(`"0"`)), 40, 60, 40, false},
+		{"partially used", promise.NewPromiseResult(1, []byte(`"15"`)), 25, 75, 25, true},
+		{"more than sent", promise.NewPromiseResult(1, []byte(`"500"`)), 0, 100, 0, true},
+		{"invalid result", promise.NewPromiseResult(1, []byte(`15`)), 0, 100, 0, true},
+		{"receiver failed", promise.NewPromiseResult(2, nil), 0, 100, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, ctx := setup(t)
+			ctx = call(ctx, "alice.near").DepositYocto(oneYocto).Build()
+			if err := token.FtTransfer("bob.near", amount(40), ""); err != nil {
+				t.Fatalf("transfer failed: %v", err)
+			}
+
+			ctx = call(ctx, "token.near").Build()
+			used, err := token.FtResolveTransfer("alice.near", "bob.near", amount(40), tt.result)
+			if err != nil {
+				t.Fatalf("resolve failed: %v", err)
+			}
+			if used.Cmp(amount(tt.used)) != 0 {
+				t.Errorf("expected %d used, got %s", tt.used, used.String())
+			}
+			expectBalance(t, token, "alice.near", tt.aliceAfter)
+			expectBalance(t, token, "bob.near", tt.bobAfter)
+
+			logged := events.ParseLogs(ctx.Logs())
+			if refunded := len(logged) == 1 && logged[0].Event == "ft_transfer"; refunded != tt.refundEvent {
+				t.Errorf("expected refund event %t, got %+v", tt.refundEvent, logged)
+			}
+		})
+	}
+}
+
+func TestFtResolveTransferBurnsRefundOfUnregisteredSender(t *testing.T) {
+	token, ctx := setup(t)
+	ctx = call(ctx, "alice.near").DepositYocto(oneYocto).Build()
+	if err := token.FtTransfer("bob.near", amount(100), ""); err != nil {
+		t.Fatalf("transfer failed: %v", err)
+	}
+	if _, err := token.InternalUnregisterAccount("alice.near", false); err != nil {
+		t.Fatalf("failed to unregister: %v", err)
+	}
+
+	ctx = call(ctx, "token.near").Build()
+	used, err := token.FtResolveTransfer("alice.near", "bob.near", amount(100), promise.NewPromiseResult(2, nil))
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	if used.Cmp(amount(100)) != 0 {
+		t.Errorf("expected the whole amount to be reported as used, got %s", used.String())
+	}
+	expectBalance(t, token, "bob.near", 0)
+	if supply := token.FtTotalSupply(); supply != amount(0).String() {
+		t.Errorf("expected the refund to be burned, got total supply %s", supply)
+	}
+	if logged := events.ParseLogs(ctx.Logs()); len(logged) != 1 || logged[0].Event != "ft_burn" {
+		t.Errorf("expected an ft_burn event, got %+v", logged)
+	}
+}
+
+func TestStorageManagementRegistersHolders(t *testing.T) {
+	ctx := testutils.NewContextBuilder().CurrentAccount("token.near").Build()
+	token := New("a")
+	management := token.NewStorageManagement("s")
+
+	bounds := management.StorageBalanceBounds()
+	if bounds.Max == nil || *bounds.Max != bounds.Min {
+		t.Fatalf("expected fixed storage bounds, got %+v", bounds)
+	}
+	minBalance, _ := types.U128FromString(bounds.Min)
+
+	ctx = call(ctx, "alice.near").DepositYocto(minBalance).Build()
+	if _, err := management.StorageDeposit("", false, token); err != nil {
+		t.Fatalf("storage deposit failed: %v", err)
+	}
+	if !token.IsRegistered("alice.near") {
+		t.Fatalf("expected storage_deposit to register alice.near in the token")
+	}
+	if err := token.Mint("alice.near", amount(5), ""); err != nil {
+		t.Fatalf("failed to mint: %v", err)
+	}
+
+	ctx = call(ctx, "alice.near").Balance(minBalance).DepositYocto(oneYocto).Build()
+	if _, err := management.StorageUnregister(false, token); err == nil || err.Error() != ErrNonZeroBalance {
+		t.Errorf("expected %q, got %v", ErrNonZeroBalance, err)
+	}
+	if unregistered, err := management.StorageUnregister(true, token); err != nil || !unregistered {
+		t.Fatalf("forced unregister failed: %v", err)
+	}
+	if token.IsRegistered("alice.near") || token.FtTotalSupply() != "0" {
+		t.Errorf("expected the balance of alice.near to be burned")
+	}
+	if logged := events.ParseLogs(ctx.Logs()); len(logged) != 1 || logged[0].Event != "ft_burn" {
+		t.Errorf("expected an ft_burn event, got %+v", logged)
+	}
+}
@@ -0,0 +1,91 @@
+package mt
+
+import (
+	"encoding/base64"
+	"errors"
+)
+
+// MetadataSpec is the NEP-245 metadata version implemented by the package.
+const MetadataSpec = "mt-1.0.0"
+
+const (
+	ErrInvalidSpec          = "(MT_ERROR): the metadata spec should be " + MetadataSpec
+	ErrInvalidReferenceHash = "(MT_ERROR): the reference hash should be 32 bytes encoded in base64"
+	ErrInvalidMediaHash     = "(MT_ERROR): the media hash should be 32 bytes encoded in base64"
+	ErrMediaHashRequired    = "(MT_ERROR): the media hash is required when media is set"
+	ErrReferenceHashMissing = "(MT_ERROR): the reference hash is required when reference is set"
+)
+
+// ContractMetadata is the metadata of the contract, returned by mt_metadata_contract.
+type ContractMetadata struct {
+	Spec string `json:"spec"`
+	Name string `json:"name"`
+}
+
+// Validate checks the spec.
+func (m ContractMetadata) Validate() error {
+	if m.Spec != MetadataSpec {
+		return errors.New(ErrInvalidSpec)
+	}
+	return nil
+}
+
+// BaseTokenMetadata is the metadata tokens of the same kind share, like the name and decimals of a
+// fungible token or the collection of a non-fungible one.
+type BaseTokenMetadata struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	Symbol        *string `json:"symbol"`
+	Icon          *string `json:"icon"`
+	Decimals      *string `json:"decimals"`
+	BaseURI       *string `json:"base_uri"`
+	Reference     *string `json:"reference"`
+	ReferenceHash *string `json:"reference_hash"`
+	Copies        *uint64 `json:"copies"`
+}
+
+// TokenMetadata is the metadata of a single token.
+type TokenMetadata struct {
+	Title         *string `json:"title"`
+	Description   *string `json:"description"`
+	Media         *string `json:"media"`
+	MediaHash     *string `json:"media_hash"`
+	IssuedAt      *string `json:"issued_at"`
+	ExpiresAt     *string `json:"expires_at"`
+	StartsAt      *string `json:"starts_at"`
+	UpdatedAt     *string `json:"updated_at"`
+	Extra         *string `json:"extra"`
+	Reference     *string `json:"reference"`
+	ReferenceHash *string `json:"reference_hash"`
+}
+
+// TokenMetadataAll is the base and token metadata of a token, returned by mt_metadata_token_all.
+type TokenMetadataAll struct {
+	Base  BaseTokenMetadata `json:"base"`
+	Token TokenMetadata     `json:"token"`
+}
+
+// Validate checks that media and references come with their hashes.
+func (m TokenMetadataAll) Validate() error {
+	if (m.Base.Reference == nil) != (m.Base.ReferenceHash == nil) ||
+		(m.Token.Reference == nil) != (m.Token.ReferenceHash == nil) {
+		return errors.New(ErrReferenceHashMissing)
+	}
+	for _, hash := range []*string{m.Base.ReferenceHash, m.Token.ReferenceHash} {
+		if hash != nil && !isHash(*hash) {
+			return errors.New(ErrInvalidReferenceHash)
+		}
+	}
+	if (m.Token.Media == nil) != (m.Token.MediaHash == nil) {
+		return errors.New(ErrMediaHashRequired)
+	}
+	if m.Token.MediaHash != nil && !isHash(*m.Token.MediaHash) {
+		return errors.New(ErrInvalidMediaHash)
+	}
+	return nil
+}
+
+func isHash(encoded string) bool {
+	hash, err := base64.StdEncoding.DecodeString(encoded)
+	return err == nil && len(hash) == 32
+}
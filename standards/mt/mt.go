@@ -0,0 +1,523 @@
+// Package mt implements the NEP-245 multi token standard: one contract holding any number of fungible and
+// non-fungible tokens, transferred one by one or in batches.
+//
+// MultiToken keeps the balance of every account and token, and the tokens with their creator and total
+// supply, in LookupMaps. Amounts are returned as the decimal strings NEP-245 expects. A contract embeds it
+// in its state and exposes the methods through its own annotated wrappers:
+//
+//	// @contract:state
+//	type Contract struct {
+//		Tokens *mt.MultiToken
+//	}
+//
+//	// @contract:payable min_deposit=0.000000000000000000000001NEAR
+//	func (c *Contract) MtTransfer(receiverId string, tokenId string, amount string, approval *mt.Approval, memo string) {
+//		value, _ := types.U128FromString(amount)
+//		if err := c.Tokens.MtTransfer(receiverId, tokenId, value, approval, memo); err != nil {
+//			env.PanicStr(err.Error())
+//		}
+//	}
+//
+// Approval management isn't part of the package: only owners transfer their tokens and transfers that
+// carry an approval fail.
+//
+// Mints, transfers and burns emit the NEP-297 mt_mint, mt_transfer and mt_burn events of NEP-245.
+package mt
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/vlmoon99/near-sdk-go/collections"
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/events"
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+const (
+	// GasForResolveTransfer is the gas reserved for the mt_resolve_transfer callback.
+	GasForResolveTransfer = 5 * types.ONE_TERA_GAS
+
+	// GasForMtTransferCall is the gas mt_transfer_call keeps for itself and the callback;
+	// the rest of the prepaid gas is attached to mt_on_transfer.
+	GasForMtTransferCall = 25*types.ONE_TERA_GAS + GasForResolveTransfer
+)
+
+const (
+	// OnTransferMethod is the receiver method called by mt_transfer_call and mt_batch_transfer_call.
+	OnTransferMethod = "mt_on_transfer"
+
+	// ResolveTransferMethod is the callback the transfer calls schedule on the token contract.
+	ResolveTransferMethod = "mt_resolve_transfer"
+)
+
+const (
+	ErrRequiresOneYocto     = "(MT_ERROR): requires attached deposit of exactly 1 yoctoNEAR"
+	ErrTokenNotFound        = "(MT_ERROR): the token doesn't exist: "
+	ErrSelfTransfer         = "(MT_ERROR): sender and receiver should be different"
+	ErrZeroAmount           = "(MT_ERROR): the amount should be a positive number"
+	ErrInsufficientBalance  = "(MT_ERROR): the account doesn't have enough balance of the token: "
+	ErrSupplyOverflow       = "(MT_ERROR): total supply overflow of the token: "
+	ErrBalanceOverflow      = "(MT_ERROR): balance overflow of the token: "
+	ErrLengthMismatch       = "(MT_ERROR): the token IDs and the amounts should have the same length"
+	ErrEmptyBatch           = "(MT_ERROR): at least one token should be transferred"
+	ErrInvalidApproval      = "(MT_ERROR): an approval should be an [owner_id, approval_id] pair"
+	ErrApprovalsUnsupported = "(MT_ERROR): approvals are not supported, only owners can transfer their tokens"
+	ErrNotEnoughGas         = "(MT_ERROR): more gas is required for mt_transfer_call"
+	ErrPredecessorUnknown   = "(MT_ERROR): failed to get the predecessor account: "
+)
+
+var zero = types.Uint128{Hi: 0, Lo: 0}
+
+// Approval is the NEP-245 approval argument of transfers, encoded as an [owner_id, approval_id] pair.
+type Approval struct {
+	OwnerID    string
+	ApprovalID uint64
+}
+
+// UnmarshalJSON decodes the [owner_id, approval_id] pair.
+func (a *Approval) UnmarshalJSON(data []byte) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return errors.New(ErrInvalidApproval)
+	}
+	if err := json.Unmarshal(pair[0], &a.OwnerID); err != nil {
+		return err
+	}
+	return json.Unmarshal(pair[1], &a.ApprovalID)
+}
+
+// MarshalJSON encodes the approval as an [owner_id, approval_id] pair.
+func (a Approval) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{a.OwnerID, a.ApprovalID})
+}
+
+// TokenInfo is the stored record of a token: the account that created it and its total supply.
+type TokenInfo struct {
+	OwnerID string        `json:"owner_id"`
+	Supply  types.Uint128 `json:"supply"`
+}
+
+// Token is the NEP-245 view of a token returned by mt_token.
+type Token struct {
+	TokenID  string            `json:"token_id"`
+	OwnerID  *string           `json:"owner_id"`
+	Metadata *TokenMetadataAll `json:"metadata,omitempty"`
+}
+
+// MultiToken is the state of a multi token contract.
+type MultiToken struct {
+	Metadata ContractMetadata                                 `json:"metadata"`
+	Tokens   *collections.LookupMap[string, TokenInfo]        `json:"tokens"`
+	Balances *collections.LookupMap[string, types.Uint128]    `json:"balances"`
+	MetaByID *collections.LookupMap[string, TokenMetadataAll] `json:"meta_by_id"`
+}
+
+// TransferCallArgs are the arguments the transfer calls pass to mt_on_transfer.
+type TransferCallArgs struct {
+	SenderID         string   `json:"sender_id"`
+	PreviousOwnerIDs []string `json:"previous_owner_ids"`
+	TokenIDs         []string `json:"token_ids"`
+	Amounts          []string `json:"amounts"`
+	Msg              string   `json:"msg"`
+}
+
+// ResolveTransferArgs are the arguments of the mt_resolve_transfer callback.
+type ResolveTransferArgs struct {
+	PreviousOwnerIDs []string `json:"previous_owner_ids"`
+	ReceiverID       string   `json:"receiver_id"`
+	TokenIDs         []string `json:"token_ids"`
+	Amounts          []string `json:"amounts"`
+}
+
+// New creates an empty multi token contract stored under prefix. The metadata has to pass
+// ContractMetadata.Validate.
+func New(prefix string, metadata ContractMetadata) (*MultiToken, error) {
+	if err := metadata.Validate(); err != nil {
+		return nil, err
+	}
+	return &MultiToken{
+		Metadata: metadata,
+		Tokens:   collections.NewLookupMap[string, TokenInfo](prefix + "t"),
+		Balances: collections.NewLookupMap[string, types.Uint128](prefix + "b"),
+		MetaByID: collections.NewLookupMap[string, TokenMetadataAll](prefix + "m"),
+	}, nil
+}
+
+// Mint creates amount of the token for ownerID and emits mt_mint. The first mint creates the token, with
+// ownerID as its owner and the optional metadata; later mints increase its supply and ignore metadata.
+func (mt *MultiToken) Mint(ownerID, tokenID string, amount types.Uint128, metadata *TokenMetadataAll, memo string) error {
+	if amount.Cmp(zero) == 0 {
+		return errors.New(ErrZeroAmount)
+	}
+	info, err := mt.Tokens.Get(tokenID)
+	if err != nil {
+		if metadata != nil {
+			if err := metadata.Validate(); err != nil {
+				return err
+			}
+			if err := mt.MetaByID.Insert(tokenID, *metadata); err != nil {
+				return err
+			}
+		}
+		info = TokenInfo{OwnerID: ownerID, Supply: zero}
+	}
+	if info.Supply, err = info.Supply.Add(amount); err != nil {
+		return errors.New(ErrSupplyOverflow + tokenID)
+	}
+	if err := mt.deposit(ownerID, tokenID, amount); err != nil {
+		return err
+	}
+	if err := mt.Tokens.Insert(tokenID, info); err != nil {
+		return err
+	}
+
+	events.MtMintEvent.Emit(events.MtMint{OwnerID: ownerID, TokenIDs: []string{tokenID}, Amounts: []string{amount.String()}, Memo: memo})
+	return nil
+}
+
+// Burn destroys amount of the token held by ownerID and emits mt_burn. The token keeps existing with a zero
+// supply.
+func (mt *MultiToken) Burn(ownerID, tokenID string, amount types.Uint128, memo string) error {
+	info, err := mt.tokenInfo(tokenID)
+	if err != nil {
+		return err
+	}
+	if err := mt.withdraw(ownerID, tokenID, amount); err != nil {
+		return err
+	}
+	info.Supply, _ = info.Supply.Sub(amount)
+	if err := mt.Tokens.Insert(tokenID, info); err != nil {
+		return err
+	}
+
+	events.MtBurnEvent.Emit(events.MtBurn{OwnerID: ownerID, TokenIDs: []string{tokenID}, Amounts: []string{amount.String()}, Memo: memo})
+	return nil
+}
+
+// InternalTransfer moves the amounts of the tokens from the sender to the receiver and emits a single
+// mt_transfer event.
+func (mt *MultiToken) InternalTransfer(senderID, receiverID string, tokenIDs []string, amounts []types.Uint128, memo string) error {
+	if len(tokenIDs) == 0 {
+		return errors.New(ErrEmptyBatch)
+	}
+	if len(tokenIDs) != len(amounts) {
+		return errors.New(ErrLengthMismatch)
+	}
+	if senderID == receiverID {
+		return errors.New(ErrSelfTransfer)
+	}
+
+	for i, tokenID := range tokenIDs {
+		if _, err := mt.tokenInfo(tokenID); err != nil {
+			return err
+		}
+		if amounts[i].Cmp(zero) == 0 {
+			return errors.New(ErrZeroAmount)
+		}
+		if err := mt.withdraw(senderID, tokenID, amounts[i]); err != nil {
+			return err
+		}
+		if err := mt.deposit(receiverID, tokenID, amounts[i]); err != nil {
+			return err
+		}
+	}
+
+	events.MtTransferEvent.Emit(events.MtTransfer{OldOwnerID: senderID, NewOwnerID: receiverID, TokenIDs: tokenIDs, Amounts: amountStrings(amounts), Memo: memo})
+	return nil
+}
+
+// MtTransfer implements mt_transfer: the predecessor sends amount of the token to the receiver.
+// Exactly one yoctoNEAR must be attached.
+func (mt *MultiToken) MtTransfer(receiverID, tokenID string, amount types.Uint128, approval *Approval, memo string) error {
+	return mt.MtBatchTransfer(receiverID, []string{tokenID}, []types.Uint128{amount}, []*Approval{approval}, memo)
+}
+
+// MtBatchTransfer implements mt_batch_transfer: the predecessor sends the amounts of the tokens to the
+// receiver. Exactly one yoctoNEAR must be attached.
+func (mt *MultiToken) MtBatchTransfer(receiverID string, tokenIDs []string, amounts []types.Uint128, approvals []*Approval, memo string) error {
+	senderID, err := mt.transferSender(approvals)
+	if err != nil {
+		return err
+	}
+	return mt.InternalTransfer(senderID, receiverID, tokenIDs, amounts, memo)
+}
+
+// MtTransferCall implements mt_transfer_call; see MtBatchTransferCall.
+func (mt *MultiToken) MtTransferCall(receiverID, tokenID string, amount types.Uint128, approval *Approval, memo, msg string) (*promise.Promise, error) {
+	return mt.MtBatchTransferCall(receiverID, []string{tokenID}, []types.Uint128{amount}, []*Approval{approval}, memo, msg)
+}
+
+// MtBatchTransferCall implements mt_batch_transfer_call: it transfers the tokens to the receiver, calls
+// mt_on_transfer on it and schedules mt_resolve_transfer on this contract. Exactly one yoctoNEAR must be
+// attached.
+//
+// The wrapper returns the resulting promise with Value, so the caller gets the amounts used by the receiver.
+func (mt *MultiToken) MtBatchTransferCall(receiverID string, tokenIDs []string, amounts []types.Uint128, approvals []*Approval, memo, msg string) (*promise.Promise, error) {
+	senderID, err := mt.transferSender(approvals)
+	if err != nil {
+		return nil, err
+	}
+	prepaidGas := env.GetPrepaidGas().Inner
+	if prepaidGas <= GasForMtTransferCall {
+		return nil, errors.New(ErrNotEnoughGas)
+	}
+	if err := mt.InternalTransfer(senderID, receiverID, tokenIDs, amounts, memo); err != nil {
+		return nil, err
+	}
+
+	previousOwnerIDs := make([]string, len(tokenIDs))
+	for i := range previousOwnerIDs {
+		previousOwnerIDs[i] = senderID
+	}
+	values := amountStrings(amounts)
+	return promise.NewCrossContract(receiverID).
+		Gas(prepaidGas-GasForMtTransferCall).
+		Call(OnTransferMethod, TransferCallArgs{SenderID: senderID, PreviousOwnerIDs: previousOwnerIDs, TokenIDs: tokenIDs, Amounts: values, Msg: msg}).
+		Gas(GasForResolveTransfer).
+		Then(ResolveTransferMethod, ResolveTransferArgs{PreviousOwnerIDs: previousOwnerIDs, ReceiverID: receiverID, TokenIDs: tokenIDs, Amounts: values}), nil
+}
+
+// MtResolveTransfer implements mt_resolve_transfer. The result is the outcome of mt_on_transfer, the amounts
+// the receiver didn't use, one per token. Each of them, capped by the receiver balance, is refunded to its
+// previous owner; a failed mt_on_transfer or a malformed result refunds everything.
+//
+// It returns the amounts that ended up on the receiver. The wrapper must be a private promise callback.
+func (mt *MultiToken) MtResolveTransfer(previousOwnerIDs []string, receiverID string, tokenIDs []string, amounts []types.Uint128, result promise.PromiseResult) ([]string, error) {
+	if len(previousOwnerIDs) != len(tokenIDs) || len(tokenIDs) != len(amounts) {
+		return nil, errors.New(ErrLengthMismatch)
+	}
+	unused := amounts
+	if result.Success {
+		if values, err := parseJSONAmounts(result.Data); err == nil && len(values) == len(amounts) {
+			unused = values
+		}
+	}
+
+	used := make([]types.Uint128, len(amounts))
+	refunds := map[string]*events.MtTransfer{}
+	var order []string
+	for i, tokenID := range tokenIDs {
+		refund := unused[i]
+		if refund.Cmp(amounts[i]) > 0 {
+			refund = amounts[i]
+		}
+		if balance := mt.balance(receiverID, tokenID); balance.Cmp(refund) < 0 {
+			refund = balance
+		}
+		used[i], _ = amounts[i].Sub(refund)
+		if refund.Cmp(zero) == 0 {
+			continue
+		}
+
+		if err := mt.withdraw(receiverID, tokenID, refund); err != nil {
+			return nil, err
+		}
+		if err := mt.deposit(previousOwnerIDs[i], tokenID, refund); err != nil {
+			return nil, err
+		}
+		event, ok := refunds[previousOwnerIDs[i]]
+		if !ok {
+			event = &events.MtTransfer{OldOwnerID: receiverID, NewOwnerID: previousOwnerIDs[i], Memo: "refund"}
+			refunds[previousOwnerIDs[i]] = event
+			order = append(order, previousOwnerIDs[i])
+		}
+		event.TokenIDs = append(event.TokenIDs, tokenID)
+		event.Amounts = append(event.Amounts, refund.String())
+	}
+
+	if len(order) > 0 {
+		transfers := make([]events.MtTransfer, len(order))
+		for i, ownerID := range order {
+			transfers[i] = *refunds[ownerID]
+		}
+		events.MtTransferEvent.Emit(transfers...)
+	}
+	return amountStrings(used), nil
+}
+
+// MtBalanceOf implements mt_balance_of.
+func (mt *MultiToken) MtBalanceOf(accountID, tokenID string) string {
+	return mt.balance(accountID, tokenID).String()
+}
+
+// MtBatchBalanceOf implements mt_batch_balance_of.
+func (mt *MultiToken) MtBatchBalanceOf(accountID string, tokenIDs []string) []string {
+	balances := make([]string, len(tokenIDs))
+	for i, tokenID := range tokenIDs {
+		balances[i] = mt.MtBalanceOf(accountID, tokenID)
+	}
+	return balances
+}
+
+// MtSupply implements mt_supply; it returns nil for unknown tokens.
+func (mt *MultiToken) MtSupply(tokenID string) *string {
+	info, err := mt.Tokens.Get(tokenID)
+	if err != nil {
+		return nil
+	}
+	supply := info.Supply.String()
+	return &supply
+}
+
+// MtBatchSupply implements mt_batch_supply.
+func (mt *MultiToken) MtBatchSupply(tokenIDs []string) []*string {
+	supplies := make([]*string, len(tokenIDs))
+	for i, tokenID := range tokenIDs {
+		supplies[i] = mt.MtSupply(tokenID)
+	}
+	return supplies
+}
+
+// MtToken implements mt_token; unknown tokens are nil.
+func (mt *MultiToken) MtToken(tokenIDs []string) []*Token {
+	tokens := make([]*Token, len(tokenIDs))
+	for i, tokenID := range tokenIDs {
+		info, err := mt.Tokens.Get(tokenID)
+		if err != nil {
+			continue
+		}
+		ownerID := info.OwnerID
+		tokens[i] = &Token{TokenID: tokenID, OwnerID: &ownerID}
+		if metadata, err := mt.MetaByID.Get(tokenID); err == nil {
+			tokens[i].Metadata = &metadata
+		}
+	}
+	return tokens
+}
+
+// MtMetadataContract implements mt_metadata_contract.
+func (mt *MultiToken) MtMetadataContract() ContractMetadata {
+	return mt.Metadata
+}
+
+// MtMetadataTokenAll implements mt_metadata_token_all; tokens without metadata are nil.
+func (mt *MultiToken) MtMetadataTokenAll(tokenIDs []string) []*TokenMetadataAll {
+	all := make([]*TokenMetadataAll, len(tokenIDs))
+	for i, tokenID := range tokenIDs {
+		if metadata, err := mt.MetaByID.Get(tokenID); err == nil {
+			all[i] = &metadata
+		}
+	}
+	return all
+}
+
+// MtMetadataTokenByTokenID implements mt_metadata_token_by_token_id.
+func (mt *MultiToken) MtMetadataTokenByTokenID(tokenIDs []string) []*TokenMetadata {
+	result := make([]*TokenMetadata, len(tokenIDs))
+	for i, metadata := range mt.MtMetadataTokenAll(tokenIDs) {
+		if metadata != nil {
+			result[i] = &metadata.Token
+		}
+	}
+	return result
+}
+
+// MtMetadataBaseByTokenID implements mt_metadata_base_by_token_id.
+func (mt *MultiToken) MtMetadataBaseByTokenID(tokenIDs []string) []*BaseTokenMetadata {
+	result := make([]*BaseTokenMetadata, len(tokenIDs))
+	for i, metadata := range mt.MtMetadataTokenAll(tokenIDs) {
+		if metadata != nil {
+			result[i] = &metadata.Base
+		}
+	}
+	return result
+}
+
+// transferSender checks the deposit and the approvals of a transfer and returns the predecessor.
+func (mt *MultiToken) transferSender(approvals []*Approval) (string, error) {
+	if err := assertOneYocto(); err != nil {
+		return "", err
+	}
+	for _, approval := range approvals {
+		if approval != nil {
+			return "", errors.New(ErrApprovalsUnsupported)
+		}
+	}
+	senderID, err := env.GetPredecessorAccountID()
+	if err != nil {
+		return "", errors.New(ErrPredecessorUnknown + err.Error())
+	}
+	return senderID, nil
+}
+
+func (mt *MultiToken) tokenInfo(tokenID string) (TokenInfo, error) {
+	info, err := mt.Tokens.Get(tokenID)
+	if err != nil {
+		return TokenInfo{}, errors.New(ErrTokenNotFound + tokenID)
+	}
+	return info, nil
+}
+
+func (mt *MultiToken) balance(accountID, tokenID string) types.Uint128 {
+	balance, err := mt.Balances.Get(balanceKey(accountID, tokenID))
+	if err != nil {
+		return zero
+	}
+	return balance
+}
+
+func (mt *MultiToken) deposit(accountID, tokenID string, amount types.Uint128) error {
+	balance, err := mt.balance(accountID, tokenID).Add(amount)
+	if err != nil {
+		return errors.New(ErrBalanceOverflow + tokenID)
+	}
+	return mt.Balances.Insert(balanceKey(accountID, tokenID), balance)
+}
+
+// withdraw debits the balance and removes it once empty.
+func (mt *MultiToken) withdraw(accountID, tokenID string, amount types.Uint128) error {
+	balance, err := mt.balance(accountID, tokenID).Sub(amount)
+	if err != nil {
+		return errors.New(ErrInsufficientBalance + tokenID)
+	}
+	if balance.Cmp(zero) == 0 {
+		if exists, _ := mt.Balances.Contains(balanceKey(accountID, tokenID)); exists {
+			return mt.Balances.Remove(balanceKey(accountID, tokenID))
+		}
+		return nil
+	}
+	return mt.Balances.Insert(balanceKey(accountID, tokenID), balance)
+}
+
+// balanceKey joins the account and the token ID; account IDs can't contain the separator.
+func balanceKey(accountID, tokenID string) string {
+	return accountID + ":" + tokenID
+}
+
+func amountStrings(amounts []types.Uint128) []string {
+	values := make([]string, len(amounts))
+	for i, amount := range amounts {
+		values[i] = amount.String()
+	}
+	return values
+}
+
+func assertOneYocto() error {
+	deposit, err := env.GetAttachedDeposit()
+	if err != nil || deposit.Cmp(types.Uint128{Hi: 0, Lo: 1}) != 0 {
+		return errors.New(ErrRequiresOneYocto)
+	}
+	return nil
+}
+
+// parseJSONAmounts decodes a list of amounts, decimal numbers in JSON strings.
+func parseJSONAmounts(data []byte) ([]types.Uint128, error) {
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	amounts := make([]types.Uint128, len(values))
+	for i, value := range values {
+		amount, err := types.U128FromString(value)
+		if err != nil {
+			return nil, err
+		}
+		amounts[i] = amount
+	}
+	return amounts, nil
+}
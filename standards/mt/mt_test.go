@@ -0,0 +1,244 @@
+package mt
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/events"
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/testutils"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+var oneYocto = types.Uint128{Hi: 0, Lo: 1}
+
+func amount(value uint64) types.Uint128 {
+	return types.U64ToUint128(value)
+}
+
+// setup mints 100 "gold" and a single "sword" to alice.near.
+func setup(t *testing.T) (*MultiToken, *testutils.Context) {
+	t.Helper()
+	ctx := testutils.NewContextBuilder().CurrentAccount("mt.near").Build()
+
+	tokens, err := New("m", ContractMetadata{Spec: MetadataSpec, Name: "Game items"})
+	if err != nil {
+		t.Fatalf("failed to create the contract: %v", err)
+	}
+	symbol := "GLD"
+	if err := tokens.Mint("alice.near", "gold", amount(100), &TokenMetadataAll{Base: BaseTokenMetadata{ID: "gold", Name: "Gold", Symbol: &symbol}}, ""); err != nil {
+		t.Fatalf("failed to mint gold: %v", err)
+	}
+	if err := tokens.Mint("alice.near", "sword", amount(1), nil, ""); err != nil {
+		t.Fatalf("failed to mint the sword: %v", err)
+	}
+	return tokens, ctx
+}
+
+// call builds the context of a call made by predecessor on top of the state left by the previous one.
+func call(previous *testutils.Context, predecessor string) *testutils.ContextBuilder {
+	return testutils.NewContextBuilder().
+		CurrentAccount("mt.near").
+		Predecessor(predecessor).
+		State(previous.Storage)
+}
+
+func expectBalance(t *testing.T, tokens *MultiToken, accountID, tokenID string, expected uint64) {
+	t.Helper()
+	if balance := tokens.MtBalanceOf(accountID, tokenID); balance != amount(expected).String() {
+		t.Errorf("expected %s to have %d %s, got %s", accountID, expected, tokenID, balance)
+	}
+}
+
+func TestMintAndBurn(t *testing.T) {
+	tokens, ctx := setup(t)
+
+	expectBalance(t, tokens, "alice.near", "gold", 100)
+	if supply := tokens.MtSupply("gold"); supply == nil || *supply != "100" {
+		t.Errorf("expected a supply of 100, got %v", supply)
+	}
+	logged := events.ParseLogs(ctx.Logs())
+	if len(logged) != 2 || logged[0].Event != "mt_mint" || logged[0].Standard != events.NEP245Standard {
+		t.Fatalf("expected mt_mint events, got %+v", logged)
+	}
+
+	ctx = call(ctx, "mt.near").Build()
+	if err := tokens.Burn("alice.near", "gold", amount(30), ""); err != nil {
+		t.Fatalf("failed to burn: %v", err)
+	}
+	expectBalance(t, tokens, "alice.near", "gold", 70)
+	if supply := tokens.MtSupply("gold"); supply == nil || *supply != "70" {
+		t.Errorf("expected a supply of 70, got %v", supply)
+	}
+	if logged := events.ParseLogs(ctx.Logs()); len(logged) != 1 || logged[0].Event != "mt_burn" {
+		t.Errorf("expected an mt_burn event, got %+v", logged)
+	}
+	if err := tokens.Burn("alice.near", "gold", amount(71), ""); err == nil || err.Error() != ErrInsufficientBalance+"gold" {
+		t.Errorf("expected %q, got %v", ErrInsufficientBalance+"gold", err)
+	}
+	if err := tokens.Burn("alice.near", "shield", amount(1), ""); err == nil || err.Error() != ErrTokenNotFound+"shield" {
+		t.Errorf("expected %q, got %v", ErrTokenNotFound+"shield", err)
+	}
+}
+
+func TestMtBatchTransfer(t *testing.T) {
+	tokens, ctx := setup(t)
+
+	call(ctx, "alice.near").Build()
+	if err := tokens.MtTransfer("bob.near", "gold", amount(1), nil, ""); err == nil || err.Error() != ErrRequiresOneYocto {
+		t.Errorf("expected %q, got %v", ErrRequiresOneYocto, err)
+	}
+
+	ctx = call(ctx, "alice.near").DepositYocto(oneYocto).Build()
+	if err := tokens.MtBatchTransfer("bob.near", []string{"gold", "sword"}, []types.Uint128{amount(40), amount(1)}, nil, "loot"); err != nil {
+		t.Fatalf("batch transfer failed: %v", err)
+	}
+	expectBalance(t, tokens, "alice.near", "gold", 60)
+	expectBalance(t, tokens, "bob.near", "gold", 40)
+	expectBalance(t, tokens, "bob.near", "sword", 1)
+	balances := tokens.MtBatchBalanceOf("alice.near", []string{"gold", "sword", "shield"})
+	if len(balances) != 3 || balances[0] != "60" || balances[1] != "0" || balances[2] != "0" {
+		t.Errorf("unexpected balances %v", balances)
+	}
+
+	logged := events.ParseLogs(ctx.Logs())
+	if len(logged) != 1 || logged[0].Event != "mt_transfer" {
+		t.Fatalf("expected a single mt_transfer event, got %+v", logged)
+	}
+	transfers, err := events.MtTransferEvent.Decode(logged[0])
+	if err != nil || len(transfers) != 1 ||
+		transfers[0].OldOwnerID != "alice.near" || transfers[0].NewOwnerID != "bob.near" || transfers[0].Memo != "loot" ||
+		strings.Join(transfers[0].TokenIDs, ",") != "gold,sword" || strings.Join(transfers[0].Amounts, ",") != "40,1" {
+		t.Errorf("unexpected event data %+v: %v", transfers, err)
+	}
+
+	approval := &Approval{OwnerID: "alice.near", ApprovalID: 1}
+	tests := []struct {
+		tokenIDs  []string
+		amounts   []types.Uint128
+		approvals []*Approval
+		receiver  string
+		err       string
+	}{
+		{[]string{"gold"}, []types.Uint128{amount(61)}, nil, "bob.near", ErrInsufficientBalance + "gold"},
+		{[]string{"gold", "sword"}, []types.Uint128{amount(1)}, nil, "bob.near", ErrLengthMismatch},
+		{[]string{}, []types.Uint128{}, nil, "bob.near", ErrEmptyBatch},
+		{[]string{"gold"}, []types.Uint128{amount(0)}, nil, "bob.near", ErrZeroAmount},
+		{[]string{"shield"}, []types.Uint128{amount(1)}, nil, "bob.near", ErrTokenNotFound + "shield"},
+		{[]string{"gold"}, []types.Uint128{amount(1)}, nil, "alice.near", ErrSelfTransfer},
+		{[]string{"gold"}, []types.Uint128{amount(1)}, []*Approval{approval}, "bob.near", ErrApprovalsUnsupported},
+	}
+	for _, tt := range tests {
+		call(ctx, "alice.near").DepositYocto(oneYocto).Build()
+		if err := tokens.MtBatchTransfer(tt.receiver, tt.tokenIDs, tt.amounts, tt.approvals, ""); err == nil || err.Error() != tt.err {
+			t.Errorf("transfer of %v: expected %q, got %v", tt.tokenIDs, tt.err, err)
+		}
+	}
+}
+
+func TestMtTransferCall(t *testing.T) {
+	tokens, ctx := setup(t)
+
+	call(ctx, "alice.near").DepositYocto(oneYocto).Gas(GasForMtTransferCall).Build()
+	if _, err := tokens.MtTransferCall("bob.near", "gold", amount(10), nil, "", "msg"); err == nil || err.Error() != ErrNotEnoughGas {
+		t.Errorf("expected %q, got %v", ErrNotEnoughGas, err)
+	}
+
+	ctx = call(ctx, "alice.near").DepositYocto(oneYocto).Gas(100 * types.ONE_TERA_GAS).Build()
+	if _, err := tokens.MtBatchTransferCall("bob.near", []string{"gold", "sword"}, []types.Uint128{amount(10), amount(1)}, nil, "", "msg"); err != nil {
+		t.Fatalf("transfer call failed: %v", err)
+	}
+	if len(ctx.Promises) != 2 {
+		t.Fatalf("expected mt_on_transfer and its callback, got %d promises", len(ctx.Promises))
+	}
+	onTransfer, resolve := ctx.Promises[0], ctx.Promises[1]
+	if onTransfer.AccountId != "bob.near" || onTransfer.FunctionName != OnTransferMethod ||
+		string(onTransfer.Arguments) != `{"sender_id":"alice.near","previous_owner_ids":["alice.near","alice.near"],"token_ids":["gold","sword"],"amounts":["10","1"],"msg":"msg"}` {
+		t.Errorf("unexpected mt_on_transfer call %+v", onTransfer)
+	}
+	if resolve.AccountId != "mt.near" || resolve.FunctionName != ResolveTransferMethod ||
+		string(resolve.Arguments) != `{"previous_owner_ids":["alice.near","alice.near"],"receiver_id":"bob.near","token_ids":["gold","sword"],"amounts":["10","1"]}` {
+		t.Errorf("unexpected mt_resolve_transfer callback %+v", resolve)
+	}
+	if onTransfer.Gas != 100*types.ONE_TERA_GAS-GasForMtTransferCall || resolve.Gas != GasForResolveTransfer {
+		t.Errorf("unexpected gas split %d/%d", onTransfer.Gas, resolve.Gas)
+	}
+}
+
+func TestMtResolveTransfer(t *testing.T) {
+	tests := []struct {
+		name       string
+		result     promise.PromiseResult
+		used       []uint64
+		aliceGold  uint64
+		aliceSword uint64
+	}{
+		{"all used", promise.NewPromiseResult(1, []byte(`["0","0"]`)), []uint64{10, 1}, 90, 0},
+		{"partially used", promise.NewPromiseResult(1, []byte(`["4","1"]`)), []uint64{6, 0}, 94, 1},
+		{"more than sent", promise.NewPromiseResult(1, []byte(`["50","0"]`)), []uint64{0, 1}, 100, 0},
+		{"wrong length", promise.NewPromiseResult(1, []byte(`["0"]`)), []uint64{0, 0}, 100, 1},
+		{"receiver failed", promise.NewPromiseResult(2, nil), []uint64{0, 0}, 100, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, ctx := setup(t)
+			ctx = call(ctx, "alice.near").DepositYocto(oneYocto).Build()
+			if err := tokens.MtBatchTransfer("bob.near", []string{"gold", "sword"}, []types.Uint128{amount(10), amount(1)}, nil, ""); err != nil {
+				t.Fatalf("transfer failed: %v", err)
+			}
+
+			ctx = call(ctx, "mt.near").Build()
+			used, err := tokens.MtResolveTransfer([]string{"alice.near", "alice.near"}, "bob.near", []string{"gold", "sword"}, []types.Uint128{amount(10), amount(1)}, tt.result)
+			if err != nil {
+				t.Fatalf("resolve failed: %v", err)
+			}
+			for i := range used {
+				if used[i] != amount(tt.used[i]).String() {
+					t.Errorf("expected %v used, got %v", tt.used, used)
+				}
+			}
+			expectBalance(t, tokens, "alice.near", "gold", tt.aliceGold)
+			expectBalance(t, tokens, "alice.near", "sword", tt.aliceSword)
+
+			logged := events.ParseLogs(ctx.Logs())
+			refunded := tt.aliceGold != 90 || tt.aliceSword != 0
+			if (len(logged) == 1) != refunded {
+				t.Errorf("expected refund event %t, got %+v", refunded, logged)
+			}
+		})
+	}
+}
+
+func TestViews(t *testing.T) {
+	tokens, _ := setup(t)
+
+	if metadata := tokens.MtMetadataContract(); metadata.Name != "Game items" {
+		t.Errorf("unexpected contract metadata %+v", metadata)
+	}
+	found := tokens.MtToken([]string{"gold", "shield"})
+	if found[0] == nil || *found[0].OwnerID != "alice.near" || found[0].Metadata.Base.Name != "Gold" || found[1] != nil {
+		t.Errorf("unexpected tokens %+v", found)
+	}
+	base := tokens.MtMetadataBaseByTokenID([]string{"gold", "sword"})
+	if base[0] == nil || *base[0].Symbol != "GLD" || base[1] != nil {
+		t.Errorf("unexpected base metadata %+v", base)
+	}
+	supplies, _ := json.Marshal(tokens.MtBatchSupply([]string{"sword", "shield"}))
+	if string(supplies) != `["1",null]` {
+		t.Errorf("expected the supplies as strings, got %s", supplies)
+	}
+	balances, _ := json.Marshal(tokens.MtBatchBalanceOf("alice.near", []string{"gold", "shield"}))
+	if string(balances) != `["100","0"]` {
+		t.Errorf("expected the balances as strings, got %s", balances)
+	}
+
+	var approval Approval
+	if err := json.Unmarshal([]byte(`["alice.near", 3]`), &approval); err != nil || approval != (Approval{OwnerID: "alice.near", ApprovalID: 3}) {
+		t.Errorf("failed to decode the approval: %+v %v", approval, err)
+	}
+	if err := json.Unmarshal([]byte(`["alice.near"]`), &approval); err == nil || err.Error() != ErrInvalidApproval {
+		t.Errorf("expected %q, got %v", ErrInvalidApproval, err)
+	}
+}
@@ -0,0 +1,158 @@
+package nft
+
+import (
+	"errors"
+
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/standards/storage"
+)
+
+// OnApproveArgs are the arguments nft_approve passes to nft_on_approve.
+type OnApproveArgs struct {
+	TokenID    string `json:"token_id"`
+	OwnerID    string `json:"owner_id"`
+	ApprovalID uint64 `json:"approval_id"`
+	Msg        string `json:"msg"`
+}
+
+// NftApprove implements nft_approve: the owner approves accountID to transfer the token. The attached deposit
+// pays for the storage of the approval and the excess is refunded. Approving an account again replaces its
+// approval ID.
+//
+// With a message, nft_on_approve is called on the approved account and the promise is returned for the
+// wrapper to return with Value; otherwise the promise is nil.
+func (nft *NonFungibleToken) NftApprove(tokenID, accountID string, msg *string) (*promise.Promise, error) {
+	deposit, err := assertAtLeastOneYocto()
+	if err != nil {
+		return nil, err
+	}
+	prepaidGas := env.GetPrepaidGas().Inner
+	if msg != nil && prepaidGas <= GasForNftApprove {
+		return nil, errors.New(ErrNotEnoughGasForApprove)
+	}
+	ownerID, err := nft.assertOwner(tokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	approvalID, _ := nft.NextApprovalIDByID.Get(tokenID)
+	approvals, _ := nft.ApprovalsByID.Get(tokenID)
+	if approvals == nil {
+		approvals = map[string]uint64{}
+	}
+	approvals[accountID] = approvalID
+
+	before := env.GetStorageUsage()
+	if err := nft.ApprovalsByID.Insert(tokenID, approvals); err != nil {
+		return nil, err
+	}
+	if err := nft.NextApprovalIDByID.Insert(tokenID, approvalID+1); err != nil {
+		return nil, err
+	}
+	var used uint64
+	if after := env.GetStorageUsage(); after > before {
+		used = after - before
+	}
+	cost, err := storage.CostOf(used)
+	if err != nil {
+		return nil, err
+	}
+	if deposit.Cmp(cost) < 0 {
+		return nil, errors.New(ErrInsufficientDeposit + cost.String())
+	}
+	if refund, _ := deposit.Sub(cost); refund.Cmp(zero) > 0 {
+		promise.CreateBatch(ownerID).Transfer(refund)
+	}
+
+	if msg == nil {
+		return nil, nil
+	}
+	return promise.NewCrossContract(accountID).
+		Gas(prepaidGas-GasForNftApprove).
+		Call(OnApproveMethod, OnApproveArgs{TokenID: tokenID, OwnerID: ownerID, ApprovalID: approvalID, Msg: *msg}), nil
+}
+
+// NftRevoke implements nft_revoke: the owner removes the approval of accountID and gets its storage refunded.
+// Exactly one yoctoNEAR must be attached.
+func (nft *NonFungibleToken) NftRevoke(tokenID, accountID string) error {
+	if err := assertOneYocto(); err != nil {
+		return err
+	}
+	ownerID, err := nft.assertOwner(tokenID)
+	if err != nil {
+		return err
+	}
+	approvals, err := nft.ApprovalsByID.Get(tokenID)
+	if err != nil {
+		return nil
+	}
+	if _, ok := approvals[accountID]; !ok {
+		return nil
+	}
+
+	before := nft.approvalsStorageUsage(tokenID, approvals)
+	delete(approvals, accountID)
+	if len(approvals) == 0 {
+		err = nft.ApprovalsByID.Remove(tokenID)
+	} else {
+		err = nft.ApprovalsByID.Insert(tokenID, approvals)
+	}
+	if err != nil {
+		return err
+	}
+	refundApprovals(ownerID, before-nft.approvalsStorageUsage(tokenID, approvals))
+	return nil
+}
+
+// NftRevokeAll implements nft_revoke_all: the owner removes every approval of the token and gets their
+// storage refunded. Exactly one yoctoNEAR must be attached.
+func (nft *NonFungibleToken) NftRevokeAll(tokenID string) error {
+	if err := assertOneYocto(); err != nil {
+		return err
+	}
+	ownerID, err := nft.assertOwner(tokenID)
+	if err != nil {
+		return err
+	}
+	return nft.clearApprovals(tokenID, ownerID)
+}
+
+// NftIsApproved implements nft_is_approved. With an approval ID, the approval must also match it.
+func (nft *NonFungibleToken) NftIsApproved(tokenID, approvedAccountID string, approvalID *uint64) bool {
+	approvals, err := nft.ApprovalsByID.Get(tokenID)
+	if err != nil {
+		return false
+	}
+	approved, ok := approvals[approvedAccountID]
+	return ok && (approvalID == nil || *approvalID == approved)
+}
+
+// assertOwner returns the owner of the token and checks that it is the predecessor.
+func (nft *NonFungibleToken) assertOwner(tokenID string) (string, error) {
+	ownerID, err := nft.OwnerOf(tokenID)
+	if err != nil {
+		return "", err
+	}
+	predecessorID, err := env.GetPredecessorAccountID()
+	if err != nil {
+		return "", errors.New(ErrPredecessorUnknown + err.Error())
+	}
+	if predecessorID != ownerID {
+		return "", errors.New(ErrNotOwner)
+	}
+	return ownerID, nil
+}
+
+// clearApprovals removes the approvals of the token and refunds their storage to the owner.
+func (nft *NonFungibleToken) clearApprovals(tokenID, ownerID string) error {
+	approvals, err := nft.ApprovalsByID.Get(tokenID)
+	if err != nil {
+		return nil
+	}
+	if err := nft.ApprovalsByID.Remove(tokenID); err != nil {
+		return err
+	}
+	refundApprovals(ownerID, nft.approvalsStorageUsage(tokenID, approvals))
+	return nil
+}
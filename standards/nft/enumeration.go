@@ -0,0 +1,51 @@
+package nft
+
+import "strconv"
+
+// NftTotalSupply implements nft_total_supply, returning the number of tokens as a decimal string.
+func (nft *NonFungibleToken) NftTotalSupply() string {
+	return strconv.FormatUint(nft.OwnerByID.Length(), 10)
+}
+
+// NftTokens implements nft_tokens: up to limit tokens, in token ID order, starting at fromIndex.
+// A zero limit returns all the remaining tokens.
+func (nft *NonFungibleToken) NftTokens(fromIndex, limit uint64) ([]Token, error) {
+	tokenIDs, err := nft.OwnerByID.KeysRange(fromIndex, limit)
+	if err != nil {
+		return nil, err
+	}
+	return nft.tokens(tokenIDs), nil
+}
+
+// NftSupplyForOwner implements nft_supply_for_owner, returning the number of tokens as a decimal string.
+func (nft *NonFungibleToken) NftSupplyForOwner(accountID string) string {
+	tokens, err := nft.TokensPerOwner.Get(accountID)
+	if err != nil {
+		return "0"
+	}
+	return strconv.FormatUint(tokens.Length(), 10)
+}
+
+// NftTokensForOwner implements nft_tokens_for_owner: up to limit tokens of the account starting at fromIndex.
+// The tokens of an owner aren't sorted. A zero limit returns all the remaining tokens.
+func (nft *NonFungibleToken) NftTokensForOwner(accountID string, fromIndex, limit uint64) ([]Token, error) {
+	tokens, err := nft.TokensPerOwner.Get(accountID)
+	if err != nil {
+		return []Token{}, nil
+	}
+	tokenIDs, err := tokens.Range(fromIndex, limit)
+	if err != nil {
+		return nil, err
+	}
+	return nft.tokens(tokenIDs), nil
+}
+
+func (nft *NonFungibleToken) tokens(tokenIDs []string) []Token {
+	result := make([]Token, 0, len(tokenIDs))
+	for _, tokenID := range tokenIDs {
+		if token := nft.NftToken(tokenID); token != nil {
+			result = append(result, *token)
+		}
+	}
+	return result
+}
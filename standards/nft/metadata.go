@@ -0,0 +1,80 @@
+package nft
+
+import (
+	"encoding/base64"
+	"errors"
+)
+
+// MetadataSpec is the NEP-177 version implemented by the package.
+const MetadataSpec = "nft-1.0.0"
+
+const (
+	ErrInvalidSpec          = "(NFT_ERROR): the metadata spec should be " + MetadataSpec
+	ErrInvalidReferenceHash = "(NFT_ERROR): the reference hash should be 32 bytes encoded in base64"
+	ErrInvalidMediaHash     = "(NFT_ERROR): the media hash should be 32 bytes encoded in base64"
+	ErrMediaHashRequired    = "(NFT_ERROR): the media hash is required when media is set"
+	ErrReferenceHashMissing = "(NFT_ERROR): the reference hash is required when reference is set"
+)
+
+// ContractMetadata is the NEP-177 metadata of the collection, returned by nft_metadata.
+type ContractMetadata struct {
+	Spec          string  `json:"spec"`
+	Name          string  `json:"name"`
+	Symbol        string  `json:"symbol"`
+	Icon          *string `json:"icon"`
+	BaseURI       *string `json:"base_uri"`
+	Reference     *string `json:"reference"`
+	ReferenceHash *string `json:"reference_hash"`
+}
+
+// Validate checks the spec and the reference hash.
+func (m ContractMetadata) Validate() error {
+	if m.Spec != MetadataSpec {
+		return errors.New(ErrInvalidSpec)
+	}
+	if (m.Reference == nil) != (m.ReferenceHash == nil) {
+		return errors.New(ErrReferenceHashMissing)
+	}
+	if m.ReferenceHash != nil && !isHash(*m.ReferenceHash) {
+		return errors.New(ErrInvalidReferenceHash)
+	}
+	return nil
+}
+
+// TokenMetadata is the NEP-177 metadata of a token.
+type TokenMetadata struct {
+	Title         *string `json:"title"`
+	Description   *string `json:"description"`
+	Media         *string `json:"media"`
+	MediaHash     *string `json:"media_hash"`
+	Copies        *uint64 `json:"copies"`
+	IssuedAt      *string `json:"issued_at"`
+	ExpiresAt     *string `json:"expires_at"`
+	StartsAt      *string `json:"starts_at"`
+	UpdatedAt     *string `json:"updated_at"`
+	Extra         *string `json:"extra"`
+	Reference     *string `json:"reference"`
+	ReferenceHash *string `json:"reference_hash"`
+}
+
+// Validate checks that media and reference come with their hashes.
+func (m TokenMetadata) Validate() error {
+	if (m.Media == nil) != (m.MediaHash == nil) {
+		return errors.New(ErrMediaHashRequired)
+	}
+	if m.MediaHash != nil && !isHash(*m.MediaHash) {
+		return errors.New(ErrInvalidMediaHash)
+	}
+	if (m.Reference == nil) != (m.ReferenceHash == nil) {
+		return errors.New(ErrReferenceHashMissing)
+	}
+	if m.ReferenceHash != nil && !isHash(*m.ReferenceHash) {
+		return errors.New(ErrInvalidReferenceHash)
+	}
+	return nil
+}
+
+func isHash(encoded string) bool {
+	hash, err := base64.StdEncoding.DecodeString(encoded)
+	return err == nil && len(hash) == 32
+}
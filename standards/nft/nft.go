@@ -0,0 +1,414 @@
+// Package nft implements the NEP-171 non-fungible token standard with the NEP-177 metadata, NEP-178
+// approval management and NEP-181 enumeration extensions.
+//
+// NonFungibleToken keeps the owners in a TreeMap, so tokens are enumerated in token ID order, the tokens of
+// every owner in an UnorderedSet, and the metadata and approvals of every token in LookupMaps. A contract
+// embeds it in its state and exposes the methods through its own annotated wrappers:
+//
+//	// @contract:state
+//	type Contract struct {
+//		Tokens *nft.NonFungibleToken
+//	}
+//
+//	// @contract:payable min_deposit=0.000000000000000000000001NEAR
+//	func (c *Contract) NftTransfer(receiverId string, tokenId string, approvalId *uint64, memo string) {
+//		if err := c.Tokens.NftTransfer(receiverId, tokenId, approvalId, memo); err != nil {
+//			env.PanicStr(err.Error())
+//		}
+//	}
+//
+// Approvals are paid by the owner: nft_approve charges the attached deposit for the storage of the approval
+// and refunds the rest, and the storage is refunded to the owner when the approvals are cleared.
+//
+// Royalties adds the NEP-199 payouts marketplaces use to split the price of a sale: nft_payout and
+// nft_transfer_payout.
+//
+// Mints, transfers and burns emit the NEP-297 nft_mint, nft_transfer and nft_burn events of NEP-171.
+package nft
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/vlmoon99/near-sdk-go/collections"
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/events"
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/standards/storage"
+	"github.com/vlmoon99/near-sdk-go/system"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+const (
+	// GasForResolveTransfer is the gas reserved for the nft_resolve_transfer callback.
+	GasForResolveTransfer = 5 * types.ONE_TERA_GAS
+
+	// GasForNftTransferCall is the gas nft_transfer_call keeps for itself and the callback;
+	// the rest of the prepaid gas is attached to nft_on_transfer.
+	GasForNftTransferCall = 25*types.ONE_TERA_GAS + GasForResolveTransfer
+
+	// GasForNftApprove is the gas nft_approve keeps for itself; the rest of the prepaid gas is attached to
+	// nft_on_approve.
+	GasForNftApprove = 10 * types.ONE_TERA_GAS
+)
+
+const (
+	// OnTransferMethod is the receiver method called by nft_transfer_call.
+	OnTransferMethod = "nft_on_transfer"
+
+	// ResolveTransferMethod is the callback nft_transfer_call schedules on the token contract.
+	ResolveTransferMethod = "nft_resolve_transfer"
+
+	// OnApproveMethod is the method nft_approve calls on the approved account when a message is given.
+	OnApproveMethod = "nft_on_approve"
+)
+
+const (
+	ErrRequiresOneYocto        = "(NFT_ERROR): requires attached deposit of exactly 1 yoctoNEAR"
+	ErrRequiresAtLeastOneYocto = "(NFT_ERROR): requires attached deposit of at least 1 yoctoNEAR"
+	ErrTokenExists             = "(NFT_ERROR): the token already exists: "
+	ErrTokenNotFound           = "(NFT_ERROR): the token doesn't exist: "
+	ErrSelfTransfer            = "(NFT_ERROR): the token owner and the receiver should be different"
+	ErrSenderNotApproved       = "(NFT_ERROR): the sender isn't approved to transfer the token"
+	ErrApprovalIDMismatch      = "(NFT_ERROR): the approval ID doesn't match"
+	ErrNotOwner                = "(NFT_ERROR): the predecessor doesn't own the token"
+	ErrNotEnoughGas            = "(NFT_ERROR): more gas is required for nft_transfer_call"
+	ErrNotEnoughGasForApprove  = "(NFT_ERROR): more gas is required for nft_approve with a message"
+	ErrInsufficientDeposit     = "(NFT_ERROR): the attached deposit doesn't cover the storage of the approval, required: "
+	ErrPredecessorUnknown      = "(NFT_ERROR): failed to get the predecessor account: "
+)
+
+var zero = types.Uint128{Hi: 0, Lo: 0}
+
+// Token is the NEP-171 view of a token, with its NEP-177 metadata and NEP-178 approvals.
+type Token struct {
+	TokenID            string            `json:"token_id"`
+	OwnerID            string            `json:"owner_id"`
+	Metadata           *TokenMetadata    `json:"metadata,omitempty"`
+	ApprovedAccountIDs map[string]uint64 `json:"approved_account_ids,omitempty"`
+}
+
+// NonFungibleToken is the state of a token collection.
+type NonFungibleToken struct {
+	Metadata           ContractMetadata                                                 `json:"metadata"`
+	OwnerByID          *collections.TreeMap[string, string]                             `json:"owner_by_id"`
+	TokenMetadataByID  *collections.LookupMap[string, TokenMetadata]                    `json:"token_metadata_by_id"`
+	TokensPerOwner     *collections.LookupMap[string, collections.UnorderedSet[string]] `json:"tokens_per_owner"`
+	ApprovalsByID      *collections.LookupMap[string, map[string]uint64]                `json:"approvals_by_id"`
+	NextApprovalIDByID *collections.LookupMap[string, uint64]                           `json:"next_approval_id_by_id"`
+	Prefix             string                                                           `json:"prefix"`
+}
+
+// TransferCallArgs are the arguments nft_transfer_call passes to nft_on_transfer.
+type TransferCallArgs struct {
+	SenderID        string `json:"sender_id"`
+	PreviousOwnerID string `json:"previous_owner_id"`
+	TokenID         string `json:"token_id"`
+	Msg             string `json:"msg"`
+}
+
+// ResolveTransferArgs are the arguments of the nft_resolve_transfer callback.
+type ResolveTransferArgs struct {
+	PreviousOwnerID    string            `json:"previous_owner_id"`
+	ReceiverID         string            `json:"receiver_id"`
+	TokenID            string            `json:"token_id"`
+	ApprovedAccountIDs map[string]uint64 `json:"approved_account_ids"`
+}
+
+// New creates an empty collection stored under prefix. The metadata has to pass ContractMetadata.Validate.
+func New(prefix string, metadata ContractMetadata) (*NonFungibleToken, error) {
+	if err := metadata.Validate(); err != nil {
+		return nil, err
+	}
+	return &NonFungibleToken{
+		Metadata:           metadata,
+		OwnerByID:          collections.NewTreeMap[string, string](prefix + "o"),
+		TokenMetadataByID:  collections.NewLookupMap[string, TokenMetadata](prefix + "m"),
+		TokensPerOwner:     collections.NewLookupMap[string, collections.UnorderedSet[string]](prefix + "t"),
+		ApprovalsByID:      collections.NewLookupMap[string, map[string]uint64](prefix + "a"),
+		NextApprovalIDByID: collections.NewLookupMap[string, uint64](prefix + "n"),
+		Prefix:             prefix,
+	}, nil
+}
+
+// NftMetadata implements nft_metadata.
+func (nft *NonFungibleToken) NftMetadata() ContractMetadata {
+	return nft.Metadata
+}
+
+// OwnerOf returns the owner of an existing token.
+func (nft *NonFungibleToken) OwnerOf(tokenID string) (string, error) {
+	ownerID, err := nft.OwnerByID.Get(tokenID)
+	if err != nil {
+		return "", errors.New(ErrTokenNotFound + tokenID)
+	}
+	return ownerID, nil
+}
+
+// Mint creates the token for ownerID and emits nft_mint. The metadata is optional.
+func (nft *NonFungibleToken) Mint(tokenID, ownerID string, metadata *TokenMetadata, memo string) (Token, error) {
+	if exists, _ := nft.OwnerByID.Contains(tokenID); exists {
+		return Token{}, errors.New(ErrTokenExists + tokenID)
+	}
+	if metadata != nil {
+		if err := metadata.Validate(); err != nil {
+			return Token{}, err
+		}
+		if err := nft.TokenMetadataByID.Insert(tokenID, *metadata); err != nil {
+			return Token{}, err
+		}
+	}
+	if err := nft.OwnerByID.Insert(tokenID, ownerID); err != nil {
+		return Token{}, err
+	}
+	if err := nft.addToOwner(ownerID, tokenID); err != nil {
+		return Token{}, err
+	}
+
+	events.NftMintEvent.Emit(events.NftMint{OwnerID: ownerID, TokenIDs: []string{tokenID}, Memo: memo})
+	return Token{TokenID: tokenID, OwnerID: ownerID, Metadata: metadata}, nil
+}
+
+// Burn destroys the token with its metadata and approvals and emits nft_burn. The storage of the approvals is
+// refunded to the owner.
+func (nft *NonFungibleToken) Burn(tokenID, memo string) error {
+	ownerID, err := nft.OwnerOf(tokenID)
+	if err != nil {
+		return err
+	}
+	if err := nft.OwnerByID.Remove(tokenID); err != nil {
+		return err
+	}
+	if err := nft.removeFromOwner(ownerID, tokenID); err != nil {
+		return err
+	}
+	// Only tokens minted with metadata and tokens approved once have these records, removing them is best effort.
+	nft.TokenMetadataByID.Remove(tokenID)
+	nft.NextApprovalIDByID.Remove(tokenID)
+	if err := nft.clearApprovals(tokenID, ownerID); err != nil {
+		return err
+	}
+
+	events.NftBurnEvent.Emit(events.NftBurn{OwnerID: ownerID, TokenIDs: []string{tokenID}, Memo: memo})
+	return nil
+}
+
+// InternalTransfer moves the token from its owner to the receiver and emits nft_transfer. The sender is the
+// owner or an approved account, in which case approvalID, when given, must match its approval.
+//
+// The approvals of the token are removed and returned with the previous owner; their storage isn't refunded,
+// the caller decides whether to refund or restore them.
+func (nft *NonFungibleToken) InternalTransfer(senderID, receiverID, tokenID string, approvalID *uint64, memo string) (string, map[string]uint64, error) {
+	ownerID, err := nft.OwnerOf(tokenID)
+	if err != nil {
+		return "", nil, err
+	}
+	approvals, _ := nft.ApprovalsByID.Get(tokenID)
+
+	if senderID != ownerID {
+		approved, ok := approvals[senderID]
+		if !ok {
+			return "", nil, errors.New(ErrSenderNotApproved)
+		}
+		if approvalID != nil && *approvalID != approved {
+			return "", nil, errors.New(ErrApprovalIDMismatch)
+		}
+	}
+	if receiverID == ownerID {
+		return "", nil, errors.New(ErrSelfTransfer)
+	}
+
+	if len(approvals) > 0 {
+		if err := nft.ApprovalsByID.Remove(tokenID); err != nil {
+			return "", nil, err
+		}
+	}
+	if err := nft.move(tokenID, ownerID, receiverID); err != nil {
+		return "", nil, err
+	}
+
+	transfer := events.NftTransfer{OldOwnerID: ownerID, NewOwnerID: receiverID, TokenIDs: []string{tokenID}, Memo: memo}
+	if senderID != ownerID {
+		transfer.AuthorizedID = senderID
+	}
+	events.NftTransferEvent.Emit(transfer)
+	return ownerID, approvals, nil
+}
+
+// NftTransfer implements nft_transfer: the predecessor, the owner or an approved account, sends the token to
+// the receiver. The storage of the cleared approvals is refunded to the previous owner. Exactly one
+// yoctoNEAR must be attached.
+func (nft *NonFungibleToken) NftTransfer(receiverID, tokenID string, approvalID *uint64, memo string) error {
+	if err := assertOneYocto(); err != nil {
+		return err
+	}
+	senderID, err := env.GetPredecessorAccountID()
+	if err != nil {
+		return errors.New(ErrPredecessorUnknown + err.Error())
+	}
+	previousOwnerID, approvals, err := nft.InternalTransfer(senderID, receiverID, tokenID, approvalID, memo)
+	if err != nil {
+		return err
+	}
+	refundApprovals(previousOwnerID, nft.approvalsStorageUsage(tokenID, approvals))
+	return nil
+}
+
+// NftTransferCall implements nft_transfer_call: it transfers the token to the receiver, calls nft_on_transfer
+// on it and schedules nft_resolve_transfer on this contract. Exactly one yoctoNEAR must be attached.
+//
+// The wrapper returns the resulting promise with Value, so the caller learns whether the transfer was kept.
+func (nft *NonFungibleToken) NftTransferCall(receiverID, tokenID string, approvalID *uint64, memo, msg string) (*promise.Promise, error) {
+	if err := assertOneYocto(); err != nil {
+		return nil, err
+	}
+	prepaidGas := env.GetPrepaidGas().Inner
+	if prepaidGas <= GasForNftTransferCall {
+		return nil, errors.New(ErrNotEnoughGas)
+	}
+	senderID, err := env.GetPredecessorAccountID()
+	if err != nil {
+		return nil, errors.New(ErrPredecessorUnknown + err.Error())
+	}
+	previousOwnerID, approvals, err := nft.InternalTransfer(senderID, receiverID, tokenID, approvalID, memo)
+	if err != nil {
+		return nil, err
+	}
+
+	return promise.NewCrossContract(receiverID).
+		Gas(prepaidGas-GasForNftTransferCall).
+		Call(OnTransferMethod, TransferCallArgs{SenderID: senderID, PreviousOwnerID: previousOwnerID, TokenID: tokenID, Msg: msg}).
+		Gas(GasForResolveTransfer).
+		Then(ResolveTransferMethod, ResolveTransferArgs{
+			PreviousOwnerID:    previousOwnerID,
+			ReceiverID:         receiverID,
+			TokenID:            tokenID,
+			ApprovedAccountIDs: approvals,
+		}), nil
+}
+
+// NftResolveTransfer implements nft_resolve_transfer. The result is the outcome of nft_on_transfer, which
+// returns true when the token should go back to the previous owner. A failed nft_on_transfer returns it too,
+// unless the receiver no longer owns it. A returned token gets its approvals back, otherwise their storage is
+// refunded to the previous owner.
+//
+// It returns whether the token stayed with the receiver. The wrapper must be a private promise callback.
+func (nft *NonFungibleToken) NftResolveTransfer(previousOwnerID, receiverID, tokenID string, approvedAccountIDs map[string]uint64, result promise.PromiseResult) (bool, error) {
+	returnToken := true
+	if result.Success {
+		if err := json.Unmarshal(result.Data, &returnToken); err != nil {
+			returnToken = true
+		}
+	}
+
+	ownerID, err := nft.OwnerByID.Get(tokenID)
+	if !returnToken || err != nil || ownerID != receiverID {
+		// The transfer is kept, or the receiver burned or passed on the token in the meantime.
+		refundApprovals(previousOwnerID, nft.approvalsStorageUsage(tokenID, approvedAccountIDs))
+		return true, nil
+	}
+
+	if err := nft.clearApprovals(tokenID, receiverID); err != nil {
+		return false, err
+	}
+	if err := nft.move(tokenID, receiverID, previousOwnerID); err != nil {
+		return false, err
+	}
+	if len(approvedAccountIDs) > 0 {
+		if err := nft.ApprovalsByID.Insert(tokenID, approvedAccountIDs); err != nil {
+			return false, err
+		}
+	}
+
+	events.NftTransferEvent.Emit(events.NftTransfer{OldOwnerID: receiverID, NewOwnerID: previousOwnerID, TokenIDs: []string{tokenID}})
+	return false, nil
+}
+
+// NftToken implements nft_token; it returns nil for unknown tokens.
+func (nft *NonFungibleToken) NftToken(tokenID string) *Token {
+	ownerID, err := nft.OwnerByID.Get(tokenID)
+	if err != nil {
+		return nil
+	}
+	token := &Token{TokenID: tokenID, OwnerID: ownerID}
+	if metadata, err := nft.TokenMetadataByID.Get(tokenID); err == nil {
+		token.Metadata = &metadata
+	}
+	if approvals, err := nft.ApprovalsByID.Get(tokenID); err == nil && len(approvals) > 0 {
+		token.ApprovedAccountIDs = approvals
+	}
+	return token
+}
+
+// move changes the owner of the token without any check.
+func (nft *NonFungibleToken) move(tokenID, fromID, toID string) error {
+	if err := nft.removeFromOwner(fromID, tokenID); err != nil {
+		return err
+	}
+	if err := nft.addToOwner(toID, tokenID); err != nil {
+		return err
+	}
+	return nft.OwnerByID.Insert(tokenID, toID)
+}
+
+func (nft *NonFungibleToken) addToOwner(ownerID, tokenID string) error {
+	tokens, err := nft.TokensPerOwner.Get(ownerID)
+	if err != nil {
+		tokens = *collections.NewUnorderedSet[string](nft.Prefix + "s" + ownerID)
+	}
+	if err := tokens.Insert(tokenID); err != nil {
+		return err
+	}
+	return nft.TokensPerOwner.Insert(ownerID, tokens)
+}
+
+func (nft *NonFungibleToken) removeFromOwner(ownerID, tokenID string) error {
+	tokens, err := nft.TokensPerOwner.Get(ownerID)
+	if err != nil {
+		return nil
+	}
+	if err := tokens.Remove(tokenID); err != nil {
+		return err
+	}
+	if tokens.Length() == 0 {
+		return nft.TokensPerOwner.Remove(ownerID)
+	}
+	return nft.TokensPerOwner.Insert(ownerID, tokens)
+}
+
+// approvalsStorageUsage returns the bytes of the approvals record of a token.
+func (nft *NonFungibleToken) approvalsStorageUsage(tokenID string, approvals map[string]uint64) uint64 {
+	if len(approvals) == 0 {
+		return 0
+	}
+	data, _ := json.Marshal(approvals)
+	return uint64(len(nft.ApprovalsByID.Prefix)+1+len(tokenID)+len(data)) + system.StorageNumExtraBytesRecord
+}
+
+func assertOneYocto() error {
+	deposit, err := env.GetAttachedDeposit()
+	if err != nil || deposit.Cmp(types.Uint128{Hi: 0, Lo: 1}) != 0 {
+		return errors.New(ErrRequiresOneYocto)
+	}
+	return nil
+}
+
+func assertAtLeastOneYocto() (types.Uint128, error) {
+	deposit, err := env.GetAttachedDeposit()
+	if err != nil || deposit.Cmp(zero) == 0 {
+		return zero, errors.New(ErrRequiresAtLeastOneYocto)
+	}
+	return deposit, nil
+}
+
+// refundApprovals refunds the storage of cleared approvals to the owner who paid for it.
+func refundApprovals(ownerID string, bytes uint64) {
+	if bytes == 0 {
+		return
+	}
+	amount, err := storage.CostOf(bytes)
+	if err != nil {
+		return
+	}
+	promise.CreateBatch(ownerID).Transfer(amount)
+}
@@ -0,0 +1,374 @@
+package nft
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/events"
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/testutils"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+var oneYocto = types.Uint128{Hi: 0, Lo: 1}
+
+func oneNear() types.Uint128 {
+	amount, _ := types.U128FromString("1000000000000000000000000")
+	return amount
+}
+
+// setup mints tokens "1", "2" and "3" to alice.near.
+func setup(t *testing.T) (*NonFungibleToken, *testutils.Context) {
+	t.Helper()
+	ctx := testutils.NewContextBuilder().CurrentAccount("nft.near").Build()
+
+	tokens, err := New("n", ContractMetadata{Spec: MetadataSpec, Name: "Tokens", Symbol: "TKN"})
+	if err != nil {
+		t.Fatalf("failed to create the collection: %v", err)
+	}
+	title := "first"
+	for _, tokenID := range []string{"1", "2", "3"} {
+		var metadata *TokenMetadata
+		if tokenID == "1" {
+			metadata = &TokenMetadata{Title: &title}
+		}
+		if _, err := tokens.Mint(tokenID, "alice.near", metadata, ""); err != nil {
+			t.Fatalf("failed to mint %s: %v", tokenID, err)
+		}
+	}
+	return tokens, ctx
+}
+
+// call builds the context of a call made by predecessor on top of the state left by the previous one.
+func call(previous *testutils.Context, predecessor string) *testutils.ContextBuilder {
+	return testutils.NewContextBuilder().
+		CurrentAccount("nft.near").
+		Predecessor(predecessor).
+		State(previous.Storage).
+		Balance(previous.AccountBalanceSys)
+}
+
+func expectOwner(t *testing.T, tokens *NonFungibleToken, tokenID, expected string) {
+	t.Helper()
+	if token := tokens.NftToken(tokenID); token == nil || token.OwnerID != expected {
+		t.Errorf("expected %s to own token %s, got %+v", expected, tokenID, token)
+	}
+}
+
+func TestMetadataValidate(t *testing.T) {
+	hash := "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+	short := "AAAA"
+	media := "https://example.com/1.png"
+	tests := []struct {
+		name     string
+		metadata TokenMetadata
+		err      string
+	}{
+		{"empty", TokenMetadata{}, ""},
+		{"media with hash", TokenMetadata{Media: &media, MediaHash: &hash}, ""},
+		{"media without hash", TokenMetadata{Media: &media}, ErrMediaHashRequired},
+		{"short media hash", TokenMetadata{Media: &media, MediaHash: &short}, ErrInvalidMediaHash},
+		{"reference without hash", TokenMetadata{Reference: &media}, ErrReferenceHashMissing},
+	}
+	for _, tt := range tests {
+		err := tt.metadata.Validate()
+		if (tt.err == "" && err != nil) || (tt.err != "" && (err == nil || err.Error() != tt.err)) {
+			t.Errorf("%s: expected %q, got %v", tt.name, tt.err, err)
+		}
+	}
+
+	if _, err := New("n", ContractMetadata{Spec: "nft-2.0.0"}); err == nil || err.Error() != ErrInvalidSpec {
+		t.Errorf("expected %q, got %v", ErrInvalidSpec, err)
+	}
+}
+
+func TestMintAndBurn(t *testing.T) {
+	tokens, ctx := setup(t)
+
+	token := tokens.NftToken("1")
+	if token == nil || token.OwnerID != "alice.near" || token.Metadata == nil || *token.Metadata.Title != "first" {
+		t.Fatalf("unexpected token %+v", token)
+	}
+	if token := tokens.NftToken("2"); token == nil || token.Metadata != nil {
+		t.Errorf("expected token 2 without metadata, got %+v", token)
+	}
+	logged := events.ParseLogs(ctx.Logs())
+	if len(logged) != 3 || logged[0].Event != "nft_mint" || logged[0].Standard != events.NEP171Standard {
+		t.Fatalf("expected nft_mint events, got %+v", logged)
+	}
+	if _, err := tokens.Mint("1", "bob.near", nil, ""); err == nil || err.Error() != ErrTokenExists+"1" {
+		t.Errorf("expected %q, got %v", ErrTokenExists+"1", err)
+	}
+
+	ctx = call(ctx, "nft.near").Build()
+	if err := tokens.Burn("1", "burn"); err != nil {
+		t.Fatalf("failed to burn: %v", err)
+	}
+	if tokens.NftToken("1") != nil {
+		t.Errorf("expected token 1 to be burned")
+	}
+	if supply := tokens.NftSupplyForOwner("alice.near"); supply != "2" {
+		t.Errorf("expected alice.near to own 2 tokens, got %s", supply)
+	}
+	if logged := events.ParseLogs(ctx.Logs()); len(logged) != 1 || logged[0].Event != "nft_burn" {
+		t.Errorf("expected an nft_burn event, got %+v", logged)
+	}
+	if err := tokens.Burn("1", ""); err == nil || err.Error() != ErrTokenNotFound+"1" {
+		t.Errorf("expected %q, got %v", ErrTokenNotFound+"1", err)
+	}
+}
+
+func TestNftTransfer(t *testing.T) {
+	tokens, ctx := setup(t)
+
+	call(ctx, "alice.near").Build()
+	if err := tokens.NftTransfer("bob.near", "1", nil, ""); err == nil || err.Error() != ErrRequiresOneYocto {
+		t.Errorf("expected %q, got %v", ErrRequiresOneYocto, err)
+	}
+
+	ctx = call(ctx, "alice.near").DepositYocto(oneYocto).Build()
+	if err := tokens.NftTransfer("bob.near", "1", nil, "gift"); err != nil {
+		t.Fatalf("transfer failed: %v", err)
+	}
+	expectOwner(t, tokens, "1", "bob.near")
+
+	logged := events.ParseLogs(ctx.Logs())
+	if len(logged) != 1 || logged[0].Event != "nft_transfer" {
+		t.Fatalf("expected an nft_transfer event, got %+v", logged)
+	}
+	transfers, err := events.NftTransferEvent.Decode(logged[0])
+	if err != nil || len(transfers) != 1 ||
+		transfers[0].OldOwnerID != "alice.near" || transfers[0].NewOwnerID != "bob.near" ||
+		transfers[0].AuthorizedID != "" || transfers[0].Memo != "gift" {
+		t.Errorf("unexpected event data %+v: %v", transfers, err)
+	}
+
+	tests := []struct {
+		predecessor string
+		receiver    string
+		tokenID     string
+		err         string
+	}{
+		{"alice.near", "carol.near", "1", ErrSenderNotApproved},
+		{"bob.near", "bob.near", "1", ErrSelfTransfer},
+		{"alice.near", "bob.near", "4", ErrTokenNotFound + "4"},
+	}
+	for _, tt := range tests {
+		call(ctx, tt.predecessor).DepositYocto(oneYocto).Build()
+		if err := tokens.NftTransfer(tt.receiver, tt.tokenID, nil, ""); err == nil || err.Error() != tt.err {
+			t.Errorf("transfer of %s by %s: expected %q, got %v", tt.tokenID, tt.predecessor, tt.err, err)
+		}
+	}
+}
+
+func TestApprovals(t *testing.T) {
+	tokens, ctx := setup(t)
+
+	call(ctx, "alice.near").Build()
+	if _, err := tokens.NftApprove("1", "market.near", nil); err == nil || err.Error() != ErrRequiresAtLeastOneYocto {
+		t.Errorf("expected %q, got %v", ErrRequiresAtLeastOneYocto, err)
+	}
+	call(ctx, "bob.near").DepositYocto(oneNear()).Build()
+	if _, err := tokens.NftApprove("1", "market.near", nil); err == nil || err.Error() != ErrNotOwner {
+		t.Errorf("expected %q, got %v", ErrNotOwner, err)
+	}
+	call(ctx, "alice.near").DepositYocto(oneYocto).Build()
+	if _, err := tokens.NftApprove("1", "market.near", nil); err == nil || !strings.HasPrefix(err.Error(), ErrInsufficientDeposit) {
+		t.Errorf("expected %q, got %v", ErrInsufficientDeposit, err)
+	}
+
+	ctx = call(ctx, "alice.near").DepositYocto(oneNear()).Build()
+	if _, err := tokens.NftApprove("1", "market.near", nil); err != nil {
+		t.Fatalf("approve failed: %v", err)
+	}
+	if ctx.AccountBalanceSys.Cmp(oneNear()) >= 0 {
+		t.Errorf("expected the excess deposit to be refunded, got balance %s", ctx.AccountBalanceSys.String())
+	}
+	msg := "list"
+	call(ctx, "alice.near").DepositYocto(oneNear()).Gas(GasForNftApprove).Build()
+	if _, err := tokens.NftApprove("1", "auction.near", &msg); err == nil || err.Error() != ErrNotEnoughGasForApprove {
+		t.Errorf("expected %q, got %v", ErrNotEnoughGasForApprove, err)
+	}
+	ctx = call(ctx, "alice.near").DepositYocto(oneNear()).Gas(100 * types.ONE_TERA_GAS).Build()
+	if _, err := tokens.NftApprove("1", "auction.near", &msg); err != nil {
+		t.Fatalf("approve failed: %v", err)
+	}
+	if len(ctx.Promises) != 1 || ctx.Promises[0].AccountId != "auction.near" || ctx.Promises[0].FunctionName != OnApproveMethod ||
+		string(ctx.Promises[0].Arguments) != `{"token_id":"1","owner_id":"alice.near","approval_id":1,"msg":"list"}` {
+		t.Errorf("unexpected nft_on_approve call %+v", ctx.Promises)
+	}
+
+	approvalID := uint64(0)
+	wrongID := uint64(1)
+	if !tokens.NftIsApproved("1", "market.near", nil) || !tokens.NftIsApproved("1", "market.near", &approvalID) {
+		t.Errorf("expected market.near to be approved with ID 0")
+	}
+	if tokens.NftIsApproved("1", "market.near", &wrongID) || tokens.NftIsApproved("2", "market.near", nil) {
+		t.Errorf("unexpected approval")
+	}
+	if token := tokens.NftToken("1"); len(token.ApprovedAccountIDs) != 2 {
+		t.Errorf("expected 2 approvals, got %+v", token.ApprovedAccountIDs)
+	}
+
+	balance := ctx.AccountBalanceSys
+	ctx = call(ctx, "alice.near").DepositYocto(oneYocto).Build()
+	if err := tokens.NftRevoke("1", "auction.near"); err != nil {
+		t.Fatalf("revoke failed: %v", err)
+	}
+	if tokens.NftIsApproved("1", "auction.near", nil) || !tokens.NftIsApproved("1", "market.near", nil) {
+		t.Errorf("expected only auction.near to be revoked")
+	}
+	if ctx.AccountBalanceSys.Cmp(balance) >= 0 {
+		t.Errorf("expected the approval storage to be refunded, got balance %s", ctx.AccountBalanceSys.String())
+	}
+
+	// An approved account transfers the token, which clears the approvals.
+	call(ctx, "market.near").DepositYocto(oneYocto).Build()
+	if err := tokens.NftTransfer("carol.near", "1", &wrongID, ""); err == nil || err.Error() != ErrApprovalIDMismatch {
+		t.Errorf("expected %q, got %v", ErrApprovalIDMismatch, err)
+	}
+	ctx = call(ctx, "market.near").DepositYocto(oneYocto).Build()
+	if err := tokens.NftTransfer("carol.near", "1", &approvalID, ""); err != nil {
+		t.Fatalf("approved transfer failed: %v", err)
+	}
+	expectOwner(t, tokens, "1", "carol.near")
+	if tokens.NftIsApproved("1", "market.near", nil) {
+		t.Errorf("expected the approvals to be cleared by the transfer")
+	}
+	if transfers, err := events.NftTransferEvent.Find(ctx.Logs()); err != nil || transfers[0].AuthorizedID != "market.near" {
+		t.Errorf("expected market.near as the authorized ID, got %+v: %v", transfers, err)
+	}
+
+	ctx = call(ctx, "carol.near").DepositYocto(oneNear()).Build()
+	if _, err := tokens.NftApprove("1", "market.near", nil); err != nil {
+		t.Fatalf("approve failed: %v", err)
+	}
+	nextID := uint64(2)
+	if !tokens.NftIsApproved("1", "market.near", &nextID) {
+		t.Errorf("expected approval IDs to keep increasing")
+	}
+	call(ctx, "carol.near").DepositYocto(oneYocto).Build()
+	if err := tokens.NftRevokeAll("1"); err != nil {
+		t.Fatalf("revoke all failed: %v", err)
+	}
+	if token := tokens.NftToken("1"); token.ApprovedAccountIDs != nil {
+		t.Errorf("expected no approvals, got %+v", token.ApprovedAccountIDs)
+	}
+}
+
+func TestNftTransferCall(t *testing.T) {
+	tokens, ctx := setup(t)
+
+	call(ctx, "alice.near").DepositYocto(oneYocto).Gas(GasForNftTransferCall).Build()
+	if _, err := tokens.NftTransferCall("bob.near", "1", nil, "", "msg"); err == nil || err.Error() != ErrNotEnoughGas {
+		t.Errorf("expected %q, got %v", ErrNotEnoughGas, err)
+	}
+
+	ctx = call(ctx, "alice.near").DepositYocto(oneYocto).Gas(100 * types.ONE_TERA_GAS).Build()
+	if _, err := tokens.NftTransferCall("bob.near", "1", nil, "", "msg"); err != nil {
+		t.Fatalf("transfer call failed: %v", err)
+	}
+	expectOwner(t, tokens, "1", "bob.near")
+	if len(ctx.Promises) != 2 {
+		t.Fatalf("expected nft_on_transfer and its callback, got %d promises", len(ctx.Promises))
+	}
+	onTransfer, resolve := ctx.Promises[0], ctx.Promises[1]
+	if onTransfer.AccountId != "bob.near" || onTransfer.FunctionName != OnTransferMethod ||
+		string(onTransfer.Arguments) != `{"sender_id":"alice.near","previous_owner_id":"alice.near","token_id":"1","msg":"msg"}` {
+		t.Errorf("unexpected nft_on_transfer call %+v", onTransfer)
+	}
+	if resolve.AccountId != "nft.near" || resolve.FunctionName != ResolveTransferMethod ||
+		string(resolve.Arguments) != `{"previous_owner_id":"alice.near","receiver_id":"bob.near","token_id":"1","approved_account_ids":null}` {
+		t.Errorf("unexpected nft_resolve_transfer callback %+v", resolve)
+	}
+	if onTransfer.Gas != 100*types.ONE_TERA_GAS-GasForNftTransferCall || resolve.Gas != GasForResolveTransfer {
+		t.Errorf("unexpected gas split %d/%d", onTransfer.Gas, resolve.Gas)
+	}
+}
+
+func TestNftResolveTransfer(t *testing.T) {
+	tests := []struct {
+		name       string
+		result     promise.PromiseResult
+		moved      bool
+		kept       bool
+		owner      string
+		approvals  bool
+		eventCount int
+	}{
+		{"kept", promise.NewPromiseResult(1, []byte(`false`)), false, true, "bob.near", false, 0},
+		{"returned", promise.NewPromiseResult(1, []byte(`true`)), false, false, "alice.near", true, 1},
+		{"invalid result", promise.NewPromiseResult(1, []byte(`"no"`)), false, false, "alice.near", true, 1},
+		{"receiver failed", promise.NewPromiseResult(2, nil), false, false, "alice.near", true, 1},
+		{"receiver passed it on", promise.NewPromiseResult(1, []byte(`true`)), true, true, "carol.near", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, ctx := setup(t)
+			ctx = call(ctx, "alice.near").DepositYocto(oneNear()).Build()
+			if _, err := tokens.NftApprove("1", "market.near", nil); err != nil {
+				t.Fatalf("approve failed: %v", err)
+			}
+			ctx = call(ctx, "alice.near").DepositYocto(oneYocto).Gas(100 * types.ONE_TERA_GAS).Build()
+			if _, err := tokens.NftTransferCall("bob.near", "1", nil, "", ""); err != nil {
+				t.Fatalf("transfer call failed: %v", err)
+			}
+			if tt.moved {
+				ctx = call(ctx, "bob.near").DepositYocto(oneYocto).Build()
+				if err := tokens.NftTransfer("carol.near", "1", nil, ""); err != nil {
+					t.Fatalf("transfer failed: %v", err)
+				}
+			}
+
+			ctx = call(ctx, "nft.near").Build()
+			kept, err := tokens.NftResolveTransfer("alice.near", "bob.near", "1", map[string]uint64{"market.near": 0}, tt.result)
+			if err != nil {
+				t.Fatalf("resolve failed: %v", err)
+			}
+			if kept != tt.kept {
+				t.Errorf("expected kept %t, got %t", tt.kept, kept)
+			}
+			expectOwner(t, tokens, "1", tt.owner)
+			if approved := tokens.NftIsApproved("1", "market.near", nil); approved != tt.approvals {
+				t.Errorf("expected the approvals restored %t, got %t", tt.approvals, approved)
+			}
+			if logged := events.ParseLogs(ctx.Logs()); len(logged) != tt.eventCount {
+				t.Errorf("expected %d events, got %+v", tt.eventCount, logged)
+			}
+		})
+	}
+}
+
+func TestEnumeration(t *testing.T) {
+	tokens, ctx := setup(t)
+	call(ctx, "alice.near").DepositYocto(oneYocto).Build()
+	if err := tokens.NftTransfer("bob.near", "2", nil, ""); err != nil {
+		t.Fatalf("transfer failed: %v", err)
+	}
+
+	if supply := tokens.NftTotalSupply(); supply != "3" {
+		t.Errorf("expected a total supply of 3, got %s", supply)
+	}
+	page, err := tokens.NftTokens(1, 1)
+	if err != nil || len(page) != 1 || page[0].TokenID != "2" || page[0].OwnerID != "bob.near" {
+		t.Errorf("unexpected page %+v: %v", page, err)
+	}
+	if all, _ := tokens.NftTokens(0, 0); len(all) != 3 {
+		t.Errorf("expected all 3 tokens, got %+v", all)
+	}
+	if rest, _ := tokens.NftTokens(5, 0); len(rest) != 0 {
+		t.Errorf("expected no tokens past the end, got %+v", rest)
+	}
+
+	if supply := tokens.NftSupplyForOwner("alice.near"); supply != "2" {
+		t.Errorf("expected alice.near to own 2 tokens, got %s", supply)
+	}
+	owned, err := tokens.NftTokensForOwner("bob.near", 0, 10)
+	if err != nil || len(owned) != 1 || owned[0].TokenID != "2" {
+		t.Errorf("unexpected tokens of bob.near %+v: %v", owned, err)
+	}
+	if owned, _ := tokens.NftTokensForOwner("carol.near", 0, 10); len(owned) != 0 {
+		t.Errorf("expected no tokens for carol.near, got %+v", owned)
+	}
+}
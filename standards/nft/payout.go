@@ -0,0 +1,172 @@
+package nft
+
+import (
+	"errors"
+	"math/bits"
+
+	"github.com/vlmoon99/near-sdk-go/collections"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+// MaxBasisPoints is 100% in basis points, the unit of royalties.
+const MaxBasisPoints = 10000
+
+// DefaultMaxPayouts is the number of payouts, royalties and the owner share, NewRoyalties allows per token.
+// It keeps nft_payout and the transfers a marketplace makes from its result within the gas limit.
+const DefaultMaxPayouts = 10
+
+const (
+	ErrRoyaltiesTooHigh  = "(NFT_ERROR): the royalties add up to more than 100%"
+	ErrTooManyRoyalties  = "(NFT_ERROR): the royalties exceed the maximum number of payouts"
+	ErrTooManyPayouts    = "(NFT_ERROR): the payout has more receivers than max_len_payout"
+	ErrPayoutOverflow    = "(NFT_ERROR): payout amount overflow"
+	ErrZeroMaxLenPayout  = "(NFT_ERROR): max_len_payout should be positive"
+	ErrRoyaltyZeroPoints = "(NFT_ERROR): a royalty should be at least 1 basis point"
+	ErrRoyaltiesExceed   = "(NFT_ERROR): the royalties add up to more than the balance"
+)
+
+// Payout is the NEP-199 split of a sale: the amount, a decimal string, each account receives.
+type Payout struct {
+	Payout map[string]string `json:"payout"`
+}
+
+// Royalties keeps the royalties of every token, in basis points per receiving account. The owner of the token
+// receives what the royalties leave of a sale.
+type Royalties struct {
+	ByID *collections.LookupMap[string, map[string]uint16] `json:"by_id"`
+	// MaxPayouts bounds the receivers of a payout, the owner included.
+	MaxPayouts uint32 `json:"max_payouts"`
+}
+
+// NewRoyalties creates the royalties of a collection under prefix, with at most DefaultMaxPayouts payouts.
+func NewRoyalties(prefix string) *Royalties {
+	return &Royalties{
+		ByID:       collections.NewLookupMap[string, map[string]uint16](prefix),
+		MaxPayouts: DefaultMaxPayouts,
+	}
+}
+
+// SetRoyalties stores the royalties of a token, usually right after minting it. They must add up to at most
+// MaxBasisPoints and leave room for the owner payout within MaxPayouts.
+func (r *Royalties) SetRoyalties(tokenID string, royalties map[string]uint16) error {
+	if uint64(len(royalties))+1 > uint64(r.MaxPayouts) {
+		return errors.New(ErrTooManyRoyalties)
+	}
+	var total uint32
+	for _, points := range royalties {
+		if points == 0 {
+			return errors.New(ErrRoyaltyZeroPoints)
+		}
+		total += uint32(points)
+	}
+	if total > MaxBasisPoints {
+		return errors.New(ErrRoyaltiesTooHigh)
+	}
+	if len(royalties) == 0 {
+		r.RemoveRoyalties(tokenID)
+		return nil
+	}
+	return r.ByID.Insert(tokenID, royalties)
+}
+
+// RoyaltiesOf returns the royalties of a token; tokens without royalties pay everything to their owner.
+func (r *Royalties) RoyaltiesOf(tokenID string) map[string]uint16 {
+	royalties, err := r.ByID.Get(tokenID)
+	if err != nil {
+		return map[string]uint16{}
+	}
+	return royalties
+}
+
+// RemoveRoyalties removes the royalties of a token, for example when it is burned.
+func (r *Royalties) RemoveRoyalties(tokenID string) {
+	if exists, _ := r.ByID.Contains(tokenID); exists {
+		r.ByID.Remove(tokenID)
+	}
+}
+
+// Payout splits balance between the royalties of the token and its owner. Each royalty is rounded down, so the
+// owner also receives the remainder and the amounts add up to balance. With maxLenPayout, a payout to more
+// accounts fails.
+func (r *Royalties) Payout(ownerID, tokenID string, balance types.Uint128, maxLenPayout *uint32) (Payout, error) {
+	royalties := r.RoyaltiesOf(tokenID)
+	receivers := len(royalties)
+	if _, ok := royalties[ownerID]; !ok {
+		receivers++
+	}
+	if maxLenPayout != nil {
+		if *maxLenPayout == 0 {
+			return Payout{}, errors.New(ErrZeroMaxLenPayout)
+		}
+		if uint64(receivers) > uint64(*maxLenPayout) {
+			return Payout{}, errors.New(ErrTooManyPayouts)
+		}
+	}
+
+	amounts := make(map[string]types.Uint128, receivers)
+	remainder := balance
+	for accountID, points := range royalties {
+		amount, err := royalty(balance, points)
+		if err != nil {
+			return Payout{}, err
+		}
+		amounts[accountID] = amount
+		if remainder, err = remainder.Sub(amount); err != nil {
+			return Payout{}, errors.New(ErrRoyaltiesExceed)
+		}
+	}
+	ownerAmount, err := amounts[ownerID].Add(remainder)
+	if err != nil {
+		return Payout{}, errors.New(ErrPayoutOverflow)
+	}
+	amounts[ownerID] = ownerAmount
+
+	payout := Payout{Payout: make(map[string]string, len(amounts))}
+	for accountID, amount := range amounts {
+		payout.Payout[accountID] = amount.String()
+	}
+	return payout, nil
+}
+
+// NftPayout implements nft_payout: the payout of a sale of the token for balance.
+func (r *Royalties) NftPayout(tokens *NonFungibleToken, tokenID string, balance types.Uint128, maxLenPayout *uint32) (Payout, error) {
+	ownerID, err := tokens.OwnerOf(tokenID)
+	if err != nil {
+		return Payout{}, err
+	}
+	return r.Payout(ownerID, tokenID, balance, maxLenPayout)
+}
+
+// NftTransferPayout implements nft_transfer_payout: it transfers the token like nft_transfer and returns the
+// payout of the sale to the previous owner. Exactly one yoctoNEAR must be attached.
+func (r *Royalties) NftTransferPayout(tokens *NonFungibleToken, receiverID, tokenID string, approvalID *uint64, memo string, balance types.Uint128, maxLenPayout *uint32) (Payout, error) {
+	ownerID, err := tokens.OwnerOf(tokenID)
+	if err != nil {
+		return Payout{}, err
+	}
+	payout, err := r.Payout(ownerID, tokenID, balance, maxLenPayout)
+	if err != nil {
+		return Payout{}, err
+	}
+	if err := tokens.NftTransfer(receiverID, tokenID, approvalID, memo); err != nil {
+		return Payout{}, err
+	}
+	return payout, nil
+}
+
+// royalty returns points basis points of balance, rounded down. It divides first, so the whole range of
+// balance is supported: balance = q*MaxBasisPoints + r gives q*points + r*points/MaxBasisPoints.
+func royalty(balance types.Uint128, points uint16) (types.Uint128, error) {
+	q, r := balance.QuoRem64(MaxBasisPoints)
+	overflow, hi := bits.Mul64(q.Hi, uint64(points))
+	carry, lo := bits.Mul64(q.Lo, uint64(points))
+	hi, sumCarry := bits.Add64(hi, carry, 0)
+	if overflow != 0 || sumCarry != 0 {
+		return zero, errors.New(ErrPayoutOverflow)
+	}
+	amount, err := types.Uint128{Hi: hi, Lo: lo}.Add(types.U64ToUint128(r * uint64(points) / MaxBasisPoints))
+	if err != nil {
+		return zero, errors.New(ErrPayoutOverflow)
+	}
+	return amount, nil
+}
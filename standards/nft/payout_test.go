@@ -0,0 +1,134 @@
+package nft
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+func TestSetRoyalties(t *testing.T) {
+	setup(t)
+	royalties := NewRoyalties("r")
+
+	tests := []struct {
+		name      string
+		royalties map[string]uint16
+		err       string
+	}{
+		{"valid", map[string]uint16{"artist.near": 1000, "label.near": 500}, ""},
+		{"all of it", map[string]uint16{"artist.near": MaxBasisPoints}, ""},
+		{"too high", map[string]uint16{"artist.near": 9000, "label.near": 1001}, ErrRoyaltiesTooHigh},
+		{"zero", map[string]uint16{"artist.near": 0}, ErrRoyaltyZeroPoints},
+	}
+	for _, tt := range tests {
+		err := royalties.SetRoyalties("1", tt.royalties)
+		if (tt.err == "" && err != nil) || (tt.err != "" && (err == nil || err.Error() != tt.err)) {
+			t.Errorf("%s: expected %q, got %v", tt.name, tt.err, err)
+		}
+	}
+
+	royalties.MaxPayouts = 2
+	if err := royalties.SetRoyalties("1", map[string]uint16{"a.near": 1, "b.near": 1}); err == nil || err.Error() != ErrTooManyRoyalties {
+		t.Errorf("expected %q, got %v", ErrTooManyRoyalties, err)
+	}
+	if err := royalties.SetRoyalties("1", map[string]uint16{}); err != nil || len(royalties.RoyaltiesOf("1")) != 0 {
+		t.Errorf("expected empty royalties to be removed, got %v %v", royalties.RoyaltiesOf("1"), err)
+	}
+}
+
+func TestNftPayout(t *testing.T) {
+	tokens, _ := setup(t)
+	royalties := NewRoyalties("r")
+	if err := royalties.SetRoyalties("1", map[string]uint16{"artist.near": 1000, "label.near": 333}); err != nil {
+		t.Fatalf("failed to set royalties: %v", err)
+	}
+
+	payout, err := royalties.NftPayout(tokens, "1", types.U64ToUint128(1001), nil)
+	if err != nil {
+		t.Fatalf("payout failed: %v", err)
+	}
+	expected := map[string]string{"artist.near": "100", "label.near": "33", "alice.near": "868"}
+	if len(payout.Payout) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, payout.Payout)
+	}
+	for accountID, amount := range expected {
+		if payout.Payout[accountID] != amount {
+			t.Errorf("expected %s to receive %s, got %s", accountID, amount, payout.Payout[accountID])
+		}
+	}
+
+	// Amounts beyond uint64 are split with 128-bit arithmetic.
+	large, _ := types.U128FromString("100000000000000000000000000")
+	payout, _ = royalties.NftPayout(tokens, "1", large, nil)
+	if payout.Payout["artist.near"] != "10000000000000000000000000" {
+		t.Errorf("unexpected royalty %s", payout.Payout["artist.near"])
+	}
+
+	two, none := uint32(2), uint32(0)
+	if _, err := royalties.NftPayout(tokens, "1", large, &two); err == nil || err.Error() != ErrTooManyPayouts {
+		t.Errorf("expected %q, got %v", ErrTooManyPayouts, err)
+	}
+	if _, err := royalties.NftPayout(tokens, "1", large, &none); err == nil || err.Error() != ErrZeroMaxLenPayout {
+		t.Errorf("expected %q, got %v", ErrZeroMaxLenPayout, err)
+	}
+	if payout, err := royalties.NftPayout(tokens, "2", large, &two); err != nil || payout.Payout["alice.near"] != large.String() {
+		t.Errorf("expected the owner to receive everything without royalties, got %v %v", payout, err)
+	}
+	if _, err := royalties.NftPayout(tokens, "9", large, nil); err == nil || err.Error() != ErrTokenNotFound+"9" {
+		t.Errorf("expected %q, got %v", ErrTokenNotFound+"9", err)
+	}
+}
+
+func TestNftTransferPayout(t *testing.T) {
+	tokens, ctx := setup(t)
+	royalties := NewRoyalties("r")
+	if err := royalties.SetRoyalties("1", map[string]uint16{"alice.near": 500, "artist.near": 500}); err != nil {
+		t.Fatalf("failed to set royalties: %v", err)
+	}
+
+	ctx = call(ctx, "alice.near").DepositYocto(oneNear()).Build()
+	if _, err := tokens.NftApprove("1", "market.near", nil); err != nil {
+		t.Fatalf("approve failed: %v", err)
+	}
+	call(ctx, "market.near").DepositYocto(oneYocto).Build()
+	approvalID := uint64(0)
+	payout, err := royalties.NftTransferPayout(tokens, "bob.near", "1", &approvalID, "", types.U64ToUint128(100), nil)
+	if err != nil {
+		t.Fatalf("transfer payout failed: %v", err)
+	}
+	if payout.Payout["alice.near"] != "95" || payout.Payout["artist.near"] != "5" || len(payout.Payout) != 2 {
+		t.Errorf("unexpected payout %v", payout.Payout)
+	}
+	expectOwner(t, tokens, "1", "bob.near")
+}
+
+func TestRoyaltyOfLargeBalance(t *testing.T) {
+	balances := []types.Uint128{
+		{Hi: math.MaxUint64 / 2, Lo: 12345},
+		{Hi: math.MaxUint64, Lo: math.MaxUint64},
+	}
+	for _, balance := range balances {
+		for _, points := range []uint16{1, 2500, MaxBasisPoints} {
+			amount, err := royalty(balance, points)
+			expected, _ := new(big.Int).SetString(balance.String(), 10)
+			expected.Mul(expected, big.NewInt(int64(points)))
+			expected.Div(expected, big.NewInt(MaxBasisPoints))
+			if err != nil || amount.String() != expected.String() {
+				t.Errorf("expected %d points of %s to be %s, got %s: %v", points, balance.String(), expected.String(), amount.String(), err)
+			}
+		}
+	}
+}
+
+func TestPayoutRejectsRoyaltiesAboveBalance(t *testing.T) {
+	setup(t)
+	royalties := NewRoyalties("r")
+	// Stored directly, bypassing the check of SetRoyalties.
+	royalties.ByID.Insert("1", map[string]uint16{"artist.near": MaxBasisPoints, "label.near": MaxBasisPoints})
+
+	if _, err := royalties.Payout("alice.near", "1", types.U64ToUint128(10000), nil); err == nil || err.Error() != ErrRoyaltiesExceed {
+		t.Errorf("expected %q, got %v", ErrRoyaltiesExceed, err)
+	}
+}
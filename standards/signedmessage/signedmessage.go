@@ -0,0 +1,215 @@
+// Package signedmessage verifies NEP-413 messages, the off-chain messages wallets sign for login-with-NEAR
+// flows.
+//
+// The wallet signs the sha256 hash of the Borsh-encoded payload prefixed with the 2^31+413 tag. A contract
+// checks the signature and burns the nonce so the same message can't be replayed:
+//
+//	verifier := signedmessage.NewVerifier("n", "app.near")
+//	err := verifier.VerifyWith(payload, publicKey, signature, func(publicKey types.PublicKey) error {
+//		_, err := c.Keys.RequireKey(publicKey)
+//		return err
+//	})
+//
+// The signature only proves the holder of publicKey signed the payload. Whether the key belongs to the
+// account that claims it has to be checked separately, for example against the keys the contract stores.
+// VerifyWith runs that check before the nonce is recorded, so keys nobody vouches for can't make the
+// contract store nonces.
+package signedmessage
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/vlmoon99/near-sdk-go/collections"
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+// Tag is the prefix that keeps NEP-413 payloads from being valid transactions.
+const Tag uint32 = 1<<31 + 413
+
+// NonceLength is the length of the nonce of a payload.
+const NonceLength = 32
+
+const (
+	ErrUnsupportedCurve  = "(SIGNED_MESSAGE_ERROR): only ed25519 keys can sign messages, got "
+	ErrInvalidPublicKey  = "(SIGNED_MESSAGE_ERROR): the ed25519 public key must have 32 bytes"
+	ErrInvalidSignature  = "(SIGNED_MESSAGE_ERROR): the signature must have 64 bytes"
+	ErrInvalidNonce      = "(SIGNED_MESSAGE_ERROR): the nonce must have 32 bytes"
+	ErrHashFailed        = "(SIGNED_MESSAGE_ERROR): failed to hash the payload: "
+	ErrSignatureMismatch = "(SIGNED_MESSAGE_ERROR): the signature doesn't match the payload"
+	ErrWrongRecipient    = "(SIGNED_MESSAGE_ERROR): the message is addressed to "
+	ErrNonceUsed         = "(SIGNED_MESSAGE_ERROR): the nonce was already used: "
+	ErrNonceStorage      = "(SIGNED_MESSAGE_ERROR): failed to access the used nonces: "
+)
+
+// Payload is the message a wallet signs. In JSON, the nonce is base64 encoded as wallets send it; an
+// array of bytes is accepted too.
+type Payload struct {
+	Message     string            `json:"message"`
+	Nonce       [NonceLength]byte `json:"nonce"`
+	Recipient   string            `json:"recipient"`
+	CallbackURL *string           `json:"callbackUrl,omitempty"`
+}
+
+type payloadJSON struct {
+	Message     string          `json:"message"`
+	Nonce       json.RawMessage `json:"nonce"`
+	Recipient   string          `json:"recipient"`
+	CallbackURL *string         `json:"callbackUrl,omitempty"`
+}
+
+// UnmarshalJSON decodes the payload with a base64 nonce, or a nonce given as an array of bytes.
+func (p *Payload) UnmarshalJSON(data []byte) error {
+	var decoded payloadJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	var nonce [NonceLength]byte
+	var encoded string
+	if err := json.Unmarshal(decoded.Nonce, &encoded); err == nil {
+		if nonce, err = NonceFromBase64(encoded); err != nil {
+			return err
+		}
+	} else if err := json.Unmarshal(decoded.Nonce, &nonce); err != nil {
+		return errors.New(ErrInvalidNonce)
+	}
+	*p = Payload{Message: decoded.Message, Nonce: nonce, Recipient: decoded.Recipient, CallbackURL: decoded.CallbackURL}
+	return nil
+}
+
+// MarshalJSON encodes the payload with a base64 nonce.
+func (p Payload) MarshalJSON() ([]byte, error) {
+	nonce, _ := json.Marshal(base64.StdEncoding.EncodeToString(p.Nonce[:]))
+	return json.Marshal(payloadJSON{Message: p.Message, Nonce: nonce, Recipient: p.Recipient, CallbackURL: p.CallbackURL})
+}
+
+// NonceFromBase64 decodes the nonce as wallets send it.
+func NonceFromBase64(encoded string) ([NonceLength]byte, error) {
+	var nonce [NonceLength]byte
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(decoded) != NonceLength {
+		return nonce, errors.New(ErrInvalidNonce)
+	}
+	copy(nonce[:], decoded)
+	return nonce, nil
+}
+
+// Serialize returns the Borsh encoding of the tag followed by the payload; it's the data whose hash is
+// signed.
+func (p Payload) Serialize() []byte {
+	data := binary.LittleEndian.AppendUint32(nil, Tag)
+	data = appendString(data, p.Message)
+	data = append(data, p.Nonce[:]...)
+	data = appendString(data, p.Recipient)
+	if p.CallbackURL == nil {
+		return append(data, 0)
+	}
+	data = append(data, 1)
+	return appendString(data, *p.CallbackURL)
+}
+
+// appendString appends s as a Borsh string: its length as a little-endian u32, then its bytes.
+func appendString(data []byte, s string) []byte {
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(s)))
+	return append(data, s...)
+}
+
+// Hash returns the sha256 hash of the serialized payload.
+func (p Payload) Hash() ([]byte, error) {
+	hash, err := env.Sha256Hash(p.Serialize())
+	if err != nil {
+		return nil, errors.New(ErrHashFailed + err.Error())
+	}
+	return hash, nil
+}
+
+// Verify checks that signature is the signature of payload by the ed25519 publicKey.
+func Verify(payload Payload, publicKey types.PublicKey, signature []byte) error {
+	if publicKey.Curve != types.ED25519 {
+		return errors.New(ErrUnsupportedCurve + publicKey.Curve.String())
+	}
+	if len(publicKey.Data) != types.ED25519.DataLen() {
+		return errors.New(ErrInvalidPublicKey)
+	}
+	if len(signature) != 64 {
+		return errors.New(ErrInvalidSignature)
+	}
+
+	hash, err := payload.Hash()
+	if err != nil {
+		return err
+	}
+
+	var sig [64]byte
+	var key [32]byte
+	copy(sig[:], signature)
+	copy(key[:], publicKey.Data)
+	if !env.Ed25519VerifySig(sig, hash, key) {
+		return errors.New(ErrSignatureMismatch)
+	}
+	return nil
+}
+
+// Verifier verifies the messages addressed to Recipient and records their nonces so each message is
+// accepted once.
+type Verifier struct {
+	Recipient string                         `json:"recipient"`
+	Nonces    *collections.LookupSet[string] `json:"nonces"`
+}
+
+// NewVerifier creates a verifier of the messages addressed to recipient that keeps the used nonces under
+// prefix.
+func NewVerifier(prefix, recipient string) *Verifier {
+	return &Verifier{
+		Recipient: recipient,
+		Nonces:    collections.NewLookupSet[string](prefix),
+	}
+}
+
+// IsNonceUsed reports whether a message with nonce was already accepted.
+func (v *Verifier) IsNonceUsed(nonce [NonceLength]byte) (bool, error) {
+	used, err := v.Nonces.Contains(hex.EncodeToString(nonce[:]))
+	if err != nil {
+		return false, errors.New(ErrNonceStorage + err.Error())
+	}
+	return used, nil
+}
+
+// Verify checks the recipient, the nonce and the signature of payload, then records the nonce. Any key can
+// sign a valid message, so every call with a fresh key grows the storage of the contract; contracts that
+// check the key against an account should use VerifyWith instead.
+func (v *Verifier) Verify(payload Payload, publicKey types.PublicKey, signature []byte) error {
+	return v.VerifyWith(payload, publicKey, signature, nil)
+}
+
+// VerifyWith is Verify calling authorize, when it isn't nil, once the signature is checked and before the
+// nonce is recorded. authorize checks that publicKey belongs to the account claiming the message; its error
+// is returned and the nonce stays unused.
+func (v *Verifier) VerifyWith(payload Payload, publicKey types.PublicKey, signature []byte, authorize func(types.PublicKey) error) error {
+	if payload.Recipient != v.Recipient {
+		return errors.New(ErrWrongRecipient + payload.Recipient)
+	}
+	used, err := v.IsNonceUsed(payload.Nonce)
+	if err != nil {
+		return err
+	}
+	if used {
+		return errors.New(ErrNonceUsed + hex.EncodeToString(payload.Nonce[:]))
+	}
+	if err := Verify(payload, publicKey, signature); err != nil {
+		return err
+	}
+	if authorize != nil {
+		if err := authorize(publicKey); err != nil {
+			return err
+		}
+	}
+	if err := v.Nonces.Insert(hex.EncodeToString(payload.Nonce[:])); err != nil {
+		return errors.New(ErrNonceStorage + err.Error())
+	}
+	return nil
+}
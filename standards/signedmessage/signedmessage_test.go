@@ -0,0 +1,157 @@
+package signedmessage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/testutils"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+// rejectingContext fails every signature check.
+type rejectingContext struct {
+	*testutils.Context
+}
+
+func (c *rejectingContext) Ed25519Verify(sigLen, sigPtr, msgLen, msgPtr, pubKeyLen, pubKeyPtr uint64) uint64 {
+	return 0
+}
+
+func publicKey() types.PublicKey {
+	return types.PublicKey{Curve: types.ED25519, Data: bytes.Repeat([]byte{7}, 32)}
+}
+
+func payload(nonce byte) Payload {
+	var n [NonceLength]byte
+	n[0] = nonce
+	return Payload{Message: "hi", Nonce: n, Recipient: "app.near"}
+}
+
+func TestSerialize(t *testing.T) {
+	p := payload(1)
+	expected := []byte{0x9d, 0x01, 0x00, 0x80, 2, 0, 0, 0, 'h', 'i', 1}
+	expected = append(expected, make([]byte, NonceLength-1)...)
+	expected = append(expected, 8, 0, 0, 0)
+	expected = append(expected, "app.near"...)
+	if serialized := p.Serialize(); !bytes.Equal(serialized, append(expected, 0)) {
+		t.Errorf("unexpected serialization %v", serialized)
+	}
+
+	url := "https://app.near.org"
+	p.CallbackURL = &url
+	withURL := append(append(expected, 1, byte(len(url)), 0, 0, 0), url...)
+	if serialized := p.Serialize(); !bytes.Equal(serialized, withURL) {
+		t.Errorf("unexpected serialization with a callback URL %v", serialized)
+	}
+}
+
+func TestNonceFromBase64(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{3}, NonceLength))
+	if nonce, err := NonceFromBase64(encoded); err != nil || nonce[31] != 3 {
+		t.Errorf("unexpected nonce %v: %v", nonce, err)
+	}
+	if _, err := NonceFromBase64("AAAA"); err == nil || err.Error() != ErrInvalidNonce {
+		t.Errorf("expected %q, got %v", ErrInvalidNonce, err)
+	}
+}
+
+func TestPayloadJSON(t *testing.T) {
+	nonce := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{3}, NonceLength))
+	var p Payload
+	err := json.Unmarshal([]byte(`{"message":"hi","nonce":"`+nonce+`","recipient":"app.near","callbackUrl":"https://app.near.org"}`), &p)
+	if err != nil || p.Message != "hi" || p.Nonce[0] != 3 || p.Nonce[31] != 3 || p.Recipient != "app.near" || p.CallbackURL == nil {
+		t.Fatalf("failed to decode a wallet payload: %+v %v", p, err)
+	}
+	encoded, err := json.Marshal(p)
+	var decoded Payload
+	if err != nil || json.Unmarshal(encoded, &decoded) != nil || decoded.Nonce != p.Nonce || *decoded.CallbackURL != *p.CallbackURL {
+		t.Errorf("expected the payload to round trip, got %s: %v", encoded, err)
+	}
+
+	var bytesNonce Payload
+	if err := json.Unmarshal([]byte(`{"message":"hi","nonce":[1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0],"recipient":"app.near"}`), &bytesNonce); err != nil || bytesNonce.Nonce != payload(1).Nonce {
+		t.Errorf("expected a byte array nonce to be accepted, got %+v: %v", bytesNonce, err)
+	}
+	if err := json.Unmarshal([]byte(`{"message":"hi","nonce":"AAAA","recipient":"app.near"}`), &p); err == nil || err.Error() != ErrInvalidNonce {
+		t.Errorf("expected %q, got %v", ErrInvalidNonce, err)
+	}
+}
+
+func TestVerifyWithChecksKeyBeforeRecordingNonce(t *testing.T) {
+	testutils.NewContextBuilder().CurrentAccount("app.near").Build()
+	verifier := NewVerifier("n", "app.near")
+	signature := make([]byte, 64)
+	errUnknownKey := errors.New("unknown key")
+
+	err := verifier.VerifyWith(payload(1), publicKey(), signature, func(types.PublicKey) error { return errUnknownKey })
+	if err != errUnknownKey {
+		t.Errorf("expected the key check to fail, got %v", err)
+	}
+	if used, _ := verifier.IsNonceUsed(payload(1).Nonce); used {
+		t.Errorf("expected an unauthorized key to leave the nonce unused")
+	}
+	if err := verifier.VerifyWith(payload(1), publicKey(), signature, func(types.PublicKey) error { return nil }); err != nil {
+		t.Errorf("expected the message to be accepted, got %v", err)
+	}
+	if used, _ := verifier.IsNonceUsed(payload(1).Nonce); !used {
+		t.Errorf("expected the nonce to be recorded")
+	}
+}
+
+func TestVerifierRejectsReplay(t *testing.T) {
+	ctx := testutils.NewContextBuilder().CurrentAccount("app.near").Build()
+	verifier := NewVerifier("n", "app.near")
+	signature := make([]byte, 64)
+
+	if err := verifier.Verify(payload(1), publicKey(), signature); err != nil {
+		t.Fatalf("expected the message to be accepted, got %v", err)
+	}
+	if used, _ := verifier.IsNonceUsed(payload(1).Nonce); !used {
+		t.Errorf("expected the nonce to be recorded")
+	}
+
+	testutils.NewContextBuilder().CurrentAccount("app.near").State(ctx.Storage).Build()
+	err := verifier.Verify(payload(1), publicKey(), signature)
+	if err == nil || err.Error() != ErrNonceUsed+"01"+string(bytes.Repeat([]byte("0"), 62)) {
+		t.Errorf("expected the replay to fail, got %v", err)
+	}
+	if err := verifier.Verify(payload(2), publicKey(), signature); err != nil {
+		t.Errorf("expected a new nonce to be accepted, got %v", err)
+	}
+}
+
+func TestVerifyErrors(t *testing.T) {
+	ctx := &rejectingContext{Context: testutils.NewContextBuilder().CurrentAccount("app.near").Build()}
+	env.SetEnv(ctx)
+	verifier := NewVerifier("n", "app.near")
+	signature := make([]byte, 64)
+
+	err := verifier.Verify(payload(1), publicKey(), signature)
+	if err == nil || err.Error() != ErrSignatureMismatch {
+		t.Errorf("expected %q, got %v", ErrSignatureMismatch, err)
+	}
+	if used, _ := verifier.IsNonceUsed(payload(1).Nonce); used {
+		t.Errorf("expected a rejected message to leave the nonce unused")
+	}
+
+	wrongRecipient := payload(1)
+	wrongRecipient.Recipient = "other.near"
+	if err := verifier.Verify(wrongRecipient, publicKey(), signature); err == nil || err.Error() != ErrWrongRecipient+"other.near" {
+		t.Errorf("expected the recipient to be checked, got %v", err)
+	}
+
+	secp := types.PublicKey{Curve: types.SECP256K1, Data: make([]byte, 64)}
+	if err := Verify(payload(1), secp, signature); err == nil || err.Error() != ErrUnsupportedCurve+"secp256k1" {
+		t.Errorf("expected secp256k1 keys to be rejected, got %v", err)
+	}
+	if err := Verify(payload(1), types.PublicKey{Curve: types.ED25519}, signature); err == nil || err.Error() != ErrInvalidPublicKey {
+		t.Errorf("expected %q, got %v", ErrInvalidPublicKey, err)
+	}
+	if err := Verify(payload(1), publicKey(), signature[:10]); err == nil || err.Error() != ErrInvalidSignature {
+		t.Errorf("expected %q, got %v", ErrInvalidSignature, err)
+	}
+}
@@ -0,0 +1,171 @@
+// Package sourcemeta implements the NEP-330 source metadata standard.
+//
+// Explorers call the contract_source_metadata view method to show where the code of a contract comes from
+// and how to reproduce its build. A contract declares its metadata once and exports the view method:
+//
+//	func init() {
+//		sourcemeta.Declare(sourcemeta.ContractSourceMetadata{
+//			Version:   sourcemeta.String("1.0.0"),
+//			Link:      sourcemeta.String("https://github.com/alice/counter"),
+//			Standards: []sourcemeta.Standard{{Standard: "nep141", Version: "1.0.0"}},
+//			BuildInfo: sourcemeta.TinyGoBuild("0.34.0",
+//				"tinygo build -no-debug -o main.wasm -target wasm-unknown main.go",
+//				"git+https://github.com/alice/counter?rev=4f2c9e1"),
+//		})
+//	}
+//
+//	//go:export contract_source_metadata
+//	func ContractSourceMetadata() {
+//		sourcemeta.View()
+//	}
+//
+// Build scripts can embed the version and the link without editing the code through the linker:
+//
+//	tinygo build -ldflags "-X github.com/vlmoon99/near-sdk-go/standards/sourcemeta.Version=1.0.0" ...
+package sourcemeta
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/vlmoon99/near-sdk-go/contract"
+	"github.com/vlmoon99/near-sdk-go/env"
+)
+
+// The standard and version of NEP-330 itself; Get always lists it among the standards.
+const (
+	NEP330Standard = "nep330"
+	NEP330Version  = "1.2.0"
+)
+
+// TinyGoImage is the docker image of the TinyGo compiler the build environment refers to.
+const TinyGoImage = "tinygo/tinygo"
+
+const (
+	ErrMissingBuildEnvironment = "(SOURCE_METADATA_ERROR): the build info must have a build environment"
+	ErrMissingBuildCommand     = "(SOURCE_METADATA_ERROR): the build info must have a build command"
+	ErrMissingSnapshot         = "(SOURCE_METADATA_ERROR): the build info must have a source code snapshot"
+	ErrInvalidStandard         = "(SOURCE_METADATA_ERROR): the standard must have a name and a version: "
+)
+
+// Version and Link are set with -ldflags "-X" by build scripts. They fill the fields the declared
+// metadata leaves empty.
+var (
+	Version string
+	Link    string
+)
+
+// Standard is a standard the contract implements, like {"standard": "nep141", "version": "1.0.0"}.
+type Standard struct {
+	Standard string `json:"standard"`
+	Version  string `json:"version"`
+}
+
+// BuildInfo describes how to reproduce the build of the contract.
+type BuildInfo struct {
+	// BuildEnvironment is the docker image the contract was built in, pinned to a tag or a digest.
+	BuildEnvironment string `json:"build_environment"`
+	// BuildCommand is the command that produced the wasm, split into arguments.
+	BuildCommand []string `json:"build_command"`
+	// SourceCodeSnapshot points to the built sources, like "git+https://github.com/alice/counter?rev=<commit>".
+	SourceCodeSnapshot string `json:"source_code_snapshot"`
+	// ContractPath is the directory of the contract inside the snapshot.
+	ContractPath *string `json:"contract_path"`
+	// OutputWasmPath is the path of the built wasm inside the build environment.
+	OutputWasmPath *string `json:"output_wasm_path"`
+}
+
+// Validate checks that the fields NEP-330 requires are set.
+func (b BuildInfo) Validate() error {
+	if b.BuildEnvironment == "" {
+		return errors.New(ErrMissingBuildEnvironment)
+	}
+	if len(b.BuildCommand) == 0 {
+		return errors.New(ErrMissingBuildCommand)
+	}
+	if b.SourceCodeSnapshot == "" {
+		return errors.New(ErrMissingSnapshot)
+	}
+	return nil
+}
+
+// ContractSourceMetadata is the value returned by contract_source_metadata.
+type ContractSourceMetadata struct {
+	Version   *string    `json:"version"`
+	Link      *string    `json:"link"`
+	Standards []Standard `json:"standards"`
+	BuildInfo *BuildInfo `json:"build_info"`
+}
+
+// Validate checks the standards and the build info.
+func (m ContractSourceMetadata) Validate() error {
+	for _, standard := range m.Standards {
+		if standard.Standard == "" || standard.Version == "" {
+			return errors.New(ErrInvalidStandard + standard.Standard + "@" + standard.Version)
+		}
+	}
+	if m.BuildInfo != nil {
+		return m.BuildInfo.Validate()
+	}
+	return nil
+}
+
+// String returns a pointer to s, for the optional fields.
+func String(s string) *string {
+	return &s
+}
+
+// TinyGoBuild returns the build info of a contract built from snapshot by the TinyGo docker image of version
+// with command. The command is split on spaces; the paths are left for the caller.
+func TinyGoBuild(version, command, snapshot string) *BuildInfo {
+	return &BuildInfo{
+		BuildEnvironment:   TinyGoImage + ":" + version,
+		BuildCommand:       strings.Fields(command),
+		SourceCodeSnapshot: snapshot,
+	}
+}
+
+var declared ContractSourceMetadata
+
+// Declare sets the metadata returned by the view method. It's meant to be called from init.
+func Declare(metadata ContractSourceMetadata) {
+	declared = metadata
+}
+
+// Get returns the declared metadata, filled with the linker-set Version and Link and with the NEP-330
+// standard added when it's not listed.
+func Get() ContractSourceMetadata {
+	metadata := declared
+	if metadata.Version == nil && Version != "" {
+		metadata.Version = String(Version)
+	}
+	if metadata.Link == nil && Link != "" {
+		metadata.Link = String(Link)
+	}
+
+	standards := make([]Standard, 0, len(metadata.Standards)+1)
+	listed := false
+	for _, standard := range metadata.Standards {
+		if standard.Standard == NEP330Standard {
+			listed = true
+		}
+		standards = append(standards, standard)
+	}
+	if !listed {
+		standards = append(standards, Standard{Standard: NEP330Standard, Version: NEP330Version})
+	}
+	metadata.Standards = standards
+	return metadata
+}
+
+// View returns the metadata to the caller; it's the body of the exported contract_source_metadata method.
+// It panics when the declared metadata is invalid.
+func View() {
+	metadata := Get()
+	if err := metadata.Validate(); err != nil {
+		env.PanicStr(err.Error())
+	}
+	if err := contract.ReturnValue(metadata); err != nil {
+		env.PanicStr(err.Error())
+	}
+}
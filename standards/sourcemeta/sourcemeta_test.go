@@ -0,0 +1,91 @@
+package sourcemeta
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/testutils"
+)
+
+func reset() {
+	declared = ContractSourceMetadata{}
+	Version = ""
+	Link = ""
+}
+
+func TestViewReturnsDeclaredMetadata(t *testing.T) {
+	reset()
+	build := TinyGoBuild("0.34.0", "tinygo build -no-debug -o main.wasm -target wasm-unknown main.go", "git+https://github.com/alice/counter?rev=abc")
+	Declare(ContractSourceMetadata{
+		Version:   String("1.0.0"),
+		Link:      String("https://github.com/alice/counter"),
+		Standards: []Standard{{Standard: "nep141", Version: "1.0.0"}},
+		BuildInfo: build,
+	})
+
+	ctx := testutils.NewContextBuilder().View().Build()
+	View()
+
+	expected := `{"version":"1.0.0","link":"https://github.com/alice/counter",` +
+		`"standards":[{"standard":"nep141","version":"1.0.0"},{"standard":"nep330","version":"1.2.0"}],` +
+		`"build_info":{"build_environment":"tinygo/tinygo:0.34.0",` +
+		`"build_command":["tinygo","build","-no-debug","-o","main.wasm","-target","wasm-unknown","main.go"],` +
+		`"source_code_snapshot":"git+https://github.com/alice/counter?rev=abc","contract_path":null,"output_wasm_path":null}}`
+	if returned := string(ctx.Registers[0]); returned != expected {
+		t.Errorf("expected %s, got %s", expected, returned)
+	}
+}
+
+func TestGetFillsLinkerValues(t *testing.T) {
+	reset()
+	Version = "2.0.0"
+	Link = "https://github.com/alice/counter"
+
+	metadata := Get()
+	if metadata.Version == nil || *metadata.Version != "2.0.0" || metadata.Link == nil || *metadata.Link != Link {
+		t.Errorf("expected the linker values, got %+v", metadata)
+	}
+	if len(metadata.Standards) != 1 || metadata.Standards[0].Standard != NEP330Standard {
+		t.Errorf("expected only the nep330 standard, got %+v", metadata.Standards)
+	}
+
+	Declare(ContractSourceMetadata{
+		Version:   String("3.0.0"),
+		Standards: []Standard{{Standard: NEP330Standard, Version: "1.1.0"}},
+	})
+	metadata = Get()
+	if *metadata.Version != "3.0.0" {
+		t.Errorf("expected the declared version to win, got %s", *metadata.Version)
+	}
+	if len(metadata.Standards) != 1 || metadata.Standards[0].Version != "1.1.0" {
+		t.Errorf("expected the declared nep330 entry to be kept, got %+v", metadata.Standards)
+	}
+
+	encoded, _ := json.Marshal(ContractSourceMetadata{})
+	if string(encoded) != `{"version":null,"link":null,"standards":null,"build_info":null}` {
+		t.Errorf("expected the optional fields to be null, got %s", encoded)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata ContractSourceMetadata
+		expected string
+	}{
+		{"empty standard", ContractSourceMetadata{Standards: []Standard{{Standard: "nep141"}}}, ErrInvalidStandard + "nep141@"},
+		{"no environment", ContractSourceMetadata{BuildInfo: &BuildInfo{}}, ErrMissingBuildEnvironment},
+		{"no command", ContractSourceMetadata{BuildInfo: TinyGoBuild("0.34.0", "", "git+https://github.com/alice/counter?rev=abc")}, ErrMissingBuildCommand},
+		{"no snapshot", ContractSourceMetadata{BuildInfo: TinyGoBuild("0.34.0", "tinygo build", "")}, ErrMissingSnapshot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.metadata.Validate(); err == nil || err.Error() != tt.expected {
+				t.Errorf("expected %q, got %v", tt.expected, err)
+			}
+		})
+	}
+	if err := (ContractSourceMetadata{}).Validate(); err != nil {
+		t.Errorf("expected empty metadata to be valid, got %v", err)
+	}
+}
@@ -0,0 +1,359 @@
+// Package storage implements the NEP-145 storage management standard.
+//
+// Users deposit NEAR to cover the storage their data occupies in the contract. Management keeps a storage
+// balance per account and charges it with the difference of env.GetStorageUsage measured around the
+// operations the account pays for:
+//
+//	err := c.Storage.Charge(accountID, func() error {
+//		return c.Records.Insert(accountID, message)
+//	})
+//
+// Registration can be forwarded to another standard through a Registrar; ft.FungibleToken is one, so
+// storage_deposit registers the token holder and storage_unregister removes it.
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+
+	"github.com/vlmoon99/near-sdk-go/collections"
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/system"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+const (
+	ErrNotRegistered       = "(STORAGE_MANAGEMENT_ERROR): the account is not registered: "
+	ErrDepositBelowMinimum = "(STORAGE_MANAGEMENT_ERROR): the attached deposit is less than the minimum storage balance"
+	ErrRequiresOneYocto    = "(STORAGE_MANAGEMENT_ERROR): requires attached deposit of exactly 1 yoctoNEAR"
+	ErrWithdrawExceeds     = "(STORAGE_MANAGEMENT_ERROR): the amount is greater than the available storage balance"
+	ErrInsufficientDeposit = "(STORAGE_MANAGEMENT_ERROR): the storage balance doesn't cover the storage usage of "
+	ErrStorageCostOverflow = "(STORAGE_MANAGEMENT_ERROR): storage cost overflow"
+	ErrBalanceOverflow     = "(STORAGE_MANAGEMENT_ERROR): storage balance overflow"
+	ErrPredecessorUnknown  = "(STORAGE_MANAGEMENT_ERROR): failed to get the predecessor account: "
+	ErrStorageInUse        = "(STORAGE_MANAGEMENT_ERROR): the account still uses storage it is charged for: "
+)
+
+// MaxAccountIDLength is the length of the longest valid NEAR account ID.
+const MaxAccountIDLength = 64
+
+var zero = types.Uint128{Hi: 0, Lo: 0}
+
+// StorageBalance is the NEP-145 view of the storage balance of an account.
+type StorageBalance struct {
+	Total     string `json:"total"`
+	Available string `json:"available"`
+}
+
+// StorageBalanceBounds are the NEP-145 minimum and optional maximum storage balances.
+type StorageBalanceBounds struct {
+	Min string  `json:"min"`
+	Max *string `json:"max"`
+}
+
+// AccountStorage is the stored storage balance of an account.
+type AccountStorage struct {
+	Total     types.Uint128 `json:"total"`
+	UsedBytes uint64        `json:"used_bytes"`
+}
+
+// Registrar is notified when accounts register and unregister, so other standards can create and remove
+// the records they keep per account. Its storage is charged to the account on registration.
+type Registrar interface {
+	OnStorageRegister(accountID string) error
+	OnStorageUnregister(accountID string, force bool) error
+}
+
+// Management is the storage balance bookkeeping of a contract.
+type Management struct {
+	Accounts *collections.LookupMap[string, AccountStorage] `json:"accounts"`
+	// MinBalance is the registration fee. It stays locked while the account is registered and covers the
+	// records Management keeps for the account.
+	MinBalance types.Uint128 `json:"min_balance"`
+	// MaxBalance caps the storage balance, the excess of a deposit is refunded. Nil means no cap.
+	MaxBalance *types.Uint128 `json:"max_balance"`
+}
+
+// New creates the storage bookkeeping under prefix with the given registration fee and no maximum.
+func New(prefix string, minBalance types.Uint128) *Management {
+	return &Management{
+		Accounts:   collections.NewLookupMap[string, AccountStorage](prefix),
+		MinBalance: minBalance,
+	}
+}
+
+// NewFixed creates the storage bookkeeping for contracts that only need a fixed registration fee, like
+// fungible tokens: the minimum and the maximum storage balance are both minBalance.
+func NewFixed(prefix string, minBalance types.Uint128) *Management {
+	m := New(prefix, minBalance)
+	maxBalance := minBalance
+	m.MaxBalance = &maxBalance
+	return m
+}
+
+// CostOf returns the amount that has to be staked for bytes of storage.
+func CostOf(bytes uint64) (types.Uint128, error) {
+	cost, err := types.U64ToUint128(types.STORAGE_PRICE_PER_BYTE).Mul(types.U64ToUint128(bytes))
+	if err != nil {
+		return zero, errors.New(ErrStorageCostOverflow)
+	}
+	return cost, nil
+}
+
+// RecordStorageUsage returns the worst case number of bytes of the record Management keeps for an account,
+// to be included in MinBalance.
+func (m *Management) RecordStorageUsage() uint64 {
+	maxRecord, _ := json.Marshal(AccountStorage{Total: types.Uint128{Hi: math.MaxUint64, Lo: math.MaxUint64}, UsedBytes: math.MaxUint64})
+	return uint64(len(m.Accounts.Prefix)+1+MaxAccountIDLength+len(maxRecord)) + system.StorageNumExtraBytesRecord
+}
+
+// IsRegistered reports whether the account has a storage balance.
+func (m *Management) IsRegistered(accountID string) bool {
+	exists, err := m.Accounts.Contains(accountID)
+	return err == nil && exists
+}
+
+// StorageDeposit implements storage_deposit. The attached deposit is credited to accountID, or to the
+// predecessor when accountID is empty. A new account is registered first and pays MinBalance; with
+// registrationOnly, everything above the registration fee is refunded to the predecessor.
+func (m *Management) StorageDeposit(accountID string, registrationOnly bool, registrar Registrar) (StorageBalance, error) {
+	predecessorID, err := env.GetPredecessorAccountID()
+	if err != nil {
+		return StorageBalance{}, errors.New(ErrPredecessorUnknown + err.Error())
+	}
+	if accountID == "" {
+		accountID = predecessorID
+	}
+	amount, _ := env.GetAttachedDeposit()
+
+	account, err := m.Accounts.Get(accountID)
+	if err != nil {
+		if amount.Cmp(m.MinBalance) < 0 {
+			return StorageBalance{}, errors.New(ErrDepositBelowMinimum)
+		}
+		account = AccountStorage{Total: m.MinBalance}
+		if registrar != nil {
+			used, err := measure(registrar.OnStorageRegister, accountID)
+			if err != nil {
+				return StorageBalance{}, err
+			}
+			account.UsedBytes = used
+		}
+		amount, _ = amount.Sub(m.MinBalance)
+	}
+
+	refund := zero
+	if registrationOnly {
+		refund, amount = amount, zero
+	}
+	if account.Total, err = account.Total.Add(amount); err != nil {
+		return StorageBalance{}, errors.New(ErrBalanceOverflow)
+	}
+	if m.MaxBalance != nil && account.Total.Cmp(*m.MaxBalance) > 0 {
+		excess, _ := account.Total.Sub(*m.MaxBalance)
+		refund, _ = refund.Add(excess)
+		account.Total = *m.MaxBalance
+	}
+	if err := m.assertCovered(accountID, account); err != nil {
+		return StorageBalance{}, err
+	}
+
+	if err := m.Accounts.Insert(accountID, account); err != nil {
+		return StorageBalance{}, err
+	}
+	transfer(predecessorID, refund)
+	return m.balance(account), nil
+}
+
+// StorageWithdraw implements storage_withdraw: the predecessor withdraws amount, or all of its available
+// balance when amount is nil. Exactly one yoctoNEAR must be attached.
+func (m *Management) StorageWithdraw(amount *types.Uint128) (StorageBalance, error) {
+	if err := assertOneYocto(); err != nil {
+		return StorageBalance{}, err
+	}
+	accountID, err := env.GetPredecessorAccountID()
+	if err != nil {
+		return StorageBalance{}, errors.New(ErrPredecessorUnknown + err.Error())
+	}
+	account, err := m.Accounts.Get(accountID)
+	if err != nil {
+		return StorageBalance{}, errors.New(ErrNotRegistered + accountID)
+	}
+
+	available := m.available(account)
+	withdraw := available
+	if amount != nil {
+		if amount.Cmp(available) > 0 {
+			return StorageBalance{}, errors.New(ErrWithdrawExceeds)
+		}
+		withdraw = *amount
+	}
+
+	account.Total, _ = account.Total.Sub(withdraw)
+	if err := m.Accounts.Insert(accountID, account); err != nil {
+		return StorageBalance{}, err
+	}
+	transfer(accountID, withdraw)
+	return m.balance(account), nil
+}
+
+// StorageUnregister implements storage_unregister: the predecessor is removed and its whole storage balance,
+// plus the attached yoctoNEAR, is refunded. The registrar decides whether force is needed. It fails while
+// the account still uses storage charged to it that the registrar didn't free, since the refund would no
+// longer cover it; returned as a contract panic, that reverts the registrar changes too. Exactly one
+// yoctoNEAR must be attached. It returns false when the predecessor isn't registered.
+func (m *Management) StorageUnregister(force bool, registrar Registrar) (bool, error) {
+	if err := assertOneYocto(); err != nil {
+		return false, err
+	}
+	accountID, err := env.GetPredecessorAccountID()
+	if err != nil {
+		return false, errors.New(ErrPredecessorUnknown + err.Error())
+	}
+	account, err := m.Accounts.Get(accountID)
+	if err != nil {
+		env.LogString("The account " + accountID + " is not registered")
+		return false, nil
+	}
+
+	freed := uint64(0)
+	if registrar != nil {
+		before := env.GetStorageUsage()
+		if err := registrar.OnStorageUnregister(accountID, force); err != nil {
+			return false, err
+		}
+		if after := env.GetStorageUsage(); after < before {
+			freed = before - after
+		}
+	}
+	if account.UsedBytes > freed {
+		return false, errors.New(ErrStorageInUse + accountID)
+	}
+	if err := m.Accounts.Remove(accountID); err != nil {
+		return false, err
+	}
+
+	refund, _ := account.Total.Add(types.Uint128{Hi: 0, Lo: 1})
+	transfer(accountID, refund)
+	return true, nil
+}
+
+// StorageBalanceOf implements storage_balance_of; it returns nil for unregistered accounts.
+func (m *Management) StorageBalanceOf(accountID string) *StorageBalance {
+	account, err := m.Accounts.Get(accountID)
+	if err != nil {
+		return nil
+	}
+	balance := m.balance(account)
+	return &balance
+}
+
+// StorageBalanceBounds implements storage_balance_bounds.
+func (m *Management) StorageBalanceBounds() StorageBalanceBounds {
+	bounds := StorageBalanceBounds{Min: m.MinBalance.String()}
+	if m.MaxBalance != nil {
+		maxBalance := m.MaxBalance.String()
+		bounds.Max = &maxBalance
+	}
+	return bounds
+}
+
+// Charge runs fn and charges the storage it adds to the account, or releases the storage it frees.
+// It fails, without undoing fn, when the storage balance of the account no longer covers its usage;
+// returned as a contract panic, that reverts the whole call.
+func (m *Management) Charge(accountID string, fn func() error) error {
+	account, err := m.Accounts.Get(accountID)
+	if err != nil {
+		return errors.New(ErrNotRegistered + accountID)
+	}
+
+	before := env.GetStorageUsage()
+	if err := fn(); err != nil {
+		return err
+	}
+	after := env.GetStorageUsage()
+
+	if after >= before {
+		account.UsedBytes += after - before
+	} else if freed := before - after; freed < account.UsedBytes {
+		account.UsedBytes -= freed
+	} else {
+		account.UsedBytes = 0
+	}
+	if err := m.assertCovered(accountID, account); err != nil {
+		return err
+	}
+	return m.Accounts.Insert(accountID, account)
+}
+
+// locked returns the part of the storage balance that can't be withdrawn: the cost of the storage the
+// account uses and of its own record, and at least the registration fee.
+func (m *Management) locked(account AccountStorage) (types.Uint128, error) {
+	bytes := account.UsedBytes + m.RecordStorageUsage()
+	if bytes < account.UsedBytes {
+		return zero, errors.New(ErrStorageCostOverflow)
+	}
+	used, err := CostOf(bytes)
+	if err != nil {
+		return zero, err
+	}
+	if used.Cmp(m.MinBalance) < 0 {
+		return m.MinBalance, nil
+	}
+	return used, nil
+}
+
+func (m *Management) available(account AccountStorage) types.Uint128 {
+	locked, err := m.locked(account)
+	if err != nil {
+		return zero
+	}
+	available, err := account.Total.Sub(locked)
+	if err != nil {
+		return zero
+	}
+	return available
+}
+
+func (m *Management) assertCovered(accountID string, account AccountStorage) error {
+	locked, err := m.locked(account)
+	if err != nil {
+		return err
+	}
+	if account.Total.Cmp(locked) < 0 {
+		return errors.New(ErrInsufficientDeposit + accountID)
+	}
+	return nil
+}
+
+func (m *Management) balance(account AccountStorage) StorageBalance {
+	return StorageBalance{Total: account.Total.String(), Available: m.available(account).String()}
+}
+
+// measure returns the storage bytes added by fn.
+func measure(fn func(string) error, accountID string) (uint64, error) {
+	before := env.GetStorageUsage()
+	if err := fn(accountID); err != nil {
+		return 0, err
+	}
+	if after := env.GetStorageUsage(); after > before {
+		return after - before, nil
+	}
+	return 0, nil
+}
+
+func transfer(accountID string, amount types.Uint128) {
+	if amount.Cmp(zero) > 0 {
+		promise.CreateBatch(accountID).Transfer(amount)
+	}
+}
+
+func assertOneYocto() error {
+	deposit, err := env.GetAttachedDeposit()
+	if err != nil || deposit.Cmp(types.Uint128{Hi: 0, Lo: 1}) != 0 {
+		return errors.New(ErrRequiresOneYocto)
+	}
+	return nil
+}
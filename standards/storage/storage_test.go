@@ -0,0 +1,261 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/collections"
+	"github.com/vlmoon99/near-sdk-go/testutils"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+var oneYocto = types.Uint128{Hi: 0, Lo: 1}
+
+func milliNear(milli uint64) types.Uint128 {
+	oneMilliNear, _ := types.U128FromString("1000000000000000000000")
+	amount, _ := oneMilliNear.Mul(types.U64ToUint128(milli))
+	return amount
+}
+
+// registrar keeps a record per registered account, like a token balance.
+type registrar struct {
+	records *collections.LookupMap[string, string]
+}
+
+func (r *registrar) OnStorageRegister(accountID string) error {
+	return r.records.Insert(accountID, "registered")
+}
+
+func (r *registrar) OnStorageUnregister(accountID string, force bool) error {
+	if !force {
+		return errors.New("force required")
+	}
+	return r.records.Remove(accountID)
+}
+
+func call(previous *testutils.Context, predecessor string) *testutils.ContextBuilder {
+	builder := testutils.NewContextBuilder().CurrentAccount("contract.near").Predecessor(predecessor)
+	if previous != nil {
+		builder.State(previous.Storage).Balance(previous.AccountBalanceSys)
+	}
+	return builder
+}
+
+func TestStorageBalanceBounds(t *testing.T) {
+	bounds := New("s", milliNear(10)).StorageBalanceBounds()
+	if bounds.Min != milliNear(10).String() || bounds.Max != nil {
+		t.Errorf("unexpected bounds %+v", bounds)
+	}
+
+	bounds = NewFixed("s", milliNear(10)).StorageBalanceBounds()
+	if bounds.Max == nil || *bounds.Max != milliNear(10).String() {
+		t.Errorf("expected a maximum of %s, got %+v", milliNear(10).String(), bounds)
+	}
+}
+
+func TestStorageDeposit(t *testing.T) {
+	management := New("s", milliNear(10))
+	records := &registrar{records: collections.NewLookupMap[string, string]("r")}
+
+	call(nil, "alice.near").DepositYocto(milliNear(5)).Build()
+	if _, err := management.StorageDeposit("", false, records); err == nil || err.Error() != ErrDepositBelowMinimum {
+		t.Errorf("expected %q, got %v", ErrDepositBelowMinimum, err)
+	}
+
+	ctx := call(nil, "alice.near").DepositYocto(milliNear(100)).Build()
+	balance, err := management.StorageDeposit("", false, records)
+	if err != nil {
+		t.Fatalf("deposit failed: %v", err)
+	}
+	if balance.Total != milliNear(100).String() || balance.Available != milliNear(90).String() {
+		t.Errorf("unexpected balance %+v", balance)
+	}
+	if exists, _ := records.records.Contains("alice.near"); !exists {
+		t.Errorf("expected the registrar to register alice.near")
+	}
+	account, _ := management.Accounts.Get("alice.near")
+	if account.UsedBytes == 0 {
+		t.Errorf("expected the registrar storage to be charged")
+	}
+
+	// A second deposit only tops up the balance, and registration only refunds it.
+	ctx = call(ctx, "alice.near").DepositYocto(milliNear(50)).Build()
+	if balance, _ := management.StorageDeposit("", false, records); balance.Total != milliNear(150).String() {
+		t.Errorf("expected a total of %s, got %+v", milliNear(150).String(), balance)
+	}
+	ctx = call(ctx, "alice.near").DepositYocto(milliNear(50)).Build()
+	if balance, _ := management.StorageDeposit("", true, records); balance.Total != milliNear(150).String() {
+		t.Errorf("expected a total of %s, got %+v", milliNear(150).String(), balance)
+	}
+	if ctx.AccountBalanceSys.Cmp(milliNear(150)) != 0 {
+		t.Errorf("expected the registration only deposit to be refunded, got %s", ctx.AccountBalanceSys.String())
+	}
+
+	// Deposits on behalf of another account are credited to it.
+	call(ctx, "alice.near").DepositYocto(milliNear(10)).Build()
+	if _, err := management.StorageDeposit("bob.near", true, nil); err != nil {
+		t.Fatalf("deposit for bob.near failed: %v", err)
+	}
+	if balance := management.StorageBalanceOf("bob.near"); balance == nil || balance.Total != milliNear(10).String() {
+		t.Errorf("expected bob.near to be registered with %s, got %+v", milliNear(10).String(), balance)
+	}
+	if management.StorageBalanceOf("carol.near") != nil {
+		t.Errorf("expected no balance for carol.near")
+	}
+}
+
+func TestFixedStorageRefundsExcess(t *testing.T) {
+	management := NewFixed("s", milliNear(10))
+
+	ctx := call(nil, "alice.near").DepositYocto(milliNear(25)).Build()
+	balance, err := management.StorageDeposit("", false, nil)
+	if err != nil {
+		t.Fatalf("deposit failed: %v", err)
+	}
+	if balance.Total != milliNear(10).String() || balance.Available != "0" {
+		t.Errorf("unexpected balance %+v", balance)
+	}
+	if ctx.AccountBalanceSys.Cmp(milliNear(10)) != 0 {
+		t.Errorf("expected the excess to be refunded, got %s", ctx.AccountBalanceSys.String())
+	}
+}
+
+func TestChargeAndWithdraw(t *testing.T) {
+	management := New("s", types.Uint128{Hi: 0, Lo: 0})
+	management.MinBalance, _ = CostOf(management.RecordStorageUsage())
+	messages := collections.NewLookupMap[string, string]("m")
+
+	deposit, _ := management.MinBalance.Add(milliNear(2))
+	ctx := call(nil, "alice.near").DepositYocto(deposit).Build()
+	if _, err := management.StorageDeposit("", false, nil); err != nil {
+		t.Fatalf("deposit failed: %v", err)
+	}
+
+	// The 2 milliNEAR above the registration fee cover 200 bytes.
+	err := management.Charge("alice.near", func() error {
+		return messages.Insert("alice.near", strings.Repeat("a", 100))
+	})
+	if err != nil {
+		t.Fatalf("charge failed: %v", err)
+	}
+	account, _ := management.Accounts.Get("alice.near")
+	if account.UsedBytes == 0 || account.UsedBytes > 200 {
+		t.Fatalf("unexpected storage usage %d", account.UsedBytes)
+	}
+	used, _ := CostOf(account.UsedBytes)
+	expectedAvailable, _ := milliNear(2).Sub(used)
+	if balance := management.StorageBalanceOf("alice.near"); balance.Total != deposit.String() {
+		t.Errorf("expected a total of %s, got %+v", deposit.String(), balance)
+	}
+	if balance := management.StorageBalanceOf("alice.near"); balance.Available != expectedAvailable.String() {
+		t.Errorf("expected %s available, got %+v", expectedAvailable.String(), balance)
+	}
+
+	err = management.Charge("alice.near", func() error {
+		return messages.Insert("alice.near", strings.Repeat("a", 300))
+	})
+	if err == nil || err.Error() != ErrInsufficientDeposit+"alice.near" {
+		t.Errorf("expected %q, got %v", ErrInsufficientDeposit+"alice.near", err)
+	}
+	if err := management.Charge("bob.near", func() error { return nil }); err == nil || err.Error() != ErrNotRegistered+"bob.near" {
+		t.Errorf("expected %q, got %v", ErrNotRegistered+"bob.near", err)
+	}
+
+	// Removing the message releases its storage.
+	ctx = call(ctx, "alice.near").Build()
+	if err := management.Charge("alice.near", func() error { return messages.Remove("alice.near") }); err != nil {
+		t.Fatalf("charge failed: %v", err)
+	}
+	if account, _ := management.Accounts.Get("alice.near"); account.UsedBytes != 0 {
+		t.Errorf("expected the storage to be released, got %d bytes", account.UsedBytes)
+	}
+
+	if _, err := management.StorageWithdraw(nil); err == nil || err.Error() != ErrRequiresOneYocto {
+		t.Errorf("expected %q, got %v", ErrRequiresOneYocto, err)
+	}
+	ctx = call(ctx, "alice.near").DepositYocto(oneYocto).Build()
+	tooMuch := milliNear(3)
+	if _, err := management.StorageWithdraw(&tooMuch); err == nil || err.Error() != ErrWithdrawExceeds {
+		t.Errorf("expected %q, got %v", ErrWithdrawExceeds, err)
+	}
+	balance, err := management.StorageWithdraw(nil)
+	if err != nil {
+		t.Fatalf("withdraw failed: %v", err)
+	}
+	if balance.Total != management.MinBalance.String() || balance.Available != "0" {
+		t.Errorf("expected only the registration fee to remain, got %+v", balance)
+	}
+}
+
+func TestStorageUnregister(t *testing.T) {
+	management := New("s", milliNear(10))
+	records := &registrar{records: collections.NewLookupMap[string, string]("r")}
+
+	ctx := call(nil, "alice.near").DepositYocto(milliNear(10)).Build()
+	if _, err := management.StorageDeposit("", false, records); err != nil {
+		t.Fatalf("deposit failed: %v", err)
+	}
+
+	ctx = call(ctx, "alice.near").DepositYocto(oneYocto).Build()
+	if _, err := management.StorageUnregister(false, records); err == nil || err.Error() != "force required" {
+		t.Errorf("expected the registrar to reject the unregistration, got %v", err)
+	}
+	unregistered, err := management.StorageUnregister(true, records)
+	if err != nil || !unregistered {
+		t.Fatalf("unregister failed: %v", err)
+	}
+	if management.IsRegistered("alice.near") {
+		t.Errorf("expected alice.near to be unregistered")
+	}
+	if ctx.AccountBalanceSys.Cmp(types.Uint128{Hi: 0, Lo: 0}) != 0 {
+		t.Errorf("expected the storage balance and the yocto to be refunded, got %s", ctx.AccountBalanceSys.String())
+	}
+
+	call(ctx, "alice.near").DepositYocto(oneYocto).Build()
+	if unregistered, err := management.StorageUnregister(true, records); err != nil || unregistered {
+		t.Errorf("expected false for an unregistered account, got %t %v", unregistered, err)
+	}
+}
+
+func TestStorageUnregisterWithUsedStorage(t *testing.T) {
+	management := New("s", milliNear(10))
+	messages := collections.NewLookupMap[string, string]("m")
+
+	ctx := call(nil, "alice.near").DepositYocto(milliNear(10)).Build()
+	if _, err := management.StorageDeposit("", false, nil); err != nil {
+		t.Fatalf("deposit failed: %v", err)
+	}
+	if err := management.Charge("alice.near", func() error { return messages.Insert("alice.near", "hello") }); err != nil {
+		t.Fatalf("charge failed: %v", err)
+	}
+
+	ctx = call(ctx, "alice.near").DepositYocto(oneYocto).Build()
+	if _, err := management.StorageUnregister(true, nil); err == nil || err.Error() != ErrStorageInUse+"alice.near" {
+		t.Errorf("expected %q, got %v", ErrStorageInUse+"alice.near", err)
+	}
+	if !management.IsRegistered("alice.near") {
+		t.Fatalf("expected alice.near to stay registered")
+	}
+
+	if err := management.Charge("alice.near", func() error { return messages.Remove("alice.near") }); err != nil {
+		t.Fatalf("charge failed: %v", err)
+	}
+	if unregistered, err := management.StorageUnregister(false, nil); err != nil || !unregistered {
+		t.Errorf("expected the unregistration to succeed once the storage is freed, got %t %v", unregistered, err)
+	}
+}
+
+func TestLockedIncludesRecord(t *testing.T) {
+	management := New("s", milliNear(1))
+	account := AccountStorage{Total: milliNear(100), UsedBytes: 1000}
+	expected, _ := CostOf(1000 + management.RecordStorageUsage())
+	if locked, err := management.locked(account); err != nil || locked.Cmp(expected) != 0 {
+		t.Errorf("expected %s locked, got %s: %v", expected.String(), locked.String(), err)
+	}
+
+	management.MinBalance = milliNear(100)
+	if locked, err := management.locked(account); err != nil || locked.Cmp(milliNear(100)) != 0 {
+		t.Errorf("expected the registration fee to be locked, got %s: %v", locked.String(), err)
+	}
+}
@@ -3,11 +3,24 @@ package system
 // For some env limitation reason we can't use crypto/* or golang.org/x/crypto/* packages
 import (
 	"encoding/binary"
+	"errors"
+	"unicode/utf16"
 	"unsafe"
 
 	"github.com/vlmoon99/near-sdk-go/types"
 )
 
+// StorageNumExtraBytesRecord is the fixed number of bytes NEAR charges for every key-value record
+// on top of the key and value lengths.
+const StorageNumExtraBytesRecord uint64 = 40
+
+const (
+	ErrBalanceExceeded            = "(BALANCE_ERROR): transfer amount exceeds the account balance"
+	ErrLackBalanceForState        = "(BALANCE_ERROR): account balance can't cover the storage usage"
+	ErrAttachedDepositOverflow    = "(DEPOSIT_ERROR): crediting the attached deposit overflows the account balance"
+	ErrStorageStakingCostOverflow = "(STORAGE_ERROR): storage staking cost overflow"
+)
+
 type MockPromise struct {
 	AccountId    string
 	FunctionName string
@@ -36,6 +49,8 @@ type MockSystem struct {
 	AttachedDepositSys      types.Uint128
 	PrepaidGasSys           uint64
 	UsedGasSys              uint64
+	// LogsSys holds the messages logged through LogUtf8 and LogUtf16, e.g. NEP-297 events.
+	LogsSys []string
 }
 
 func NewMockSystem() *MockSystem {
@@ -89,6 +104,13 @@ func (m *MockSystem) StorageWrite(keyLen, keyPtr, valueLen, valuePtr, registerId
 	value := unsafe.Slice((*byte)(unsafe.Pointer(uintptr(valuePtr))), valueLen)
 	keyStr := string(key)
 
+	if oldValue, exists := m.Storage[keyStr]; exists {
+		m.releaseStorage(uint64(len(oldValue)))
+		m.StorageUsageSys += valueLen
+	} else {
+		m.StorageUsageSys += keyLen + valueLen + StorageNumExtraBytesRecord
+	}
+
 	m.Storage[keyStr] = make([]byte, valueLen)
 	copy(m.Storage[keyStr], value)
 
@@ -125,6 +147,7 @@ func (m *MockSystem) StorageRemove(keyLen, keyPtr, registerId uint64) uint64 {
 			m.WriteRegister(registerId, uint64(len(valueCopy)), uint64(uintptr(unsafe.Pointer(&valueCopy[0]))))
 		}
 		delete(m.Storage, keyStr)
+		m.releaseStorage(keyLen + uint64(len(value)) + StorageNumExtraBytesRecord)
 		return 1
 	}
 	return 0
@@ -140,6 +163,35 @@ func (m *MockSystem) StorageHasKey(keyLen, keyPtr uint64) uint64 {
 	return 0
 }
 
+// releaseStorage lowers the tracked storage usage without underflowing,
+// because records inserted into Storage directly by tests are never accounted for.
+func (m *MockSystem) releaseStorage(bytes uint64) {
+	if bytes > m.StorageUsageSys {
+		m.StorageUsageSys = 0
+		return
+	}
+	m.StorageUsageSys -= bytes
+}
+
+// StorageStakingCheck reports whether the account balance (including the locked balance)
+// covers the storage staked for StorageUsageSys bytes, as the runtime checks at the end of every call.
+func (m *MockSystem) StorageStakingCheck() error {
+	required, err := types.U64ToUint128(m.StorageUsageSys).SafeMul64(types.STORAGE_PRICE_PER_BYTE)
+	if err != nil {
+		return errors.New(ErrStorageStakingCostOverflow)
+	}
+
+	available, err := m.AccountBalanceSys.Add(m.AccountLockedBalanceSys)
+	if err != nil {
+		return errors.New(ErrStorageStakingCostOverflow)
+	}
+
+	if available.Cmp(required) < 0 {
+		return errors.New(ErrLackBalanceForState)
+	}
+	return nil
+}
+
 // Storage API
 
 // Context API
@@ -220,6 +272,18 @@ func (m *MockSystem) AttachedDeposit(balancePtr uint64) {
 	copy(targetBytes[:], balanceBytes)
 }
 
+// AttachDeposit sets the deposit attached to the current call and credits it to the account balance,
+// the same way the runtime does before the function call is executed.
+func (m *MockSystem) AttachDeposit(amount types.Uint128) {
+	balance, err := m.AccountBalanceSys.Add(amount)
+	if err != nil {
+		panic(ErrAttachedDepositOverflow)
+	}
+
+	m.AccountBalanceSys = balance
+	m.AttachedDepositSys = amount
+}
+
 func (m *MockSystem) PrepaidGas() uint64 {
 	return m.PrepaidGasSys
 }
@@ -311,18 +375,20 @@ func (m *MockSystem) PanicUtf8(len, ptr uint64) {
 }
 
 func (m *MockSystem) LogUtf8(len, ptr uint64) {
-	// value := make([]byte, len)
-	// copy(value, *(*[]byte)(unsafe.Pointer(uintptr(ptr))))
-	// fmt.Printf("Log: %s", value[:len])
+	if len == 0 {
+		m.LogsSys = append(m.LogsSys, "")
+		return
+	}
+	value := unsafe.Slice((*byte)(unsafe.Pointer(uintptr(ptr))), len)
+	m.LogsSys = append(m.LogsSys, string(value))
 }
 
 func (m *MockSystem) LogUtf16(len, ptr uint64) {
-	// utf16Bytes := make([]uint16, len/2)
-	// for i := 0; i < int(len)/2; i++ {
-	// 	utf16Bytes[i] = *(*uint16)(unsafe.Pointer(uintptr(ptr) + uintptr(i*2)))
-	// }
-	// message := string(utf16.Decode(utf16Bytes))
-	// fmt.Printf("Log: %s", message)
+	utf16Bytes := make([]uint16, len/2)
+	for i := 0; i < int(len)/2; i++ {
+		utf16Bytes[i] = *(*uint16)(unsafe.Pointer(uintptr(ptr) + uintptr(i*2)))
+	}
+	m.LogsSys = append(m.LogsSys, string(utf16.Decode(utf16Bytes)))
 }
 
 // Miscellaneous API
@@ -411,7 +477,13 @@ func (m *MockSystem) PromiseBatchActionFunctionCallWeight(promiseIndex, function
 }
 
 func (m *MockSystem) PromiseBatchActionTransfer(promiseIndex, amountPtr uint64) {
-	binary.LittleEndian.Uint64((*(*[8]byte)(unsafe.Pointer(uintptr(amountPtr))))[:])
+	amount, _ := types.LoadUint128LE((*(*[16]byte)(unsafe.Pointer(uintptr(amountPtr))))[:])
+
+	balance, err := m.AccountBalanceSys.Sub(amount)
+	if err != nil {
+		panic(ErrBalanceExceeded)
+	}
+	m.AccountBalanceSys = balance
 }
 
 func (m *MockSystem) PromiseBatchActionStake(promiseIndex, amountPtr, publicKeyLen, publicKeyPtr uint64) {
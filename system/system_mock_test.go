@@ -159,6 +159,56 @@ func TestStorageRemove(t *testing.T) {
 	}
 }
 
+func TestStorageUsageAccounting(t *testing.T) {
+	mockSys := NewMockSystem()
+	key := []byte("testKey")
+	value := []byte("testValue")
+	keyPtr := uint64(uintptr(unsafe.Pointer(&key[0])))
+	valuePtr := uint64(uintptr(unsafe.Pointer(&value[0])))
+
+	mockSys.StorageWrite(uint64(len(key)), keyPtr, uint64(len(value)), valuePtr, 0)
+
+	expected := uint64(len(key)+len(value)) + StorageNumExtraBytesRecord
+	if mockSys.StorageUsageSys != expected {
+		t.Errorf("expected storage usage %d after insert, got %d", expected, mockSys.StorageUsageSys)
+	}
+
+	// Overwriting a record only accounts for the value length difference
+	longerValue := []byte("testValueLonger")
+	longerValuePtr := uint64(uintptr(unsafe.Pointer(&longerValue[0])))
+	mockSys.StorageWrite(uint64(len(key)), keyPtr, uint64(len(longerValue)), longerValuePtr, 0)
+
+	expected = uint64(len(key)+len(longerValue)) + StorageNumExtraBytesRecord
+	if mockSys.StorageUsageSys != expected {
+		t.Errorf("expected storage usage %d after update, got %d", expected, mockSys.StorageUsageSys)
+	}
+
+	mockSys.StorageRemove(uint64(len(key)), keyPtr, 0)
+	if mockSys.StorageUsageSys != 0 {
+		t.Errorf("expected storage usage 0 after remove, got %d", mockSys.StorageUsageSys)
+	}
+}
+
+func TestStorageStakingCheck(t *testing.T) {
+	mockSys := NewMockSystem()
+	mockSys.StorageUsageSys = 100
+
+	if err := mockSys.StorageStakingCheck(); err == nil {
+		t.Errorf("expected storage staking check to fail with zero balance")
+	}
+
+	// 100 bytes * 10^19 yoctoNEAR = 10^21 yoctoNEAR
+	mockSys.AccountBalanceSys, _ = types.U128FromString("1000000000000000000000")
+	if err := mockSys.StorageStakingCheck(); err != nil {
+		t.Errorf("expected storage staking check to pass, got %v", err)
+	}
+
+	mockSys.StorageUsageSys = 101
+	if err := mockSys.StorageStakingCheck(); err == nil {
+		t.Errorf("expected storage staking check to fail when usage grows past the balance")
+	}
+}
+
 func TestStorageHasKey(t *testing.T) {
 	mockSys := NewMockSystem()
 	key := "testKey"
@@ -313,6 +363,23 @@ func TestAttachedDeposit(t *testing.T) {
 	}
 }
 
+func TestAttachDeposit(t *testing.T) {
+	mockSys := NewMockSystem()
+	mockSys.AccountBalanceSys = types.Uint128{Hi: 0, Lo: 500}
+	deposit := types.Uint128{Hi: 0, Lo: 1000}
+
+	mockSys.AttachDeposit(deposit)
+
+	if mockSys.AttachedDepositSys != deposit {
+		t.Errorf("expected attached deposit %v, got %v", deposit, mockSys.AttachedDepositSys)
+	}
+
+	expected := types.Uint128{Hi: 0, Lo: 1500}
+	if mockSys.AccountBalanceSys != expected {
+		t.Errorf("expected balance %v, got %v", expected, mockSys.AccountBalanceSys)
+	}
+}
+
 func TestPrepaidGas(t *testing.T) {
 	mockSys := NewMockSystem()
 	expected := mockSys.PrepaidGasSys
@@ -693,10 +760,32 @@ func TestPromiseBatchActionFunctionCallWeight(t *testing.T) {
 
 func TestPromiseBatchActionTransfer(t *testing.T) {
 	mockSys := MockSystem{}
+	mockSys.AccountBalanceSys, _ = types.U128FromString("30000000000000000000000") // 0.03 Near
 	promiseIdx := uint64(0)
 	amount, _ := types.U128FromString("10000000000000000000000") // 0.01 Near
-	amountPtr := uintptr(unsafe.Pointer(&amount))
+	amountBytes := amount.ToLE()
+	amountPtr := uintptr(unsafe.Pointer(&amountBytes[0]))
 	mockSys.PromiseBatchActionTransfer(promiseIdx, uint64(amountPtr))
+
+	expected, _ := types.U128FromString("20000000000000000000000")
+	if mockSys.AccountBalanceSys != expected {
+		t.Errorf("expected balance %v, got %v", expected, mockSys.AccountBalanceSys)
+	}
+}
+
+func TestPromiseBatchActionTransferBalanceExceeded(t *testing.T) {
+	mockSys := MockSystem{}
+	amount := types.Uint128{Hi: 0, Lo: 1000}
+	amountBytes := amount.ToLE()
+	amountPtr := uintptr(unsafe.Pointer(&amountBytes[0]))
+
+	defer func() {
+		if r := recover(); r != ErrBalanceExceeded {
+			t.Errorf("expected panic %q, got %v", ErrBalanceExceeded, r)
+		}
+	}()
+
+	mockSys.PromiseBatchActionTransfer(0, uint64(amountPtr))
 }
 
 func TestPromiseBatchActionStake(t *testing.T) {
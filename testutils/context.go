@@ -0,0 +1,234 @@
+// Package testutils helps unit tests set up the blockchain context a contract method runs in.
+//
+// Instead of mutating system.MockSystem fields by hand, a test describes the call and installs a fresh
+// environment with Build:
+//
+//	ctx := testutils.NewContextBuilder().
+//		Predecessor("alice.near").
+//		Deposit(1).
+//		Input(map[string]string{"message": "hi"}).
+//		Build()
+//
+// Every Build starts from an empty storage unless State is given, so tests don't leak state into each other.
+package testutils
+
+import (
+	"encoding/json"
+	"unsafe"
+
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/system"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+const (
+	ErrProhibitedInView = "(TESTUTILS_ERROR): method is not allowed in view calls: "
+	ErrDepositInView    = "(TESTUTILS_ERROR): view calls can't have an attached deposit"
+	ErrInvalidInput     = "(TESTUTILS_ERROR): failed to encode the contract input: "
+)
+
+// ContextBuilder describes the context of a single function call.
+type ContextBuilder struct {
+	mock    *system.MockSystem
+	deposit types.Uint128
+	state   map[string][]byte
+	view    bool
+}
+
+// NewContextBuilder starts from the defaults of system.NewMockSystem with an empty input and no deposit.
+func NewContextBuilder() *ContextBuilder {
+	mock := system.NewMockSystem()
+	mock.ContractInput = []byte{}
+	return &ContextBuilder{mock: mock}
+}
+
+// CurrentAccount sets the account the contract is deployed on.
+func (b *ContextBuilder) CurrentAccount(accountID string) *ContextBuilder {
+	b.mock.CurrentAccountIdSys = accountID
+	return b
+}
+
+// Predecessor sets the account that called the method.
+func (b *ContextBuilder) Predecessor(accountID string) *ContextBuilder {
+	b.mock.PredecessorAccountIdSys = accountID
+	return b
+}
+
+// Signer sets the account that signed the transaction.
+func (b *ContextBuilder) Signer(accountID string) *ContextBuilder {
+	b.mock.SignerAccountIdSys = accountID
+	return b
+}
+
+// SignerPK sets the public key the transaction was signed with.
+func (b *ContextBuilder) SignerPK(publicKey []byte) *ContextBuilder {
+	b.mock.SignerAccountPkSys = publicKey
+	return b
+}
+
+// Deposit attaches the given number of NEAR to the call.
+func (b *ContextBuilder) Deposit(near uint64) *ContextBuilder {
+	oneNear, _ := types.U128FromString("1000000000000000000000000")
+	deposit, err := oneNear.Mul(types.U64ToUint128(near))
+	if err != nil {
+		panic(err)
+	}
+	return b.DepositYocto(deposit)
+}
+
+// DepositYocto attaches an exact amount of yoctoNEAR to the call, e.g. the one yocto required by NEP-141 transfers.
+func (b *ContextBuilder) DepositYocto(amount types.Uint128) *ContextBuilder {
+	b.deposit = amount
+	return b
+}
+
+// Balance sets the contract account balance before the deposit is credited.
+func (b *ContextBuilder) Balance(amount types.Uint128) *ContextBuilder {
+	b.mock.AccountBalanceSys = amount
+	return b
+}
+
+// Gas sets the gas attached to the call.
+func (b *ContextBuilder) Gas(gas uint64) *ContextBuilder {
+	b.mock.PrepaidGasSys = gas
+	return b
+}
+
+// BlockHeight sets the height of the block the call is executed in.
+func (b *ContextBuilder) BlockHeight(height uint64) *ContextBuilder {
+	b.mock.BlockIndexSys = height
+	return b
+}
+
+// Timestamp sets the block timestamp in nanoseconds.
+func (b *ContextBuilder) Timestamp(timestamp uint64) *ContextBuilder {
+	b.mock.BlockTimestampSys = timestamp
+	return b
+}
+
+// Input sets the arguments of the call. A []byte or a string is used as is, anything else is encoded to JSON.
+func (b *ContextBuilder) Input(args interface{}) *ContextBuilder {
+	switch value := args.(type) {
+	case []byte:
+		b.mock.ContractInput = value
+	case string:
+		b.mock.ContractInput = []byte(value)
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			panic(ErrInvalidInput + err.Error())
+		}
+		b.mock.ContractInput = data
+	}
+	return b
+}
+
+// State preloads the contract storage, for example with the Storage of a previous Context.
+func (b *ContextBuilder) State(storage map[string][]byte) *ContextBuilder {
+	b.state = storage
+	return b
+}
+
+// View makes the call a view call: state writes, deposits and promises are rejected, as on chain.
+func (b *ContextBuilder) View() *ContextBuilder {
+	b.view = true
+	return b
+}
+
+// Build installs the described context as the environment of the env package and returns it.
+func (b *ContextBuilder) Build() *Context {
+	if b.view && b.deposit.Cmp(types.Uint128{Hi: 0, Lo: 0}) != 0 {
+		panic(ErrDepositInView)
+	}
+
+	mock := *b.mock
+	mock.Registers = make(map[uint64][]byte)
+	mock.LogsSys = nil
+	mock.Storage = make(map[string][]byte, len(b.state))
+	for key, value := range b.state {
+		mock.Storage[key] = append([]byte{}, value...)
+		mock.StorageUsageSys += uint64(len(key)+len(value)) + system.StorageNumExtraBytesRecord
+	}
+	mock.AttachDeposit(b.deposit)
+
+	ctx := &Context{MockSystem: &mock, view: b.view}
+	env.SetEnv(ctx)
+	return ctx
+}
+
+// Context is the environment installed by ContextBuilder.Build.
+// The embedded MockSystem exposes the storage, balances and promises after the call.
+type Context struct {
+	*system.MockSystem
+	view bool
+}
+
+// Logs returns the messages logged since the context was built.
+func (ctx *Context) Logs() []string {
+	return ctx.LogsSys
+}
+
+func readBytes(length, ptr uint64) []byte {
+	if length == 0 {
+		return []byte{}
+	}
+	data := make([]byte, length)
+	copy(data, unsafe.Slice((*byte)(unsafe.Pointer(uintptr(ptr))), length))
+	return data
+}
+
+// recordPromise appends a function call promise to Promises with the arguments the contract passed.
+func (ctx *Context) recordPromise(accountIdLen, accountIdPtr, functionNameLen, functionNamePtr, argumentsLen, argumentsPtr, amountPtr, gas uint64) uint64 {
+	amount, _ := types.LoadUint128LE(readBytes(16, amountPtr))
+	index := uint64(len(ctx.Promises))
+	ctx.Promises = append(ctx.Promises, system.MockPromise{
+		AccountId:    string(readBytes(accountIdLen, accountIdPtr)),
+		FunctionName: string(readBytes(functionNameLen, functionNamePtr)),
+		Arguments:    readBytes(argumentsLen, argumentsPtr),
+		Amount:       amount,
+		Gas:          gas,
+		PromiseIndex: index,
+	})
+	return index
+}
+
+// IsView reports whether the context was built for a view call.
+func (ctx *Context) IsView() bool {
+	return ctx.view
+}
+
+func (ctx *Context) prohibitedInView(method string) {
+	if ctx.view {
+		panic(ErrProhibitedInView + method)
+	}
+}
+
+func (ctx *Context) StorageWrite(keyLen, keyPtr, valueLen, valuePtr, registerId uint64) uint64 {
+	ctx.prohibitedInView("storage_write")
+	return ctx.MockSystem.StorageWrite(keyLen, keyPtr, valueLen, valuePtr, registerId)
+}
+
+func (ctx *Context) StorageRemove(keyLen, keyPtr, registerId uint64) uint64 {
+	ctx.prohibitedInView("storage_remove")
+	return ctx.MockSystem.StorageRemove(keyLen, keyPtr, registerId)
+}
+
+func (ctx *Context) AttachedDeposit(balancePtr uint64) {
+	ctx.prohibitedInView("attached_deposit")
+	ctx.MockSystem.AttachedDeposit(balancePtr)
+}
+
+func (ctx *Context) PromiseCreate(accountIdLen, accountIdPtr, functionNameLen, functionNamePtr, argumentsLen, argumentsPtr, amountPtr, gas uint64) uint64 {
+	ctx.prohibitedInView("promise_create")
+	return ctx.recordPromise(accountIdLen, accountIdPtr, functionNameLen, functionNamePtr, argumentsLen, argumentsPtr, amountPtr, gas)
+}
+
+func (ctx *Context) PromiseThen(promiseIndex, accountIdLen, accountIdPtr, functionNameLen, functionNamePtr, argumentsLen, argumentsPtr, amountPtr, gas uint64) uint64 {
+	ctx.prohibitedInView("promise_then")
+	return ctx.recordPromise(accountIdLen, accountIdPtr, functionNameLen, functionNamePtr, argumentsLen, argumentsPtr, amountPtr, gas)
+}
+
+func (ctx *Context) PromiseBatchCreate(accountIdLen, accountIdPtr uint64) uint64 {
+	ctx.prohibitedInView("promise_batch_create")
+	return ctx.MockSystem.PromiseBatchCreate(accountIdLen, accountIdPtr)
+}
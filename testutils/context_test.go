@@ -0,0 +1,130 @@
+package testutils
+
+import (
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+func expectPanic(t *testing.T, expected string, fn func()) {
+	t.Helper()
+	defer func() {
+		t.Helper()
+		if r := recover(); r != expected {
+			t.Errorf("expected panic %q, got %v", expected, r)
+		}
+	}()
+	fn()
+}
+
+func TestBuildInstallsContext(t *testing.T) {
+	ctx := NewContextBuilder().
+		CurrentAccount("contract.near").
+		Predecessor("alice.near").
+		Signer("bob.near").
+		Deposit(2).
+		Gas(300 * types.ONE_TERA_GAS).
+		BlockHeight(42).
+		Timestamp(1_700_000_000_000_000_000).
+		Input(map[string]string{"message": "hi"}).
+		Build()
+
+	if env.NearBlockchainImports != ctx {
+		t.Fatalf("expected the context to be installed in env")
+	}
+
+	if accountID, _ := env.GetCurrentAccountId(); accountID != "contract.near" {
+		t.Errorf("expected contract.near, got %s", accountID)
+	}
+	if accountID, _ := env.GetPredecessorAccountID(); accountID != "alice.near" {
+		t.Errorf("expected alice.near, got %s", accountID)
+	}
+	if accountID, _ := env.GetSignerAccountID(); accountID != "bob.near" {
+		t.Errorf("expected bob.near, got %s", accountID)
+	}
+
+	twoNear, _ := types.U128FromString("2000000000000000000000000")
+	if deposit, _ := env.GetAttachedDeposit(); deposit.Cmp(twoNear) != 0 {
+		t.Errorf("expected 2 NEAR attached, got %s", deposit.String())
+	}
+	if balance, _ := env.GetAccountBalance(); balance.Cmp(twoNear) != 0 {
+		t.Errorf("expected the deposit to be credited to the balance, got %s", balance.String())
+	}
+	if gas := ctx.PrepaidGas(); gas != 300*types.ONE_TERA_GAS {
+		t.Errorf("expected 300 TGas, got %d", gas)
+	}
+	if ctx.BlockIndexSys != 42 {
+		t.Errorf("expected block height 42, got %d", ctx.BlockIndexSys)
+	}
+	if timeMs := env.GetBlockTimeMs(); timeMs != 1_700_000_000_000 {
+		t.Errorf("expected block time 1700000000000, got %d", timeMs)
+	}
+
+	env.LogString("called")
+	if logs := ctx.Logs(); len(logs) != 1 || logs[0] != "called" {
+		t.Errorf("expected the log to be recorded, got %q", logs)
+	}
+
+	env.PromiseCreate([]byte("receiver.near"), []byte("method"), []byte(`{}`), twoNear, 5*types.ONE_TERA_GAS)
+	if len(ctx.Promises) != 1 || ctx.Promises[0].AccountId != "receiver.near" || ctx.Promises[0].FunctionName != "method" ||
+		ctx.Promises[0].Amount.Cmp(twoNear) != 0 {
+		t.Errorf("expected the promise to be recorded, got %+v", ctx.Promises)
+	}
+
+	input, _, err := env.ContractInput(types.ContractInputOptions{IsRawBytes: true})
+	if err != nil || string(input) != `{"message":"hi"}` {
+		t.Errorf("expected JSON input, got %q (%v)", input, err)
+	}
+}
+
+func TestBuildResetsStorage(t *testing.T) {
+	first := NewContextBuilder().Build()
+	if _, err := env.StorageWrite([]byte("key"), []byte("value")); err != nil {
+		t.Fatalf("storage write failed: %v", err)
+	}
+
+	NewContextBuilder().Build()
+	if exists, _ := env.StorageHasKey([]byte("key")); exists {
+		t.Errorf("expected a fresh storage")
+	}
+
+	NewContextBuilder().State(first.Storage).Build()
+	if value, err := env.StorageRead([]byte("key")); err != nil || string(value) != "value" {
+		t.Errorf("expected the preloaded state, got %q (%v)", value, err)
+	}
+	if usage := env.GetStorageUsage(); usage != first.StorageUsageSys {
+		t.Errorf("expected storage usage %d, got %d", first.StorageUsageSys, usage)
+	}
+}
+
+func TestViewForbidsWritesAndDeposits(t *testing.T) {
+	NewContextBuilder().Build()
+	env.StorageWrite([]byte("key"), []byte("value"))
+	state := env.NearBlockchainImports.(*Context).Storage
+
+	ctx := NewContextBuilder().State(state).View().Build()
+	if !ctx.IsView() {
+		t.Fatalf("expected a view context")
+	}
+
+	if value, err := env.StorageRead([]byte("key")); err != nil || string(value) != "value" {
+		t.Errorf("expected reads to be allowed, got %q (%v)", value, err)
+	}
+	expectPanic(t, ErrProhibitedInView+"storage_write", func() {
+		env.StorageWrite([]byte("key"), []byte("other"))
+	})
+	expectPanic(t, ErrProhibitedInView+"storage_remove", func() {
+		env.StorageRemove([]byte("key"))
+	})
+	expectPanic(t, ErrProhibitedInView+"attached_deposit", func() {
+		env.GetAttachedDeposit()
+	})
+	expectPanic(t, ErrProhibitedInView+"promise_batch_create", func() {
+		env.PromiseBatchCreate([]byte("alice.near"))
+	})
+
+	expectPanic(t, ErrDepositInView, func() {
+		NewContextBuilder().DepositYocto(types.Uint128{Hi: 0, Lo: 1}).View().Build()
+	})
+}
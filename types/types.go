@@ -17,6 +17,9 @@ const (
 	ONE_MILI_NEAR = 1_000_000_000_000_000_000_000
 	ONE_TERA_GAS  = 1_000_000_000_000
 	ONE_GIGA_GAS  = 1_000_000_000
+
+	// STORAGE_PRICE_PER_BYTE is the amount of yoctoNEAR that has to be staked for every byte of storage.
+	STORAGE_PRICE_PER_BYTE = 10_000_000_000_000_000_000
 )
 
 const (
@@ -0,0 +1,219 @@
+// Package upgrade lets a contract replace its own code.
+//
+// The owner first stages the new code, which is kept in the contract storage with its sha256 hash. Once the
+// optional time lock is over, the owner deploys it, giving the hash it expects so the code can't be swapped
+// in between. The deployment is a batch on the contract account itself: DeployContract followed by a call to
+// the migrate method of the new code, which converts the state.
+//
+//	// @contract:mutating
+//	func (c *Contract) StageCode(code []byte) error {
+//		return c.Upgrader.Stage(code, c.Owner)
+//	}
+//
+//	// @contract:mutating
+//	func (c *Contract) DeployStagedCode(codeHash string) error {
+//		_, err := c.Upgrader.Deploy(codeHash, nil, c.Owner)
+//		return err
+//	}
+//
+// Code hashes are base58 encoded, like the code_hash NEAR RPC reports for the account.
+package upgrade
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/mr-tron/base58"
+
+	"github.com/vlmoon99/near-sdk-go/access"
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/events"
+	"github.com/vlmoon99/near-sdk-go/promise"
+	"github.com/vlmoon99/near-sdk-go/types"
+)
+
+const (
+	// DefaultMigrateMethod is the method of the new code called after the deployment.
+	DefaultMigrateMethod = "migrate"
+
+	// DefaultMigrateGas is the gas attached to the migrate call.
+	DefaultMigrateGas = 50 * types.ONE_TERA_GAS
+)
+
+const (
+	ErrEmptyCode             = "(UPGRADE_ERROR): the code is empty"
+	ErrNothingStaged         = "(UPGRADE_ERROR): no code is staged"
+	ErrTimeLocked            = "(UPGRADE_ERROR): the staged code can't be deployed before "
+	ErrHashMismatch          = "(UPGRADE_ERROR): the staged code hash is "
+	ErrCodeCorrupted         = "(UPGRADE_ERROR): the staged code doesn't match the hash it was staged with"
+	ErrHashFailed            = "(UPGRADE_ERROR): failed to hash the code: "
+	ErrStorageFailed         = "(UPGRADE_ERROR): failed to access the staged code: "
+	ErrCurrentAccountUnknown = "(UPGRADE_ERROR): failed to get the current account: "
+)
+
+// The standard and version of the upgrade events.
+const (
+	EventStandard = "upgrade"
+	EventVersion  = "1.0.0"
+)
+
+// Upgrade events.
+var (
+	CodeStagedEvent   = events.NewKind[CodeStaged](EventStandard, EventVersion, "code_staged")
+	CodeDeployedEvent = events.NewKind[CodeDeployed](EventStandard, EventVersion, "code_deployed")
+)
+
+// CodeStaged is the data of the code_staged event. Timestamps are in milliseconds.
+type CodeStaged struct {
+	CodeHash    string `json:"code_hash"`
+	AvailableAt string `json:"available_at"`
+}
+
+// CodeDeployed is the data of the code_deployed event.
+type CodeDeployed struct {
+	CodeHash string `json:"code_hash"`
+}
+
+// StagedCode is the view of the staged code. Timestamps are in milliseconds.
+type StagedCode struct {
+	CodeHash    string `json:"code_hash"`
+	StagedAt    uint64 `json:"staged_at"`
+	AvailableAt uint64 `json:"available_at"`
+}
+
+// Upgrader stages and deploys the code of the contract. The code itself is stored under Prefix, outside the
+// contract state, so loading the state doesn't read it.
+type Upgrader struct {
+	Prefix string `json:"prefix"`
+	// Delay is the time lock in milliseconds between staging and deploying code.
+	Delay         uint64 `json:"delay"`
+	MigrateMethod string `json:"migrate_method"`
+	MigrateGas    uint64 `json:"migrate_gas"`
+	StagedHash    string `json:"staged_hash"`
+	StagedAt      uint64 `json:"staged_at"`
+}
+
+// New creates an upgrader storing the staged code under prefix, with a time lock of delay milliseconds.
+func New(prefix string, delay uint64) *Upgrader {
+	return &Upgrader{
+		Prefix:        prefix,
+		Delay:         delay,
+		MigrateMethod: DefaultMigrateMethod,
+		MigrateGas:    DefaultMigrateGas,
+	}
+}
+
+// CodeHash returns the base58 encoded sha256 hash of code.
+func CodeHash(code []byte) (string, error) {
+	if len(code) == 0 {
+		return "", errors.New(ErrEmptyCode)
+	}
+	hash, err := env.Sha256Hash(code)
+	if err != nil {
+		return "", errors.New(ErrHashFailed + err.Error())
+	}
+	return base58.Encode(hash), nil
+}
+
+func (u *Upgrader) codeKey() []byte {
+	return []byte(u.Prefix + ":code")
+}
+
+// Stage replaces the staged code with code and restarts the time lock. Only the owner can stage code.
+func (u *Upgrader) Stage(code []byte, owner *access.Ownable) error {
+	if err := owner.RequireOwner(); err != nil {
+		return err
+	}
+	hash, err := CodeHash(code)
+	if err != nil {
+		return err
+	}
+	if _, err := env.StorageWrite(u.codeKey(), code); err != nil {
+		return errors.New(ErrStorageFailed + err.Error())
+	}
+	u.StagedHash = hash
+	u.StagedAt = env.GetBlockTimeMs()
+	CodeStagedEvent.Emit(CodeStaged{CodeHash: hash, AvailableAt: strconv.FormatUint(u.availableAt(), 10)})
+	return nil
+}
+
+// Unstage drops the staged code. Only the owner can unstage code.
+func (u *Upgrader) Unstage(owner *access.Ownable) error {
+	if err := owner.RequireOwner(); err != nil {
+		return err
+	}
+	if u.StagedHash == "" {
+		return errors.New(ErrNothingStaged)
+	}
+	return u.clear()
+}
+
+func (u *Upgrader) clear() error {
+	if _, err := env.StorageRemove(u.codeKey()); err != nil {
+		return errors.New(ErrStorageFailed + err.Error())
+	}
+	u.StagedHash, u.StagedAt = "", 0
+	return nil
+}
+
+func (u *Upgrader) availableAt() uint64 {
+	return u.StagedAt + u.Delay
+}
+
+// Staged returns the staged code, or nil when there is none.
+func (u *Upgrader) Staged() *StagedCode {
+	if u.StagedHash == "" {
+		return nil
+	}
+	return &StagedCode{CodeHash: u.StagedHash, StagedAt: u.StagedAt, AvailableAt: u.availableAt()}
+}
+
+// Deploy deploys the staged code to the contract account and calls its migrate method with migrateArgs,
+// an empty object when nil. expectedHash must be the hash of the staged code and the time lock must be
+// over. Only the owner can deploy code.
+//
+// The staged code is removed; if the deployment or the migration fails, the batch is reverted as a whole
+// and the contract keeps its current code, but the code has to be staged again.
+func (u *Upgrader) Deploy(expectedHash string, migrateArgs interface{}, owner *access.Ownable) (*promise.PromiseBatch, error) {
+	if err := owner.RequireOwner(); err != nil {
+		return nil, err
+	}
+	if u.StagedHash == "" {
+		return nil, errors.New(ErrNothingStaged)
+	}
+	if u.StagedHash != expectedHash {
+		return nil, errors.New(ErrHashMismatch + u.StagedHash)
+	}
+	if now := env.GetBlockTimeMs(); now < u.availableAt() {
+		return nil, errors.New(ErrTimeLocked + strconv.FormatUint(u.availableAt(), 10))
+	}
+
+	code, err := env.StorageRead(u.codeKey())
+	if err != nil {
+		return nil, errors.New(ErrStorageFailed + err.Error())
+	}
+	hash, err := CodeHash(code)
+	if err != nil {
+		return nil, err
+	}
+	if hash != u.StagedHash {
+		return nil, errors.New(ErrCodeCorrupted)
+	}
+	accountID, err := env.GetCurrentAccountId()
+	if err != nil {
+		return nil, errors.New(ErrCurrentAccountUnknown + err.Error())
+	}
+	if err := u.clear(); err != nil {
+		return nil, err
+	}
+
+	batch := promise.CreateBatch(accountID).DeployContract(code)
+	if u.MigrateMethod != "" {
+		if migrateArgs == nil {
+			migrateArgs = struct{}{}
+		}
+		batch = batch.FunctionCall(u.MigrateMethod, migrateArgs, types.Uint128{Hi: 0, Lo: 0}, u.MigrateGas)
+	}
+	CodeDeployedEvent.Emit(CodeDeployed{CodeHash: hash})
+	return batch, nil
+}
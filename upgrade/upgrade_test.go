@@ -0,0 +1,148 @@
+package upgrade
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/vlmoon99/near-sdk-go/access"
+	"github.com/vlmoon99/near-sdk-go/contract"
+	"github.com/vlmoon99/near-sdk-go/env"
+	"github.com/vlmoon99/near-sdk-go/sim"
+)
+
+const (
+	contractID = "app.near"
+	ownerID    = "owner.near"
+	stateKey   = "STATE"
+	delay      = 10_000
+)
+
+var newCode = []byte("new contract code")
+
+type state struct {
+	Owner    *access.Ownable
+	Upgrader *Upgrader
+}
+
+func currentContract() sim.Methods {
+	return sim.Methods{
+		"init": sim.Init(func(s *state) error {
+			s.Owner = access.NewOwnable(ownerID)
+			s.Upgrader = New("u", delay)
+			return nil
+		}),
+		"stage_code": sim.Method(func(s *state) (interface{}, error) {
+			return nil, s.Upgrader.Stage(sim.RawInput(), s.Owner)
+		}),
+		"unstage_code": sim.Method(func(s *state) (interface{}, error) {
+			return nil, s.Upgrader.Unstage(s.Owner)
+		}),
+		"deploy_staged_code": sim.Method(func(s *state) (interface{}, error) {
+			var args struct {
+				CodeHash string `json:"code_hash"`
+			}
+			sim.Input(&args)
+			_, err := s.Upgrader.Deploy(args.CodeHash, map[string]string{"version": "2"}, s.Owner)
+			return nil, err
+		}),
+	}
+}
+
+func nextContract() sim.Methods {
+	return sim.Methods{
+		"migrate": func() {
+			var args struct {
+				Version string `json:"version"`
+			}
+			sim.Input(&args)
+			env.StorageWrite([]byte("version"), []byte(args.Version))
+		},
+		"version": func() {
+			version, _ := env.StorageRead([]byte("version"))
+			contract.ReturnValue(string(version))
+		},
+	}
+}
+
+func setupChain(t *testing.T) *sim.Chain {
+	t.Helper()
+	chain := sim.Setup(t, sim.NEAR(100), map[string]sim.Contract{contractID: currentContract()}, contractID, ownerID, "mallory.near")
+	chain.RegisterCode(newCode, nextContract())
+	if result := chain.MustCall(t, ownerID, contractID, "init", nil); result.Failed() {
+		t.Fatalf("failed to init: %v", result.Failure)
+	}
+	return chain
+}
+
+func expectFailure(t *testing.T, result *sim.Result, expected string) {
+	t.Helper()
+	if result.Failure == nil || result.Failure.Error() != sim.ErrContractPanicPrefix+expected {
+		t.Errorf("expected %q, got %v", expected, result.Failure)
+	}
+}
+
+func stagedHash(t *testing.T, chain *sim.Chain) string {
+	t.Helper()
+	var s state
+	if err := json.Unmarshal(chain.Account(contractID).Storage[stateKey], &s); err != nil {
+		t.Fatalf("failed to read the state: %v", err)
+	}
+	return s.Upgrader.StagedHash
+}
+
+func TestUpgrade(t *testing.T) {
+	chain := setupChain(t)
+
+	expectFailure(t, chain.MustCall(t, "mallory.near", contractID, "stage_code", newCode), access.ErrNotOwner)
+	result := chain.MustCall(t, ownerID, contractID, "stage_code", newCode)
+	if result.Failed() {
+		t.Fatalf("failed to stage: %v", result.Failure)
+	}
+	hash := stagedHash(t, chain)
+	staged, _ := CodeStagedEvent.Find(result.Logs())
+	if len(staged) != 1 || staged[0].CodeHash != hash || hash == "" {
+		t.Errorf("unexpected code_staged events %+v for %s", staged, hash)
+	}
+
+	deploy := map[string]string{"code_hash": hash}
+	expectFailure(t, chain.MustCall(t, ownerID, contractID, "deploy_staged_code", map[string]string{"code_hash": "wrong"}), ErrHashMismatch+hash)
+	if result := chain.MustCall(t, ownerID, contractID, "deploy_staged_code", deploy); result.Failure == nil {
+		t.Errorf("expected the time lock to reject the deployment")
+	}
+
+	chain.FastForward(delay / 1000)
+	expectFailure(t, chain.MustCall(t, "mallory.near", contractID, "deploy_staged_code", deploy), access.ErrNotOwner)
+	result = chain.MustCall(t, ownerID, contractID, "deploy_staged_code", deploy)
+	if result.Failed() || len(result.ReceiptFailures()) != 0 {
+		t.Fatalf("failed to deploy: %v %v", result.Failure, result.ReceiptFailures())
+	}
+	if deployed, _ := CodeDeployedEvent.Find(result.Logs()); len(deployed) != 1 || deployed[0].CodeHash != hash {
+		t.Errorf("unexpected code_deployed events %+v", deployed)
+	}
+
+	if string(chain.Account(contractID).Code) != string(newCode) {
+		t.Errorf("expected the new code to be deployed")
+	}
+	view, err := chain.View(contractID, "version", nil)
+	var version string
+	if err != nil || view.Unmarshal(&version) != nil || version != "2" {
+		t.Errorf("expected migrate to run, got version %q: %v", version, err)
+	}
+	if _, staged := chain.Account(contractID).Storage["u:code"]; staged {
+		t.Errorf("expected the staged code to be removed")
+	}
+}
+
+func TestUnstage(t *testing.T) {
+	chain := setupChain(t)
+	expectFailure(t, chain.MustCall(t, ownerID, contractID, "unstage_code", nil), ErrNothingStaged)
+
+	chain.MustCall(t, ownerID, contractID, "stage_code", newCode)
+	if result := chain.MustCall(t, ownerID, contractID, "unstage_code", nil); result.Failed() {
+		t.Fatalf("failed to unstage: %v", result.Failure)
+	}
+	if hash := stagedHash(t, chain); hash != "" {
+		t.Errorf("expected nothing to be staged, got %s", hash)
+	}
+	expectFailure(t, chain.MustCall(t, ownerID, contractID, "deploy_staged_code", map[string]string{"code_hash": ""}), ErrNothingStaged)
+}